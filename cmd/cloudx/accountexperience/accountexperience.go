@@ -14,7 +14,6 @@ import (
 
 	client "github.com/ory/cli/cmd/cloudx/client"
 	cloud "github.com/ory/client-go"
-	"github.com/ory/x/cmdx"
 )
 
 func NewAccountExperienceOpenCmd() *cobra.Command {
@@ -42,11 +41,11 @@ func NewAxCmd(cmd string) *cobra.Command {
 			}
 			id, err := getSelectedProjectId(h, args)
 			if err != nil {
-				return cmdx.PrintOpenAPIError(cmd, err)
+				return client.PrintOpenAPIError(cmd, err)
 			}
 			project, err := h.GetProject(id)
 			if err != nil {
-				return cmdx.PrintOpenAPIError(cmd, err)
+				return client.PrintOpenAPIError(cmd, err)
 			}
 			return AxWrapper(cmd, project)
 
@@ -28,6 +28,7 @@ func NewAuthCmd() *cobra.Command {
 		},
 	}
 	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
 	client.RegisterYesFlag(cmd.PersistentFlags())
 	cmdx.RegisterNoiseFlags(cmd.PersistentFlags())
 	cmdx.RegisterFormatFlags(cmd.Flags())
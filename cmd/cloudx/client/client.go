@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"time"
 
 	cloud "github.com/ory/client-go"
@@ -20,7 +19,6 @@ import (
 	hydra "github.com/ory/hydra-client-go"
 	hydracli "github.com/ory/hydra/cmd/cliclient"
 	kratoscli "github.com/ory/kratos/cmd/cliclient"
-	"github.com/ory/x/cmdx"
 	"github.com/ory/x/flagx"
 )
 
@@ -37,19 +35,42 @@ func ProjectOrDefault(cmd *cobra.Command, h *CommandHelper) (string, error) {
 		if id := h.GetDefaultProjectID(); id != "" {
 			return id, nil
 		} else {
-			_, _ = fmt.Fprintf(os.Stderr, "No project selected! Please use the flag --%s to specify one.\n", projectFlag)
-			return "", cmdx.FailSilently(cmd)
+			return "", Fail(cmd, cmd.ErrOrStderr(), fmt.Sprintf("No project selected! Please use the flag --%s to specify one.", projectFlag))
 		}
 	} else {
 		return flag, nil
 	}
 }
 
+type resolvedProjectKey struct{}
+
+// ResolveProject resolves the project set with the `--project` flag, or the default project, to its ID and stores it in
+// the context of the command. It is meant to be used as PreRunE, so that all command helpers created for the command
+// afterwards return the project from ProjectID instead of resolving it again.
+func ResolveProject(cmd *cobra.Command, _ []string) error {
+	h, err := NewCommandHelper(cmd)
+	if err != nil {
+		return err
+	}
+
+	projectOrSlug, err := ProjectOrDefault(cmd, h)
+	if err != nil {
+		return err
+	}
+
+	id, err := h.ResolveProjectID(projectOrSlug)
+	if err != nil {
+		return err
+	}
+
+	cmd.SetContext(context.WithValue(cmd.Context(), resolvedProjectKey{}, id))
+	return nil
+}
+
 func Client(cmd *cobra.Command) (*retryablehttp.Client, *AuthContext, *cloud.Project, error) {
 	sc, err := NewCommandHelper(cmd)
 	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Failed to initialize HTTP Client: %s\n", err)
-		return nil, nil, nil, cmdx.FailSilently(cmd)
+		return nil, nil, nil, Fail(cmd, cmd.ErrOrStderr(), fmt.Sprintf("Failed to initialize HTTP Client: %s", err))
 	}
 
 	ac, err := sc.EnsureContext()
@@ -59,7 +80,7 @@ func Client(cmd *cobra.Command) (*retryablehttp.Client, *AuthContext, *cloud.Pro
 
 	projectOrSlug, err := ProjectOrDefault(cmd, sc)
 	if err != nil {
-		return nil, nil, nil, cmdx.FailSilently(cmd)
+		return nil, nil, nil, err
 	}
 
 	p, err := sc.GetProject(projectOrSlug)
@@ -100,7 +121,7 @@ func ContextWithClient(ctx context.Context) context.Context {
 			Timeout:   time.Second * 10,
 		}
 
-		consoleURL, err := url.ParseRequestURI(makeCloudConsoleURL(p.Slug + ".projects"))
+		consoleURL, err := url.ParseRequestURI(makeCloudConsoleURL(ConsoleURL(cmd), p.Slug+".projects"))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -117,7 +138,7 @@ func ContextWithClient(ctx context.Context) context.Context {
 
 		// We use the cloud console API because it works with ory cloud session tokens.
 		return &kratoscli.ClientContext{
-			Endpoint: makeCloudConsoleURL(p.Slug + ".projects"),
+			Endpoint: makeCloudConsoleURL(ConsoleURL(cmd), p.Slug+".projects"),
 			HTTPClient: &http.Client{
 				Transport: &bearerTokenTransporter{
 					RoundTripper: c.StandardClient().Transport,
@@ -0,0 +1,32 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	NoColorFlag   = "no-color"
+	noColorEnvVar = "NO_COLOR"
+)
+
+// RegisterNoColorFlag registers the --no-color flag on the root command and all its children.
+func RegisterNoColorFlag(root *cobra.Command) {
+	root.PersistentFlags().Bool(NoColorFlag, false, "Disable colored output. Setting the "+noColorEnvVar+" environment variable has the same effect.")
+}
+
+// ColorEnabled reports whether the command may style its output with ANSI colors. Colors are disabled by the
+// --no-color flag and, following https://no-color.org, by a non-empty NO_COLOR environment variable. Callers still
+// need to check that they write to a terminal.
+func ColorEnabled(cmd *cobra.Command) bool {
+	if len(os.Getenv(noColorEnvVar)) > 0 {
+		return false
+	}
+
+	f := cmd.Flag(NoColorFlag)
+	return f == nil || f.Value.String() != "true"
+}
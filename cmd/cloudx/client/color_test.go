@@ -0,0 +1,39 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColorEnabled(t *testing.T) {
+	newCmd := func(t *testing.T, args ...string) *cobra.Command {
+		root := &cobra.Command{Use: "root"}
+		RegisterNoColorFlag(root)
+		child := &cobra.Command{Use: "child"}
+		root.AddCommand(child)
+		require.NoError(t, root.PersistentFlags().Parse(args))
+		return child
+	}
+
+	t.Setenv(noColorEnvVar, "")
+
+	t.Run("case=enabled by default", func(t *testing.T) {
+		assert.True(t, ColorEnabled(newCmd(t)))
+		assert.True(t, ColorEnabled(&cobra.Command{}))
+	})
+
+	t.Run("case=disabled by the flag", func(t *testing.T) {
+		assert.False(t, ColorEnabled(newCmd(t, "--"+NoColorFlag)))
+	})
+
+	t.Run("case=disabled by the environment", func(t *testing.T) {
+		t.Setenv(noColorEnvVar, "1")
+		assert.False(t, ColorEnabled(newCmd(t)))
+	})
+}
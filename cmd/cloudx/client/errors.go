@@ -0,0 +1,182 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/cmdx"
+)
+
+const JSONErrorsFlag = "json-errors"
+
+// jsonErrorsValue is a boolean flag which silences cobra's own error and usage output as soon as it is parsed, so that
+// the only thing written to stderr is the JSON error.
+type jsonErrorsValue struct {
+	root    *cobra.Command
+	enabled bool
+}
+
+func (v *jsonErrorsValue) Set(s string) error {
+	enabled, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	v.enabled = enabled
+	v.root.SilenceErrors = enabled
+	v.root.SilenceUsage = enabled
+	return nil
+}
+
+func (v *jsonErrorsValue) String() string {
+	return strconv.FormatBool(v.enabled)
+}
+
+func (*jsonErrorsValue) Type() string {
+	return "bool"
+}
+
+func (*jsonErrorsValue) IsBoolFlag() bool {
+	return true
+}
+
+// RegisterJSONErrorsFlag registers the --json-errors flag on the root command and all its children.
+func RegisterJSONErrorsFlag(root *cobra.Command) {
+	root.PersistentFlags().VarPF(&jsonErrorsValue{root: root}, JSONErrorsFlag, "", "Print errors as JSON objects to stderr.").NoOptDefVal = "true"
+}
+
+// JSONErrors reports whether the command should print errors as JSON.
+func JSONErrors(cmd *cobra.Command) bool {
+	f := cmd.Flag(JSONErrorsFlag)
+	return f != nil && f.Value.String() == "true"
+}
+
+// PrintOpenAPIError behaves like cmdx.PrintOpenAPIError, unless errors are printed as JSON. In that case the error is
+// returned as is and printed by PrintJSONError.
+func PrintOpenAPIError(cmd *cobra.Command, err error) error {
+	if JSONErrors(cmd) {
+		return err
+	}
+	return cmdx.PrintOpenAPIError(cmd, err)
+}
+
+// WrapJSONErrors changes a command which prints its own errors and fails silently, such as the commands of Ory Hydra
+// and Ory Keto, so that what it printed to stderr is returned as the error when errors are printed as JSON.
+func WrapJSONErrors(cmd *cobra.Command) {
+	run := cmd.RunE
+	if run == nil {
+		return
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if !JSONErrors(cmd) {
+			return run(cmd, args)
+		}
+
+		var printed bytes.Buffer
+		stderr := cmd.ErrOrStderr()
+		cmd.SetErr(&printed)
+		err := run(cmd, args)
+		cmd.SetErr(stderr)
+
+		if !errors.Is(err, cmdx.ErrNoPrintButFail) {
+			_, _ = printed.WriteTo(stderr)
+			return err
+		}
+		if message := strings.TrimSpace(printed.String()); len(message) > 0 {
+			return errors.New(message)
+		}
+		return errors.New("the command failed")
+	}
+}
+
+// DetailedError is an error with structured details, which PrintJSONError adds to the JSON object.
+type DetailedError struct {
+	Message string
+	Details interface{}
+}
+
+func (e *DetailedError) Error() string {
+	return e.Message
+}
+
+// Fail prints the message to w and fails silently, unless errors are printed as JSON. In that case nothing is printed
+// and the message is returned as an error for PrintJSONError.
+func Fail(cmd *cobra.Command, w io.Writer, message string) error {
+	return FailWithDetails(cmd, w, message, nil)
+}
+
+// FailWithDetails behaves like Fail, but adds the details to the JSON error.
+func FailWithDetails(cmd *cobra.Command, w io.Writer, message string, details interface{}) error {
+	if JSONErrors(cmd) {
+		return errors.WithStack(&DetailedError{Message: message, Details: details})
+	}
+	_, _ = fmt.Fprintln(w, message)
+	return cmdx.FailSilently(cmd)
+}
+
+// FailWithErrors prints the message to w and the errors, keyed by the input they belong to, with cmdx.PrintErrors and
+// fails silently, unless errors are printed as JSON. In that case the errors are returned as the details of the JSON
+// error instead.
+func FailWithErrors(cmd *cobra.Command, w io.Writer, message string, errs map[string]error) error {
+	if JSONErrors(cmd) {
+		details := make(map[string]string, len(errs))
+		for k, err := range errs {
+			details[k] = err.Error()
+		}
+		return errors.WithStack(&DetailedError{Message: message, Details: details})
+	}
+	_, _ = fmt.Fprintln(w, message)
+	cmdx.PrintErrors(cmd, errs)
+	return cmdx.FailSilently(cmd)
+}
+
+type jsonError struct {
+	Type      string          `json:"type"`
+	Message   string          `json:"message"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+// PrintJSONError writes the error as a JSON object. Errors coming from the Ory APIs include the reason, details, and
+// request ID of the API response.
+func PrintJSONError(w io.Writer, err error) {
+	out := jsonError{Type: "error", Message: err.Error()}
+
+	var de *DetailedError
+	if errors.As(err, &de) && de.Details != nil {
+		if details, err := json.Marshal(de.Details); err == nil {
+			out.Details = details
+		}
+	}
+
+	var be interface{ Body() []byte }
+	if errors.As(err, &be) && gjson.ValidBytes(be.Body()) {
+		body := gjson.ParseBytes(be.Body())
+		out.Type = "api_error"
+		if message := body.Get("error.message"); message.Exists() {
+			out.Message = message.String()
+		}
+		if reason := body.Get("error.reason"); reason.Exists() {
+			out.Message += ": " + reason.String()
+		}
+		if details := body.Get("error.details"); details.Exists() {
+			out.Details = json.RawMessage(details.Raw)
+		}
+		out.RequestID = body.Get("error.request").String()
+	}
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	_ = e.Encode(out)
+}
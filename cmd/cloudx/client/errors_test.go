@@ -0,0 +1,143 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/cmdx"
+)
+
+type bodyError []byte
+
+func (e bodyError) Error() string { return "404 Not Found" }
+func (e bodyError) Body() []byte  { return e }
+
+func TestPrintJSONError(t *testing.T) {
+	t.Run("case=plain error", func(t *testing.T) {
+		var buf bytes.Buffer
+		PrintJSONError(&buf, errors.New("something went wrong"))
+		assert.JSONEq(t, `{"type":"error","message":"something went wrong"}`, buf.String())
+	})
+
+	t.Run("case=api error", func(t *testing.T) {
+		var buf bytes.Buffer
+		PrintJSONError(&buf, errors.WithStack(bodyError(`{"error":{"code":404,"message":"The requested resource could not be found","reason":"Project does not exist.","request":"req-123","details":{"id":"abc"}}}`)))
+		assert.JSONEq(t, `{"type":"api_error","message":"The requested resource could not be found: Project does not exist.","details":{"id":"abc"},"request_id":"req-123"}`, buf.String())
+	})
+}
+
+func TestJSONErrorsFlag(t *testing.T) {
+	var returned error
+	root := &cobra.Command{Use: "root"}
+	child := &cobra.Command{Use: "child", RunE: func(cmd *cobra.Command, _ []string) error {
+		returned = PrintOpenAPIError(cmd, bodyError(`{"error":{"message":"not found"}}`))
+		return returned
+	}}
+	root.AddCommand(child)
+	RegisterJSONErrorsFlag(root)
+
+	var stderr bytes.Buffer
+	root.SetErr(&stderr)
+	root.SetArgs([]string{"child", "--json-errors"})
+	require.Error(t, root.Execute())
+
+	assert.True(t, JSONErrors(root))
+	assert.True(t, root.SilenceErrors)
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, bodyError(`{"error":{"message":"not found"}}`), returned)
+}
+
+func TestFailWithErrors(t *testing.T) {
+	newCmd := func(t *testing.T, args ...string) (*cobra.Command, *bytes.Buffer) {
+		root := &cobra.Command{Use: "root"}
+		RegisterJSONErrorsFlag(root)
+		require.NoError(t, root.ParseFlags(args))
+
+		var stderr bytes.Buffer
+		root.SetErr(&stderr)
+		return root, &stderr
+	}
+	errs := map[string]error{"a.json": errors.New("the identity is invalid")}
+
+	t.Run("case=plain output", func(t *testing.T) {
+		cmd, stderr := newCmd(t)
+		err := FailWithErrors(cmd, cmd.ErrOrStderr(), "Imported 0 of 1 identities, 1 failed.", errs)
+		assert.ErrorIs(t, err, cmdx.ErrNoPrintButFail)
+		assert.Equal(t, "Imported 0 of 1 identities, 1 failed.\na.json: the identity is invalid\n", stderr.String())
+	})
+
+	t.Run("case=json errors", func(t *testing.T) {
+		cmd, stderr := newCmd(t, "--"+JSONErrorsFlag)
+		err := FailWithErrors(cmd, cmd.ErrOrStderr(), "Imported 0 of 1 identities, 1 failed.", errs)
+		assert.NotErrorIs(t, err, cmdx.ErrNoPrintButFail)
+		assert.Empty(t, stderr.String())
+
+		var buf bytes.Buffer
+		PrintJSONError(&buf, err)
+		assert.JSONEq(t, `{"type":"error","message":"Imported 0 of 1 identities, 1 failed.","details":{"a.json":"the identity is invalid"}}`, buf.String())
+	})
+
+	t.Run("case=json errors without details", func(t *testing.T) {
+		cmd, stderr := newCmd(t, "--"+JSONErrorsFlag)
+		err := Fail(cmd, cmd.ErrOrStderr(), "No project selected!")
+		assert.Empty(t, stderr.String())
+
+		var buf bytes.Buffer
+		PrintJSONError(&buf, err)
+		assert.JSONEq(t, `{"type":"error","message":"No project selected!"}`, buf.String())
+	})
+}
+
+func TestWrapJSONErrors(t *testing.T) {
+	newCmd := func(t *testing.T, run func(cmd *cobra.Command, _ []string) error, args ...string) (*cobra.Command, *bytes.Buffer) {
+		root := &cobra.Command{Use: "root"}
+		child := &cobra.Command{Use: "child", RunE: run}
+		root.AddCommand(child)
+		RegisterJSONErrorsFlag(root)
+		WrapJSONErrors(child)
+
+		var stderr bytes.Buffer
+		root.SetErr(&stderr)
+		root.SetArgs(append([]string{"child"}, args...))
+		return root, &stderr
+	}
+	failSilently := func(cmd *cobra.Command, _ []string) error {
+		_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "Unable to find the OAuth2 client.")
+		return cmdx.FailSilently(cmd)
+	}
+
+	t.Run("case=plain output", func(t *testing.T) {
+		root, stderr := newCmd(t, failSilently)
+		assert.ErrorIs(t, root.Execute(), cmdx.ErrNoPrintButFail)
+		assert.Equal(t, "Unable to find the OAuth2 client.\n", stderr.String())
+	})
+
+	t.Run("case=json errors", func(t *testing.T) {
+		root, stderr := newCmd(t, failSilently, "--"+JSONErrorsFlag)
+		err := root.Execute()
+		assert.NotErrorIs(t, err, cmdx.ErrNoPrintButFail)
+		assert.Empty(t, stderr.String())
+
+		var buf bytes.Buffer
+		PrintJSONError(&buf, err)
+		assert.JSONEq(t, `{"type":"error","message":"Unable to find the OAuth2 client."}`, buf.String())
+	})
+
+	t.Run("case=json errors keep other output", func(t *testing.T) {
+		root, stderr := newCmd(t, func(cmd *cobra.Command, _ []string) error {
+			_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "Created the OAuth2 client.")
+			return nil
+		}, "--"+JSONErrorsFlag)
+		require.NoError(t, root.Execute())
+		assert.Equal(t, "Created the OAuth2 client.\n", stderr.String())
+	})
+}
@@ -17,6 +17,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/gofrs/uuid/v3"
 	"github.com/imdario/mergo"
@@ -30,6 +31,7 @@ import (
 	"github.com/ory/x/cmdx"
 	"github.com/ory/x/flagx"
 	"github.com/ory/x/jsonx"
+	"github.com/ory/x/stringslice"
 	"github.com/ory/x/stringsx"
 )
 
@@ -54,6 +56,8 @@ type AuthContext struct {
 	SessionToken    string       `json:"session_token"`
 	SelectedProject uuid.UUID    `json:"selected_project"`
 	IdentityTraits  AuthIdentity `json:"session_identity_traits"`
+	ConsoleURL      string       `json:"console_url,omitempty"`
+	Format          string       `json:"format,omitempty"`
 }
 
 func (i *AuthContext) ID() string {
@@ -88,6 +92,9 @@ type AuthProject struct {
 
 var ErrNoConfig = stderrs.New("no ory configuration file present")
 var ErrNoConfigQuiet = stderrs.New("please run `ory auth` to initialize your configuration or remove the `--quiet` flag")
+var ErrProjectNotFound = stderrs.New("the project does not exist or you do not have access to it")
+var ErrIdentityNotFound = stderrs.New("the identity does not exist")
+var ErrSessionNotFound = stderrs.New("the session does not exist or has already expired")
 
 func getConfigPath(cmd *cobra.Command) (string, error) {
 	path, err := os.UserHomeDir()
@@ -110,8 +117,15 @@ type CommandHelper struct {
 	NoConfirm        bool
 	IsQuiet          bool
 	APIDomain        *url.URL
+	ConsoleURL       *url.URL
 	Stdin            *bufio.Reader
 	PwReader         passwordReader
+
+	// projectID is the project resolved by ResolveProject, if any.
+	projectID string
+
+	// projectClients caches the API clients of projects by the ID or slug they were requested with.
+	projectClients map[string]*cloud.APIClient
 }
 
 type PasswordReader struct{}
@@ -123,6 +137,11 @@ func NewCommandHelper(cmd *cobra.Command) (*CommandHelper, error) {
 		return nil, err
 	}
 
+	// Fail instead of silently talking to the default console when the console URL is malformed.
+	if err := ValidateConsoleURL(cmd); err != nil {
+		return nil, err
+	}
+
 	var out = cmd.OutOrStdout()
 	if flagx.MustGetBool(cmd, cmdx.FlagQuiet) {
 		out = io.Discard
@@ -140,7 +159,7 @@ func NewCommandHelper(cmd *cobra.Command) (*CommandHelper, error) {
 		pwReader = p
 	}
 
-	return &CommandHelper{
+	h := &CommandHelper{
 		ConfigLocation:   location,
 		NoConfirm:        flagx.MustGetBool(cmd, yesFlag),
 		IsQuiet:          flagx.MustGetBool(cmd, cmdx.FlagQuiet),
@@ -149,7 +168,29 @@ func NewCommandHelper(cmd *cobra.Command) (*CommandHelper, error) {
 		Stdin:            bufio.NewReader(cmd.InOrStdin()),
 		Ctx:              cmd.Context(),
 		PwReader:         pwReader,
-	}, nil
+		ConsoleURL:       ConsoleURL(cmd),
+	}
+	h.projectID, _ = cmd.Context().Value(resolvedProjectKey{}).(string)
+	h.applyDefaultFormat(cmd)
+
+	return h, nil
+}
+
+// applyDefaultFormat uses the format from the configuration file as the default of the `--format` flag. An explicit
+// `--format` always wins.
+func (h *CommandHelper) applyDefaultFormat(cmd *cobra.Command) {
+	f := cmd.Flags().Lookup(cmdx.FlagFormat)
+	if f == nil || f.Changed {
+		return
+	}
+
+	conf, err := h.readConfig()
+	if err != nil || len(conf.Format) == 0 {
+		return
+	}
+
+	// Setting the value directly keeps the flag marked as unchanged.
+	_ = f.Value.Set(conf.Format)
 }
 
 func (h *CommandHelper) GetDefaultProjectID() string {
@@ -227,7 +268,7 @@ func (h *CommandHelper) HasValidContext() (*AuthContext, bool, error) {
 	}
 
 	if len(c.SessionToken) > 0 {
-		client, err := NewKratosClient()
+		client, err := newKratosClient(h.ConsoleURL)
 		if err != nil {
 			return nil, false, err
 		}
@@ -458,7 +499,7 @@ func (h *CommandHelper) Authenticate() (*AuthContext, error) {
 		}
 	}
 
-	c, err := NewKratosClient()
+	c, err := newKratosClient(h.ConsoleURL)
 	if err != nil {
 		return nil, err
 	}
@@ -482,7 +523,7 @@ func (h *CommandHelper) Authenticate() (*AuthContext, error) {
 		}
 	}
 
-	if err := h.WriteConfig(ac); err != nil {
+	if err := h.WriteConfig(h.keepSettings(ac)); err != nil {
 		return nil, err
 	}
 
@@ -496,7 +537,68 @@ func (h *CommandHelper) Authenticate() (*AuthContext, error) {
 }
 
 func (h *CommandHelper) SignOut() error {
-	return h.WriteConfig(new(AuthContext))
+	return h.WriteConfig(h.keepSettings(new(AuthContext)))
+}
+
+// keepSettings copies the settings that do not belong to the signed-in account, such as the console URL and the
+// default format, from the configuration file to c, so that signing in or out does not reset them.
+func (h *CommandHelper) keepSettings(c *AuthContext) *AuthContext {
+	current, err := h.readConfig()
+	if err != nil {
+		// Without a readable configuration, there are no settings to keep.
+		return c
+	}
+
+	c.ConsoleURL = current.ConsoleURL
+	c.Format = current.Format
+	return c
+}
+
+// defaultFormats are the formats that can be stored as the default of the `--format` flag.
+var defaultFormats = []string{string(cmdx.FormatDefault), string(cmdx.FormatTable), string(cmdx.FormatJSON), string(cmdx.FormatJSONPretty), string(cmdx.FormatYAML)}
+
+// SetDefaultFormat stores the default of the `--format` flag in the configuration file. An empty format removes it.
+func (h *CommandHelper) SetDefaultFormat(format string) error {
+	if len(format) > 0 && !stringslice.Has(defaultFormats, format) {
+		return errors.Errorf("the format must be one of %s but got: %q", strings.Join(defaultFormats, ", "), format)
+	}
+
+	conf, err := h.readConfig()
+	if err != nil && !errors.Is(err, ErrNoConfig) {
+		return err
+	}
+
+	conf.Format = format
+	return h.WriteConfig(conf)
+}
+
+// SetConsoleURL stores the console URL in the configuration file of the command. Unlike NewCommandHelper, it works
+// while the configuration file holds a malformed console URL, so that it can be replaced.
+func SetConsoleURL(cmd *cobra.Command, consoleURL string) error {
+	location, err := getConfigPath(cmd)
+	if err != nil {
+		return err
+	}
+	return (&CommandHelper{ConfigLocation: location}).SetConsoleURL(consoleURL)
+}
+
+// SetConsoleURL stores the console URL in the configuration file, which is used if neither the flag nor the
+// environment variable is set. An empty URL removes it.
+func (h *CommandHelper) SetConsoleURL(consoleURL string) error {
+	if len(consoleURL) > 0 {
+		u, err := url.ParseRequestURI(consoleURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return errors.Errorf("the console URL must be an absolute http or https URL such as %s but got: %q", defaultConsoleURL, consoleURL)
+		}
+	}
+
+	conf, err := h.readConfig()
+	if err != nil && !errors.Is(err, ErrNoConfig) {
+		return err
+	}
+
+	conf.ConsoleURL = consoleURL
+	return h.WriteConfig(conf)
 }
 
 func (h *CommandHelper) ListProjects() ([]cloud.ProjectMetadata, error) {
@@ -505,7 +607,7 @@ func (h *CommandHelper) ListProjects() ([]cloud.ProjectMetadata, error) {
 		return nil, err
 	}
 
-	c, err := newCloudClient(ac.SessionToken)
+	c, err := newCloudClient(h.ConsoleURL, ac.SessionToken)
 	if err != nil {
 		return nil, err
 	}
@@ -518,44 +620,170 @@ func (h *CommandHelper) ListProjects() ([]cloud.ProjectMetadata, error) {
 	return projects, nil
 }
 
-func (h *CommandHelper) GetProject(projectOrSlug string) (*cloud.Project, error) {
+// PaginateProjects returns at most pageSize projects, starting at the project the page token points to, and the token
+// of the next page. The token is empty on the last page.
+//
+// Paging happens on the client, because the API returns all projects at once. The page token is the ID of the first
+// project on the page, so that pages stay stable when projects are added or removed.
+func PaginateProjects(projects []cloud.ProjectMetadata, pageSize int, pageToken string) ([]cloud.ProjectMetadata, string, error) {
+	if pageSize < 1 {
+		return nil, "", errors.Errorf("the page size must be at least 1 but got: %d", pageSize)
+	}
+
+	start := 0
+	if len(pageToken) > 0 {
+		start = -1
+		for k := range projects {
+			if projects[k].Id == pageToken {
+				start = k
+				break
+			}
+		}
+		if start < 0 {
+			return nil, "", errors.Errorf("the page token %q does not point to any of your projects", pageToken)
+		}
+	}
+
+	end := start + pageSize
+	if end >= len(projects) {
+		return projects[start:], "", nil
+	}
+	return projects[start:end], projects[end].Id, nil
+}
+
+// resolvedProjectIDs caches the project IDs of slugs for the lifetime of the process, because commands such as the
+// identity commands resolve the project again for every API client they create.
+var resolvedProjectIDs = struct {
+	sync.Mutex
+	ids map[string]string
+}{ids: map[string]string{}}
+
+// ProjectID returns the ID of the project that ResolveProject resolved for the command.
+func (h *CommandHelper) ProjectID() (string, error) {
+	if h.projectID == "" {
+		return "", errors.Errorf("No project selected! Please use the flag --%s to specify one.", projectFlag)
+	}
+	return h.projectID, nil
+}
+
+// ResolveProjectID returns the ID of the project with the given ID, slug, or unique slug prefix.
+func (h *CommandHelper) ResolveProjectID(projectOrSlug string) (string, error) {
 	if projectOrSlug == "" {
-		return nil, errors.Errorf("No project selected! Please see the help message on how to set one.")
+		return "", errors.Errorf("No project selected! Please see the help message on how to set one.")
 	}
 
 	ac, err := h.EnsureContext()
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	return h.resolveProjectID(ac, projectOrSlug)
+}
+
+func (h *CommandHelper) resolveProjectID(ac *AuthContext, projectOrSlug string) (string, error) {
+	if id := uuid.FromStringOrNil(projectOrSlug); id != uuid.Nil {
+		return id.String(), nil
+	}
+
+	key := ac.SessionToken + "\x00" + projectOrSlug
+	resolvedProjectIDs.Lock()
+	id, ok := resolvedProjectIDs.ids[key]
+	resolvedProjectIDs.Unlock()
+	if ok {
+		return id, nil
 	}
 
-	c, err := newCloudClient(ac.SessionToken)
+	pjs, err := h.ListProjects()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	id := uuid.FromStringOrNil(projectOrSlug)
-	if id == uuid.Nil {
-		pjs, err := h.ListProjects()
-		if err != nil {
-			return nil, err
+	id, err = matchProjectSlug(pjs, projectOrSlug)
+	if err != nil {
+		return "", err
+	}
+
+	resolvedProjectIDs.Lock()
+	resolvedProjectIDs.ids[key] = id
+	resolvedProjectIDs.Unlock()
+	return id, nil
+}
+
+// ResolveExactProjectID returns the ID of the project with the given ID or slug. Unlike ResolveProjectID, it does not
+// match slug prefixes, so that commands which cannot be undone never act on a project the user did not name.
+func (h *CommandHelper) ResolveExactProjectID(projectOrSlug string) (string, error) {
+	if id := uuid.FromStringOrNil(projectOrSlug); id != uuid.Nil {
+		return id.String(), nil
+	}
+
+	pjs, err := h.ListProjects()
+	if err != nil {
+		return "", err
+	}
+	return exactProjectSlug(pjs, projectOrSlug)
+}
+
+// exactProjectSlug returns the ID of the project whose slug is slug.
+func exactProjectSlug(projects []cloud.ProjectMetadata, slug string) (string, error) {
+	availableSlugs := make([]string, len(projects))
+	for i, pm := range projects {
+		if pm.GetSlug() == slug {
+			return pm.Id, nil
 		}
+		availableSlugs[i] = pm.GetSlug()
+	}
+	return "", errors.Errorf("no project found with slug %s, only slugs known are: %v", slug, availableSlugs)
+}
 
-		availableSlugs := make([]string, len(pjs))
-		for i, pm := range pjs {
-			availableSlugs[i] = pm.GetSlug()
-			if strings.HasPrefix(pm.GetSlug(), projectOrSlug) {
-				if id != uuid.Nil {
-					return nil, errors.Errorf("The slug prefix %q is not unique, please use more characters. Found slugs:\n%s", projectOrSlug, strings.Join(availableSlugs, "\n"))
-				}
-				id = uuid.FromStringOrNil(pm.GetId())
-			}
+// matchProjectSlug returns the ID of the project whose slug is, or starts with, slug. An exact match wins over
+// prefix matches.
+func matchProjectSlug(projects []cloud.ProjectMetadata, slug string) (string, error) {
+	var id string
+	availableSlugs := make([]string, len(projects))
+	for i, pm := range projects {
+		availableSlugs[i] = pm.GetSlug()
+		if pm.GetSlug() == slug {
+			return pm.Id, nil
 		}
-		if id == uuid.Nil {
-			return nil, errors.Errorf("no project found with slug %s, only slugs known are: %v", projectOrSlug, availableSlugs)
+	}
+
+	for _, pm := range projects {
+		if strings.HasPrefix(pm.GetSlug(), slug) {
+			if id != "" {
+				return "", errors.Errorf("The slug prefix %q is not unique, please use more characters. Found slugs:\n%s", slug, strings.Join(availableSlugs, "\n"))
+			}
+			id = pm.Id
 		}
 	}
+	if id == "" {
+		return "", errors.Errorf("no project found with slug %s, only slugs known are: %v", slug, availableSlugs)
+	}
+	return id, nil
+}
+
+func (h *CommandHelper) GetProject(projectOrSlug string) (*cloud.Project, error) {
+	if projectOrSlug == "" {
+		return nil, errors.Errorf("No project selected! Please see the help message on how to set one.")
+	}
 
-	project, res, err := c.ProjectApi.GetProject(h.Ctx, id.String()).Execute()
+	ac, err := h.EnsureContext()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := newCloudClient(h.ConsoleURL, ac.SessionToken)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := h.resolveProjectID(ac, projectOrSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	project, res, err := c.ProjectApi.GetProject(h.Ctx, id).Execute()
+	if res != nil && res.StatusCode == http.StatusNotFound {
+		return nil, errors.WithMessagef(ErrProjectNotFound, "unable to get project %s", id)
+	}
 	if err != nil {
 		return nil, handleError("unable to get project", res, err)
 	}
@@ -569,14 +797,14 @@ func (h *CommandHelper) CreateProject(name string, setDefault bool) (*cloud.Proj
 		return nil, err
 	}
 
-	c, err := newCloudClient(ac.SessionToken)
+	c, err := newCloudClient(h.ConsoleURL, ac.SessionToken)
 	if err != nil {
 		return nil, err
 	}
 
 	project, res, err := c.ProjectApi.CreateProject(h.Ctx).CreateProjectBody(*cloud.NewCreateProjectBody(strings.TrimSpace(name))).Execute()
 	if err != nil {
-		return nil, handleError("unable to list projects", res, err)
+		return nil, handleError("unable to create project", res, err)
 	}
 
 	if def := h.GetDefaultProjectID(); setDefault || def == "" {
@@ -586,6 +814,34 @@ func (h *CommandHelper) CreateProject(name string, setDefault bool) (*cloud.Proj
 	return project, nil
 }
 
+// DeleteProject purges the project. If it was the default project, no project is selected afterwards.
+func (h *CommandHelper) DeleteProject(id string) error {
+	ac, err := h.EnsureContext()
+	if err != nil {
+		return err
+	}
+
+	c, err := newCloudClient(h.ConsoleURL, ac.SessionToken)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.ProjectApi.PurgeProject(h.Ctx, id).Execute()
+	if err != nil {
+		return handleError("unable to delete project", res, err)
+	}
+
+	if h.GetDefaultProjectID() == id {
+		conf, err := h.readConfig()
+		if err != nil {
+			return err
+		}
+		conf.SelectedProject = uuid.Nil
+		return h.WriteConfig(conf)
+	}
+	return nil
+}
+
 func handleError(message string, res *http.Response, err error) error {
 	if e, ok := err.(*cloud.GenericOpenAPIError); ok {
 		return errors.Wrapf(err, "%s: %s", message, e.Body())
@@ -629,7 +885,7 @@ func (h *CommandHelper) PatchProject(id string, raw []json.RawMessage, add, repl
 		return nil, err
 	}
 
-	c, err := newCloudClient(ac.SessionToken)
+	c, err := newCloudClient(h.ConsoleURL, ac.SessionToken)
 	if err != nil {
 		return nil, err
 	}
@@ -680,7 +936,7 @@ func (h *CommandHelper) UpdateProject(id string, name string, configs []json.Raw
 		return nil, err
 	}
 
-	c, err := newCloudClient(ac.SessionToken)
+	c, err := newCloudClient(h.ConsoleURL, ac.SessionToken)
 	if err != nil {
 		return nil, err
 	}
@@ -751,7 +1007,7 @@ func (h *CommandHelper) CreateAPIKey(projectIdOrSlug, name string) (*cloud.Proje
 		return nil, err
 	}
 
-	c, err := newCloudClient(ac.SessionToken)
+	c, err := newCloudClient(h.ConsoleURL, ac.SessionToken)
 	if err != nil {
 		return nil, err
 	}
@@ -770,7 +1026,7 @@ func (h *CommandHelper) DeleteAPIKey(projectIdOrSlug, id string) error {
 		return err
 	}
 
-	c, err := newCloudClient(ac.SessionToken)
+	c, err := newCloudClient(h.ConsoleURL, ac.SessionToken)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,216 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cloud "github.com/ory/client-go"
+	"github.com/ory/x/cmdx"
+)
+
+func TestDefaultFormat(t *testing.T) {
+	stored := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, (&CommandHelper{ConfigLocation: stored}).SetDefaultFormat(string(cmdx.FormatJSON)))
+
+	for _, tc := range []struct {
+		name, config, expected string
+		args                   []string
+	}{
+		{name: "without configuration", config: `{}`, expected: string(cmdx.FormatDefault)},
+		{name: "from configuration", config: `{"format":"json"}`, expected: string(cmdx.FormatJSON)},
+		{name: "flag wins", config: `{"format":"json"}`, args: []string{"--" + cmdx.FlagFormat, "yaml"}, expected: string(cmdx.FormatYAML)},
+		{name: "stored with SetDefaultFormat", expected: string(cmdx.FormatJSON)},
+		{name: "flag wins over SetDefaultFormat", args: []string{"--" + cmdx.FlagFormat, "yaml"}, expected: string(cmdx.FormatYAML)},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			path := stored
+			if len(tc.config) > 0 {
+				path = filepath.Join(t.TempDir(), "config.json")
+				require.NoError(t, os.WriteFile(path, []byte(tc.config), 0600))
+			}
+
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			RegisterConfigFlag(cmd.Flags())
+			RegisterYesFlag(cmd.Flags())
+			cmdx.RegisterFormatFlags(cmd.Flags())
+			require.NoError(t, cmd.ParseFlags(append([]string{"--" + ConfigFlag, path}, tc.args...)))
+
+			_, err := NewCommandHelper(cmd)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, cmd.Flags().Lookup(cmdx.FlagFormat).Value.String())
+		})
+	}
+}
+
+func TestPaginateProjects(t *testing.T) {
+	projects := []cloud.ProjectMetadata{{Id: "a"}, {Id: "b"}, {Id: "c"}}
+	ids := func(projects []cloud.ProjectMetadata) []string {
+		ids := make([]string, len(projects))
+		for k := range projects {
+			ids[k] = projects[k].Id
+		}
+		return ids
+	}
+
+	for _, tc := range []struct {
+		name, token, expectedNext string
+		size                      int
+		expected                  []string
+	}{
+		{name: "first page", size: 2, expected: []string{"a", "b"}, expectedNext: "c"},
+		{name: "last page", size: 2, token: "c", expected: []string{"c"}},
+		{name: "exact fit", size: 3, expected: []string{"a", "b", "c"}},
+		{name: "from the middle", size: 1, token: "b", expected: []string{"b"}, expectedNext: "c"},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			page, next, err := PaginateProjects(projects, tc.size, tc.token)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ids(page))
+			assert.Equal(t, tc.expectedNext, next)
+		})
+	}
+
+	t.Run("case=rejects an unknown page token", func(t *testing.T) {
+		_, _, err := PaginateProjects(projects, 2, "unknown")
+		assert.ErrorContains(t, err, `the page token "unknown"`)
+	})
+
+	t.Run("case=rejects an empty page", func(t *testing.T) {
+		_, _, err := PaginateProjects(projects, 0, "")
+		assert.ErrorContains(t, err, "at least 1")
+	})
+}
+
+func TestMatchProjectSlug(t *testing.T) {
+	slug := func(s string) *string { return &s }
+	projects := []cloud.ProjectMetadata{
+		{Id: "a", Slug: slug("good-wright")},
+		{Id: "b", Slug: slug("good-wright-t7kzy3vugf")},
+		{Id: "c", Slug: slug("other-project")},
+	}
+
+	for _, tc := range []struct{ slug, expected string }{
+		{slug: "other", expected: "c"},
+		{slug: "good-wright", expected: "a"},
+		{slug: "good-wright-t", expected: "b"},
+	} {
+		t.Run("case="+tc.slug, func(t *testing.T) {
+			id, err := matchProjectSlug(projects, tc.slug)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, id)
+		})
+	}
+
+	t.Run("case=rejects an ambiguous prefix", func(t *testing.T) {
+		_, err := matchProjectSlug(projects, "good")
+		assert.ErrorContains(t, err, `The slug prefix "good" is not unique`)
+	})
+
+	t.Run("case=rejects an unknown slug", func(t *testing.T) {
+		_, err := matchProjectSlug(projects, "unknown")
+		assert.ErrorContains(t, err, "no project found with slug unknown")
+	})
+}
+
+func TestExactProjectSlug(t *testing.T) {
+	slug := func(s string) *string { return &s }
+	projects := []cloud.ProjectMetadata{
+		{Id: "a", Slug: slug("good-wright")},
+		{Id: "b", Slug: slug("good-wright-t7kzy3vugf")},
+	}
+
+	id, err := exactProjectSlug(projects, "good-wright-t7kzy3vugf")
+	require.NoError(t, err)
+	assert.Equal(t, "b", id)
+
+	for _, prefix := range []string{"good", "good-wright-t"} {
+		t.Run("case=rejects the prefix "+prefix, func(t *testing.T) {
+			_, err := exactProjectSlug(projects, prefix)
+			assert.ErrorContains(t, err, "no project found with slug "+prefix)
+		})
+	}
+}
+
+func TestProjectID(t *testing.T) {
+	newCmd := func(t *testing.T, ctx context.Context) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.SetContext(ctx)
+		RegisterConfigFlag(cmd.Flags())
+		RegisterYesFlag(cmd.Flags())
+		cmdx.RegisterFormatFlags(cmd.Flags())
+		require.NoError(t, cmd.ParseFlags([]string{"--" + ConfigFlag, filepath.Join(t.TempDir(), "config.json")}))
+		return cmd
+	}
+
+	t.Run("case=resolved", func(t *testing.T) {
+		h, err := NewCommandHelper(newCmd(t, context.WithValue(context.Background(), resolvedProjectKey{}, "ecaaa3cb-0730-4ee8-a6df-9553cdfeef89")))
+		require.NoError(t, err)
+		id, err := h.ProjectID()
+		require.NoError(t, err)
+		assert.Equal(t, "ecaaa3cb-0730-4ee8-a6df-9553cdfeef89", id)
+	})
+
+	t.Run("case=not resolved", func(t *testing.T) {
+		h, err := NewCommandHelper(newCmd(t, context.Background()))
+		require.NoError(t, err)
+		_, err = h.ProjectID()
+		assert.ErrorContains(t, err, "--project")
+	})
+}
+
+func TestKeepSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	h := &CommandHelper{ConfigLocation: path}
+
+	require.NoError(t, h.WriteConfig(&AuthContext{SessionToken: "old-token", ConsoleURL: "https://console.example.org"}))
+	require.NoError(t, h.SetConsoleURL("https://console.staging.example.org"))
+
+	t.Run("case=sign out", func(t *testing.T) {
+		require.NoError(t, h.SignOut())
+		conf, err := h.readConfig()
+		require.NoError(t, err)
+		assert.Empty(t, conf.SessionToken)
+		assert.Equal(t, "https://console.staging.example.org", conf.ConsoleURL)
+	})
+
+	t.Run("case=sign in", func(t *testing.T) {
+		// Authenticate writes the new session like this after signing in.
+		require.NoError(t, h.WriteConfig(h.keepSettings(&AuthContext{SessionToken: "new-token"})))
+		conf, err := h.readConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "new-token", conf.SessionToken)
+		assert.Equal(t, "https://console.staging.example.org", conf.ConsoleURL)
+	})
+
+	t.Run("case=keeps the default format", func(t *testing.T) {
+		require.NoError(t, h.SetDefaultFormat("yaml"))
+		require.NoError(t, h.SignOut())
+		require.NoError(t, h.WriteConfig(h.keepSettings(&AuthContext{SessionToken: "new-token"})))
+		conf, err := h.readConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "yaml", conf.Format)
+		assert.ErrorContains(t, h.SetDefaultFormat("xml"), "the format must be one of")
+	})
+
+	t.Run("case=rejects invalid console URLs", func(t *testing.T) {
+		assert.ErrorContains(t, h.SetConsoleURL("console.example.org"), "absolute http or https URL")
+	})
+
+	t.Run("case=removes the console URL", func(t *testing.T) {
+		require.NoError(t, h.SetConsoleURL(""))
+		conf, err := h.readConfig()
+		require.NoError(t, err)
+		assert.Empty(t, conf.ConsoleURL)
+		assert.Equal(t, "new-token", conf.SessionToken)
+	})
+}
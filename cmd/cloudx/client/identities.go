@@ -0,0 +1,241 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	cloud "github.com/ory/client-go"
+)
+
+// sessionsPageSize is the number of sessions listed at once.
+const sessionsPageSize = 250
+
+// newProjectClient returns a client for the APIs of the project. It uses the console endpoint of the project because
+// that works with Ory Network session tokens.
+func (h *CommandHelper) newProjectClient(projectOrSlug string) (*cloud.APIClient, error) {
+	if c, ok := h.projectClients[projectOrSlug]; ok {
+		return c, nil
+	}
+
+	ac, err := h.EnsureContext()
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := h.GetProject(projectOrSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := cloud.NewConfiguration()
+	conf.Servers = cloud.ServerConfigurations{{URL: makeCloudConsoleURL(h.ConsoleURL, project.Slug+".projects")}}
+	conf.HTTPClient = newBearerTokenClient(ac.SessionToken)
+
+	c := cloud.NewAPIClient(conf)
+	if h.projectClients == nil {
+		h.projectClients = map[string]*cloud.APIClient{}
+	}
+	h.projectClients[projectOrSlug] = c
+	return c, nil
+}
+
+// ListIdentities returns at most pageSize identities of the project, starting at the page the token points to, and
+// the token of the next page. The token is empty on the last page.
+func (h *CommandHelper) ListIdentities(projectOrSlug string, pageSize int, pageToken string) ([]cloud.Identity, string, error) {
+	if pageSize < 1 {
+		return nil, "", errors.Errorf("the page size must be at least 1 but got: %d", pageSize)
+	}
+
+	c, err := h.newProjectClient(projectOrSlug)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req := c.IdentityApi.ListIdentities(h.Ctx).PerPage(int64(pageSize))
+	if len(pageToken) > 0 {
+		page, err := strconv.ParseInt(pageToken, 10, 64)
+		if err != nil {
+			return nil, "", errors.Errorf("the page token %q is invalid, please use the token printed by the previous page", pageToken)
+		}
+		req = req.Page(page)
+	}
+
+	identities, res, err := req.Execute()
+	if err != nil {
+		return nil, "", handleError("unable to list identities", res, err)
+	}
+
+	// An empty page means that we went past the last identity.
+	if len(identities) < pageSize {
+		return identities, "", nil
+	}
+	return identities, nextPageToken(res), nil
+}
+
+// GetIdentity returns the identity of the project. It returns ErrIdentityNotFound if there is no identity with the ID.
+func (h *CommandHelper) GetIdentity(projectOrSlug, id string) (*cloud.Identity, error) {
+	c, err := h.newProjectClient(projectOrSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, res, err := c.IdentityApi.GetIdentity(h.Ctx, id).Execute()
+	if res != nil && res.StatusCode == http.StatusNotFound {
+		return nil, errors.WithMessagef(ErrIdentityNotFound, "unable to get identity %s", id)
+	}
+	if err != nil {
+		return nil, handleError("unable to get identity", res, err)
+	}
+	return identity, nil
+}
+
+// DeleteIdentity deletes the identity of the project. It returns ErrIdentityNotFound if there is no identity with the ID.
+func (h *CommandHelper) DeleteIdentity(projectOrSlug, id string) error {
+	c, err := h.newProjectClient(projectOrSlug)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.IdentityApi.DeleteIdentity(h.Ctx, id).Execute()
+	if res != nil && res.StatusCode == http.StatusNotFound {
+		return errors.WithMessagef(ErrIdentityNotFound, "unable to delete identity %s", id)
+	}
+	if err != nil {
+		return handleError("unable to delete identity", res, err)
+	}
+	return nil
+}
+
+// ImportIdentity creates the identity, which may contain credentials such as hashed passwords, in the project.
+func (h *CommandHelper) ImportIdentity(projectOrSlug string, identity json.RawMessage) (*cloud.Identity, error) {
+	var body cloud.CreateIdentityBody
+	if err := json.Unmarshal(identity, &body); err != nil {
+		return nil, errors.Wrap(err, "unable to parse identity")
+	}
+
+	c, err := h.newProjectClient(projectOrSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	created, res, err := c.IdentityApi.CreateIdentity(h.Ctx).CreateIdentityBody(body).Execute()
+	if err != nil {
+		return nil, handleError("unable to import identity", res, err)
+	}
+	return created, nil
+}
+
+// GetIdentitySchema returns the identity schema of the project with the ID.
+func (h *CommandHelper) GetIdentitySchema(projectOrSlug, id string) (map[string]interface{}, error) {
+	c, err := h.newProjectClient(projectOrSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, res, err := c.IdentityApi.GetIdentitySchema(h.Ctx, id).Execute()
+	if err != nil {
+		return nil, handleError("unable to get identity schema", res, err)
+	}
+	return schema, nil
+}
+
+// ListIdentitySessions returns all sessions of the identity, including inactive ones.
+func (h *CommandHelper) ListIdentitySessions(projectOrSlug, identityID string) ([]cloud.Session, error) {
+	c, err := h.newProjectClient(projectOrSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []cloud.Session
+	for page := ""; ; {
+		req := c.IdentityApi.ListIdentitySessions(h.Ctx, identityID).PerPage(sessionsPageSize)
+		if len(page) > 0 {
+			p, err := strconv.ParseInt(page, 10, 64)
+			if err != nil {
+				return nil, errors.Errorf("the API returned the invalid page %q", page)
+			}
+			req = req.Page(p)
+		}
+
+		s, res, err := req.Execute()
+		if res != nil && res.StatusCode == http.StatusNotFound {
+			return nil, errors.WithMessagef(ErrIdentityNotFound, "unable to list sessions of identity %s", identityID)
+		}
+		if err != nil {
+			return nil, handleError("unable to list sessions", res, err)
+		}
+		sessions = append(sessions, s...)
+
+		if page = nextPageToken(res); len(s) < sessionsPageSize || len(page) == 0 {
+			return sessions, nil
+		}
+	}
+}
+
+// RevokeSession revokes the session. It returns ErrSessionNotFound if the session does not exist or has already
+// expired.
+func (h *CommandHelper) RevokeSession(projectOrSlug, sessionID string) error {
+	c, err := h.newProjectClient(projectOrSlug)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.IdentityApi.DisableSession(h.Ctx, sessionID).Execute()
+	if res != nil && res.StatusCode == http.StatusNotFound {
+		return errors.WithMessagef(ErrSessionNotFound, "unable to revoke session %s", sessionID)
+	}
+	if err != nil {
+		return handleError("unable to revoke session", res, err)
+	}
+	return nil
+}
+
+// RevokeIdentitySessions revokes all sessions of the identity. It returns ErrSessionNotFound if the identity has no
+// active sessions.
+func (h *CommandHelper) RevokeIdentitySessions(projectOrSlug, identityID string) error {
+	c, err := h.newProjectClient(projectOrSlug)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.IdentityApi.DeleteIdentitySessions(h.Ctx, identityID).Execute()
+	if res != nil && res.StatusCode == http.StatusNotFound {
+		return errors.WithMessagef(ErrSessionNotFound, "unable to revoke the sessions of identity %s", identityID)
+	}
+	if err != nil {
+		return handleError("unable to revoke sessions", res, err)
+	}
+	return nil
+}
+
+// nextPageToken returns the page parameter of the next link in the Link header of the response, which the Ory APIs
+// use for pagination.
+func nextPageToken(res *http.Response) string {
+	if res == nil {
+		return ""
+	}
+
+	for _, header := range res.Header.Values("Link") {
+		for _, link := range strings.Split(header, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok || !strings.Contains(params, `rel="next"`) {
+				continue
+			}
+
+			u, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+			if err != nil {
+				continue
+			}
+			return u.Query().Get("page")
+		}
+	}
+	return ""
+}
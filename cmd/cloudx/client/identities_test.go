@@ -0,0 +1,45 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextPageToken(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		links    []string
+		expected string
+	}{
+		{name: "no link header"},
+		{
+			name:     "next and last links",
+			links:    []string{`</admin/identities?page=0&per_page=2>; rel="first",</admin/identities?page=1&per_page=2>; rel="next",</admin/identities?page=4&per_page=2>; rel="last"`},
+			expected: "1",
+		},
+		{
+			name:  "no next link",
+			links: []string{`</admin/identities?page=0&per_page=2>; rel="first",</admin/identities?page=0&per_page=2>; rel="prev"`},
+		},
+		{
+			name:     "multiple headers",
+			links:    []string{`</admin/identities?page=0&per_page=2>; rel="first"`, `</admin/identities?page=3&per_page=2>; rel="next"`},
+			expected: "3",
+		},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			res := &http.Response{Header: http.Header{}}
+			for _, l := range tc.links {
+				res.Header.Add("Link", l)
+			}
+			assert.Equal(t, tc.expected, nextPageToken(res))
+		})
+	}
+
+	assert.Equal(t, "", nextPageToken(nil))
+}
@@ -4,39 +4,124 @@
 package client
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/url"
 	"os"
 	"time"
 
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
 	cloud "github.com/ory/client-go"
 	"github.com/ory/x/stringsx"
 )
 
-func CloudConsoleURL(prefix string) *url.URL {
-	u, err := url.ParseRequestURI(stringsx.Coalesce(os.Getenv("ORY_CLOUD_CONSOLE_URL"), "https://console.ory.sh"))
+const (
+	ConsoleURLFlag         = "console-url"
+	consoleURLEnvVar       = "ORY_CONSOLE_URL"
+	legacyConsoleURLEnvVar = "ORY_CLOUD_CONSOLE_URL"
+	defaultConsoleURL      = "https://console.ory.sh"
+)
+
+func RegisterConsoleURLFlag(f *pflag.FlagSet) {
+	f.String(ConsoleURLFlag, "", "The URL of the Ory Network console. Defaults to the "+consoleURLEnvVar+" environment variable or "+defaultConsoleURL+".")
+}
+
+// ConsoleURL resolves the Ory Network console URL from the `--console-url` flag, the `ORY_CONSOLE_URL` environment variable,
+// the Ory Network configuration file, or the default, in that order.
+func ConsoleURL(cmd *cobra.Command) *url.URL {
+	fromFlag, fromConfig, _ := consoleURLSources(cmd)
+	return consoleBaseURL(fromFlag, fromConfig)
+}
+
+// ValidateConsoleURL returns an error naming the flag, environment variable, or configuration file if the console URL
+// set there is not an absolute http or https URL. ConsoleURL falls back to the default for such values instead.
+func ValidateConsoleURL(cmd *cobra.Command) error {
+	fromFlag, fromConfig, configPath := consoleURLSources(cmd)
+	for _, source := range []struct{ name, value string }{
+		{name: "--" + ConsoleURLFlag, value: fromFlag},
+		{name: consoleURLEnvVar, value: os.Getenv(consoleURLEnvVar)},
+		{name: legacyConsoleURLEnvVar, value: os.Getenv(legacyConsoleURLEnvVar)},
+		{name: "console_url in " + configPath, value: fromConfig},
+	} {
+		if len(source.value) == 0 {
+			continue
+		}
+
+		u, err := url.ParseRequestURI(source.value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return errors.Errorf("%s must be an absolute http or https URL such as %s but got: %q", source.name, defaultConsoleURL, source.value)
+		}
+		return nil
+	}
+	return nil
+}
+
+// consoleURLSources returns the console URL set with the `--console-url` flag and the one in the configuration file,
+// together with the path of that file.
+func consoleURLSources(cmd *cobra.Command) (fromFlag, fromConfig, configPath string) {
+	if f := cmd.Flags().Lookup(ConsoleURLFlag); f != nil {
+		fromFlag = f.Value.String()
+	}
+
+	configPath, err := getConfigPath(cmd)
+	if err != nil {
+		return fromFlag, "", ""
+	}
+	if contents, err := os.ReadFile(configPath); err == nil {
+		var c AuthContext
+		if err := json.Unmarshal(contents, &c); err == nil {
+			fromConfig = c.ConsoleURL
+		}
+	}
+	return fromFlag, fromConfig, configPath
+}
+
+func consoleBaseURL(fromFlag, fromConfig string) *url.URL {
+	u, err := url.ParseRequestURI(stringsx.Coalesce(fromFlag, os.Getenv(consoleURLEnvVar), os.Getenv(legacyConsoleURLEnvVar), fromConfig, defaultConsoleURL))
 	if err != nil {
 		u = &url.URL{Scheme: "https", Host: "console.ory.sh"}
 	}
+	return u
+}
+
+func CloudConsoleURL(prefix string) *url.URL {
+	return cloudConsoleURL(consoleBaseURL("", ""), prefix)
+}
+
+func cloudConsoleURL(base *url.URL, prefix string) *url.URL {
+	u := *base
 	u.Host = prefix + "." + u.Host
 	if u.Port() == "" {
 		u.Host = u.Host + ":443"
 	}
 
-	return u
+	return &u
+}
+
+// ConsoleAPIURL returns the URL of the Ory Network API the CLI uses to manage projects.
+func ConsoleAPIURL(cmd *cobra.Command) *url.URL {
+	return cloudConsoleURL(ConsoleURL(cmd), "api")
 }
 
-func makeCloudConsoleURL(prefix string) string {
-	u := CloudConsoleURL(prefix)
+func makeCloudConsoleURL(base *url.URL, prefix string) string {
+	u := cloudConsoleURL(base, prefix)
 
 	return u.Scheme + "://" + u.Host
 }
 
-func CloudAPIsURL(prefix string) *url.URL {
+func cloudAPIsBaseURL() *url.URL {
 	u, err := url.ParseRequestURI(stringsx.Coalesce(os.Getenv("ORY_CLOUD_ORYAPIS_URL"), "https://oryapis.com"))
 	if err != nil {
 		u = &url.URL{Scheme: "https", Host: "oryapis.com"}
 	}
+	return u
+}
+
+func CloudAPIsURL(prefix string) *url.URL {
+	u := cloudAPIsBaseURL()
 	u.Host = prefix + "." + u.Host
 	if u.Port() == "" {
 		u.Host = u.Host + ":443"
@@ -45,6 +130,15 @@ func CloudAPIsURL(prefix string) *url.URL {
 	return u
 }
 
+// ProjectAPIsURL returns the public API URL of the project with the given slug, for example
+// `https://<slug>.projects.oryapis.com/`.
+func ProjectAPIsURL(slug string) *url.URL {
+	u := cloudAPIsBaseURL()
+	u.Host = slug + ".projects." + u.Host
+	u.Path = "/"
+	return u
+}
+
 func makeCloudAPIsURL(prefix string) string {
 	u := CloudAPIsURL(prefix)
 
@@ -52,15 +146,19 @@ func makeCloudAPIsURL(prefix string) string {
 }
 
 func NewKratosClient() (*cloud.APIClient, error) {
+	return newKratosClient(consoleBaseURL("", ""))
+}
+
+func newKratosClient(consoleURL *url.URL) (*cloud.APIClient, error) {
 	conf := cloud.NewConfiguration()
-	conf.Servers = cloud.ServerConfigurations{{URL: makeCloudConsoleURL("project")}}
+	conf.Servers = cloud.ServerConfigurations{{URL: makeCloudConsoleURL(consoleURL, "project")}}
 	conf.HTTPClient = &http.Client{Timeout: time.Second * 10}
 
 	return cloud.NewAPIClient(conf), nil
 }
 
-func newCloudClient(token string) (*cloud.APIClient, error) {
-	u := makeCloudConsoleURL("api")
+func newCloudClient(consoleURL *url.URL, token string) (*cloud.APIClient, error) {
+	u := makeCloudConsoleURL(consoleURL, "api")
 
 	conf := cloud.NewConfiguration()
 	conf.Servers = cloud.ServerConfigurations{{URL: u}}
@@ -0,0 +1,108 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/flagx"
+)
+
+func TestConsoleURL(t *testing.T) {
+	t.Setenv(legacyConsoleURLEnvVar, "")
+
+	newCmd := func(t *testing.T, flag, config string) *cobra.Command {
+		path := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"console_url":"`+config+`"}`), 0600))
+
+		cmd := &cobra.Command{}
+		RegisterConfigFlag(cmd.Flags())
+		RegisterConsoleURLFlag(cmd.Flags())
+		require.NoError(t, cmd.Flags().Set(ConfigFlag, path))
+		if flag != "" {
+			require.NoError(t, cmd.Flags().Set(ConsoleURLFlag, flag))
+		}
+		return cmd
+	}
+
+	t.Run("case=defaults to the Ory Network console", func(t *testing.T) {
+		assert.Equal(t, defaultConsoleURL, ConsoleURL(newCmd(t, "", "")).String())
+	})
+
+	t.Run("case=reads the configuration file", func(t *testing.T) {
+		assert.Equal(t, "https://config.example.com", ConsoleURL(newCmd(t, "", "https://config.example.com")).String())
+	})
+
+	t.Run("case=environment takes precedence over the configuration file", func(t *testing.T) {
+		t.Setenv(consoleURLEnvVar, "https://env.example.com")
+		assert.Equal(t, "https://env.example.com", ConsoleURL(newCmd(t, "", "https://config.example.com")).String())
+	})
+
+	t.Run("case=flag takes precedence over everything", func(t *testing.T) {
+		t.Setenv(consoleURLEnvVar, "https://env.example.com")
+		assert.Equal(t, "https://flag.example.com", ConsoleURL(newCmd(t, "https://flag.example.com", "https://config.example.com")).String())
+	})
+}
+
+func TestValidateConsoleURL(t *testing.T) {
+	t.Setenv(consoleURLEnvVar, "")
+	t.Setenv(legacyConsoleURLEnvVar, "")
+
+	newCmd := func(t *testing.T, flag string) *cobra.Command {
+		cmd := &cobra.Command{}
+		RegisterConfigFlag(cmd.Flags())
+		RegisterConsoleURLFlag(cmd.Flags())
+		require.NoError(t, cmd.Flags().Set(ConfigFlag, filepath.Join(t.TempDir(), "config.json")))
+		if flag != "" {
+			require.NoError(t, cmd.Flags().Set(ConsoleURLFlag, flag))
+		}
+		return cmd
+	}
+
+	t.Run("case=accepts unset and valid values", func(t *testing.T) {
+		assert.NoError(t, ValidateConsoleURL(newCmd(t, "")))
+		assert.NoError(t, ValidateConsoleURL(newCmd(t, "http://localhost:4000")))
+	})
+
+	t.Run("case=names the flag", func(t *testing.T) {
+		for _, value := range []string{"console.ory.sh", "ftp://console.ory.sh", "https://"} {
+			err := ValidateConsoleURL(newCmd(t, value))
+			assert.ErrorContains(t, err, "--"+ConsoleURLFlag+" must be an absolute http or https URL")
+		}
+	})
+
+	t.Run("case=names the environment variable", func(t *testing.T) {
+		t.Setenv(consoleURLEnvVar, "console.ory.sh")
+		assert.ErrorContains(t, ValidateConsoleURL(newCmd(t, "")), consoleURLEnvVar+" must be an absolute http or https URL")
+	})
+
+	t.Run("case=names the configuration file", func(t *testing.T) {
+		cmd := newCmd(t, "")
+		path := flagx.MustGetString(cmd, ConfigFlag)
+		require.NoError(t, os.WriteFile(path, []byte(`{"console_url":"console.ory.sh"}`), 0600))
+
+		assert.ErrorContains(t, ValidateConsoleURL(cmd), "console_url in "+path+" must be an absolute http or https URL")
+		_, err := NewCommandHelper(cmd)
+		assert.ErrorContains(t, err, "console_url in "+path)
+
+		require.NoError(t, cmd.Flags().Set(ConsoleURLFlag, "https://flag.example.com"))
+		assert.NoError(t, ValidateConsoleURL(cmd), "the flag takes precedence over the configuration file")
+	})
+
+	t.Run("case=replaces a malformed value in the configuration file", func(t *testing.T) {
+		cmd := newCmd(t, "")
+		path := flagx.MustGetString(cmd, ConfigFlag)
+		require.NoError(t, os.WriteFile(path, []byte(`{"console_url":"console.ory.sh"}`), 0600))
+
+		require.NoError(t, SetConsoleURL(cmd, "https://console.example.com"))
+		assert.NoError(t, ValidateConsoleURL(cmd))
+		assert.Equal(t, "https://console.example.com", ConsoleURL(cmd).String())
+	})
+}
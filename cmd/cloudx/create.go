@@ -27,6 +27,7 @@ func NewCreateCmd() *cobra.Command {
 	)
 
 	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
 	client.RegisterYesFlag(cmd.PersistentFlags())
 	cmdx.RegisterNoiseFlags(cmd.PersistentFlags())
 	cmdx.RegisterJSONFormatFlags(cmd.PersistentFlags())
@@ -11,6 +11,7 @@ import (
 
 	"github.com/ory/cli/cmd/cloudx/client"
 	"github.com/ory/cli/cmd/cloudx/identity"
+	"github.com/ory/cli/cmd/cloudx/project"
 	"github.com/ory/x/cmdx"
 )
 
@@ -21,6 +22,7 @@ func NewDeleteCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(
+		project.NewDeleteProjectCmd(),
 		identity.NewDeleteIdentityCmd(),
 		oauth2.NewDeleteOAuth2Client(),
 		oauth2.NewDeleteJWKs(),
@@ -29,6 +31,7 @@ func NewDeleteCmd() *cobra.Command {
 	)
 
 	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
 	client.RegisterYesFlag(cmd.PersistentFlags())
 	cmdx.RegisterNoiseFlags(cmd.PersistentFlags())
 	cmdx.RegisterJSONFormatFlags(cmd.PersistentFlags())
@@ -0,0 +1,172 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cloudx
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+)
+
+const (
+	checkPass = "pass"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+type (
+	doctorCheck struct {
+		Name     string `json:"name"`
+		Result   string `json:"result"`
+		Details  string `json:"details"`
+		critical bool
+	}
+	outputDoctorChecks []doctorCheck
+)
+
+func (outputDoctorChecks) Header() []string {
+	return []string{"CHECK", "RESULT", "DETAILS"}
+}
+
+func (c outputDoctorChecks) Table() [][]string {
+	rows := make([][]string, len(c))
+	for i, check := range c {
+		rows[i] = []string{check.Name, check.Result, check.Details}
+	}
+	return rows
+}
+
+func (c outputDoctorChecks) Interface() interface{} {
+	return c
+}
+
+func (c outputDoctorChecks) Len() int {
+	return len(c)
+}
+
+func NewDoctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Args:  cobra.NoArgs,
+		Short: "Check your setup for common problems",
+		Long: `Check that the Ory Network is reachable, that you are signed in, and that your project can be found.
+
+Every check is listed with its result and the reason it failed. The command exits with a non-zero status if a
+check failed that prevents the CLI from working.`,
+		Example: `$ ory doctor
+
+CHECK			RESULT	DETAILS
+console reachable	pass	https://console.ory.sh responded with status 200
+api reachable		pass	https://api.console.ory.sh:443 responded with status 404
+signed in		pass	signed in as foo@example.com
+project resolved	pass	good-wright-t7kzy3vugf (ecaaa3cb-0730-4ee8-a6df-9553cdfeef89)`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			hc := &http.Client{Timeout: 10 * time.Second}
+			checks := outputDoctorChecks{
+				checkReachable(hc, "console reachable", client.ConsoleURL(cmd)),
+				checkReachable(hc, "api reachable", client.ConsoleAPIURL(cmd)),
+			}
+
+			signedIn := checkSignedIn(h)
+			checks = append(checks, signedIn)
+
+			project := doctorCheck{Name: "project resolved", Result: checkSkip, Details: "requires a valid sign in"}
+			if signedIn.Result == checkPass {
+				project = checkProject(h, flagx.MustGetString(cmd, "project"))
+			}
+			checks = append(checks, project)
+
+			cmdx.PrintTable(cmd, checks)
+			failed := make(map[string]error)
+			for _, check := range checks {
+				if check.critical && check.Result == checkFail {
+					failed[check.Name] = errors.New(check.Details)
+				}
+			}
+			if len(failed) == 0 {
+				return nil
+			} else if client.JSONErrors(cmd) {
+				return client.FailWithErrors(cmd, cmd.ErrOrStderr(), fmt.Sprintf("%d critical checks failed.", len(failed)), failed)
+			}
+			// The failed checks were already printed.
+			return cmdx.FailSilently(cmd)
+		},
+	}
+
+	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
+	client.RegisterYesFlag(cmd.PersistentFlags())
+	client.RegisterProjectFlag(cmd.Flags())
+	cmdx.RegisterNoiseFlags(cmd.PersistentFlags())
+	cmdx.RegisterFormatFlags(cmd.Flags())
+	return cmd
+}
+
+// checkReachable passes if the endpoint responds at all, as any HTTP response proves that DNS, proxies, and TLS work.
+func checkReachable(hc *http.Client, name string, u *url.URL) doctorCheck {
+	check := doctorCheck{Name: name, critical: true}
+
+	res, err := hc.Get(u.String())
+	if err != nil {
+		check.Result, check.Details = checkFail, err.Error()
+		return check
+	}
+	_ = res.Body.Close()
+
+	check.Result, check.Details = checkPass, fmt.Sprintf("%s responded with status %d", u, res.StatusCode)
+	return check
+}
+
+func checkSignedIn(h *client.CommandHelper) doctorCheck {
+	check := doctorCheck{Name: "signed in", critical: true}
+
+	ac, valid, err := h.HasValidContext()
+	switch {
+	case errors.Is(err, client.ErrNoConfigQuiet):
+		check.Result, check.Details = checkFail, "no configuration found, run ory auth to sign in"
+	case err != nil:
+		check.Result, check.Details = checkFail, err.Error()
+	case !valid:
+		check.Result, check.Details = checkFail, "not signed in or the session expired, run ory auth to sign in"
+	default:
+		check.Result, check.Details = checkPass, "signed in as "+ac.IdentityTraits.Email
+	}
+	return check
+}
+
+// checkProject resolves the project from the flag or the default project. Not having a project is fine, but a
+// project that can not be found is not.
+func checkProject(h *client.CommandHelper, projectOrSlug string) doctorCheck {
+	check := doctorCheck{Name: "project resolved", critical: true}
+
+	if projectOrSlug == "" {
+		projectOrSlug = h.GetDefaultProjectID()
+	}
+	if projectOrSlug == "" {
+		check.Result, check.Details = checkSkip, "no project selected, run ory use project to select one"
+		return check
+	}
+
+	project, err := h.GetProject(projectOrSlug)
+	if err != nil {
+		check.Result, check.Details = checkFail, err.Error()
+		return check
+	}
+
+	check.Result, check.Details = checkPass, fmt.Sprintf("%s (%s)", project.Slug, project.Id)
+	return check
+}
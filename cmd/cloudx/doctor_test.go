@@ -0,0 +1,38 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cloudx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/cli/cmd/cloudx/testhelpers"
+)
+
+func TestDoctor(t *testing.T) {
+	t.Run("case=signed in with a project", func(t *testing.T) {
+		configDir := testhelpers.NewConfigDir(t)
+		testhelpers.RegisterAccount(t, configDir)
+		project := testhelpers.CreateAndUseProject(t, configDir)
+
+		stdout, _, err := testhelpers.ConfigAwareCmd(configDir).Exec(nil, "doctor", "--format", "json")
+		require.NoError(t, err, stdout)
+		for _, check := range gjson.Get(stdout, "@this").Array() {
+			assert.Equal(t, "pass", check.Get("result").String(), check.Raw)
+		}
+		assert.Contains(t, gjson.Get(stdout, `#(name=="project resolved").details`).String(), project)
+	})
+
+	t.Run("case=not signed in", func(t *testing.T) {
+		configDir := testhelpers.NewConfigDir(t)
+
+		stdout, _, err := testhelpers.ConfigAwareCmd(configDir).Exec(nil, "doctor", "--format", "json")
+		require.Error(t, err)
+		assert.Equal(t, "fail", gjson.Get(stdout, `#(name=="signed in").result`).String(), stdout)
+		assert.Equal(t, "skip", gjson.Get(stdout, `#(name=="project resolved").result`).String(), stdout)
+	})
+}
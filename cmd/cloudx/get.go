@@ -25,12 +25,14 @@ func NewGetCmd() *cobra.Command {
 		project.NewGetKratosConfigCmd(),
 		project.NewGetKetoConfigCmd(),
 		project.NewGetOAuth2ConfigCmd(),
+		project.NewGetProjectConfigValueCmd(),
 		identity.NewGetIdentityCmd(),
 		oauth2.NewGetOAuth2Client(),
 		oauth2.NewGetJWK(),
 	)
 
 	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
 	client.RegisterYesFlag(cmd.PersistentFlags())
 	cmdx.RegisterNoiseFlags(cmd.PersistentFlags())
 
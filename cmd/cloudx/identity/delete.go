@@ -4,16 +4,119 @@
 package identity
 
 import (
+	"fmt"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/ory/cli/cmd/cloudx/client"
-	"github.com/ory/kratos/cmd/identities"
 	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+)
+
+const (
+	allFlag = "all"
+
+	// deleteAllPageSize is the number of identities listed at once when deleting all identities.
+	deleteAllPageSize = 250
 )
 
 func NewDeleteIdentityCmd() *cobra.Command {
-	cmd := identities.NewDeleteIdentityCmd()
+	cmd := &cobra.Command{
+		Use:   "identity <id-1> [<id-2> ...]",
+		Short: "Delete one or more identities of an Ory Network project.",
+		Long: `Delete one or more identities of an Ory Network project by their IDs, or all identities with --all.
+Without --project, the currently selected project is used.
+
+Deleting all identities asks for confirmation unless the --yes flag is set. If some identities
+cannot be deleted, the others are deleted anyway, the errors are printed, and the command fails.`,
+		Example: `$ ory delete identity 6ab92b75-f2ca-4e5a-9e79-2d9e7d4a6a5e c7f2c9d1-8a5e-4b8e-9d3a-1f5c0b3e2a10
+
+$ ory delete identity --all --project ecaaa3cb-0730-4ee8-a6df-9553cdfeef89 --yes`,
+		PreRunE: client.ResolveProject,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all := flagx.MustGetBool(cmd, allFlag)
+			if all && len(args) > 0 {
+				return errors.Errorf("please pass either identity IDs or --%s, but not both", allFlag)
+			} else if !all && len(args) == 0 {
+				return errors.Errorf("please pass at least one identity ID or --%s", allFlag)
+			}
+
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			project, err := h.ProjectID()
+			if err != nil {
+				return err
+			}
+
+			ids := args
+			if all {
+				if !h.NoConfirm {
+					ok, err := cmdx.AskScannerForConfirmation(fmt.Sprintf("Do you really want to delete all identities of the project %s? This cannot be undone.", project), h.Stdin, h.VerboseErrWriter)
+					if err != nil {
+						return err
+					} else if !ok {
+						return errors.New("no identities were deleted")
+					}
+				}
+
+				if ids, err = listAllIdentityIDs(h, project); err != nil {
+					return client.PrintOpenAPIError(cmd, err)
+				}
+			}
+
+			var (
+				deleted = make([]cmdx.OutputIder, 0, len(ids))
+				failed  = make(map[string]error)
+			)
+			for _, id := range ids {
+				if err := h.DeleteIdentity(project, id); err != nil {
+					failed[id] = err
+					continue
+				}
+				deleted = append(deleted, cmdx.OutputIder(id))
+			}
+
+			if len(deleted) == 1 && !all {
+				cmdx.PrintRow(cmd, &deleted[0])
+			} else if len(deleted) > 0 || all {
+				cmdx.PrintTable(cmd, &cmdx.OutputIderCollection{Items: deleted})
+			}
+
+			summary := fmt.Sprintf("Deleted %d of %d identities.", len(deleted), len(ids))
+			if len(failed) != 0 {
+				return client.FailWithErrors(cmd, h.VerboseErrWriter, summary, failed)
+			}
+			_, _ = fmt.Fprintln(h.VerboseErrWriter, summary)
+			return nil
+		},
+	}
+
 	client.RegisterProjectFlag(cmd.Flags())
+	cmd.Flags().Bool(allFlag, false, "Delete all identities of the project.")
 	cmdx.RegisterFormatFlags(cmd.Flags())
 	return cmd
 }
+
+// listAllIdentityIDs pages through the identities of the project. The IDs are collected before deleting any identity
+// so that the deletions do not shift the pages.
+func listAllIdentityIDs(h *client.CommandHelper, project string) ([]string, error) {
+	var ids []string
+	for pageToken := ""; ; {
+		identities, next, err := h.ListIdentities(project, deleteAllPageSize, pageToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range identities {
+			ids = append(ids, i.Id)
+		}
+
+		if len(next) == 0 {
+			return ids, nil
+		}
+		pageToken = next
+	}
+}
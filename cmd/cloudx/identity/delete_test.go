@@ -4,14 +4,17 @@
 package identity_test
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/gofrs/uuid/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson"
 
 	"github.com/ory/cli/cmd/cloudx/client"
 	"github.com/ory/cli/cmd/cloudx/testhelpers"
+	"github.com/ory/x/cmdx"
 )
 
 func TestDeleteIdentity(t *testing.T) {
@@ -41,4 +44,47 @@ func TestDeleteIdentity(t *testing.T) {
 		out := gjson.Parse(stdout)
 		assert.Equal(t, userID, out.String(), stdout)
 	})
+
+	t.Run("is able to delete multiple identities", func(t *testing.T) {
+		first := testhelpers.ImportIdentity(t, defaultCmd, defaultProject, nil)
+		second := testhelpers.ImportIdentity(t, defaultCmd, defaultProject, nil)
+		stdout, stderr, err := defaultCmd.Exec(nil, "delete", "identity", "--format", "json", "--project", defaultProject, first, second)
+		require.NoError(t, err, stderr)
+		assert.ElementsMatch(t, []interface{}{first, second}, gjson.Parse(stdout).Value())
+		assert.Contains(t, stderr, "Deleted 2 of 2 identities.")
+	})
+
+	t.Run("deletes the other identities if one fails", func(t *testing.T) {
+		userID := testhelpers.ImportIdentity(t, defaultCmd, defaultProject, nil)
+		missingID := uuid.Must(uuid.NewV4()).String()
+		stdout, stderr, err := defaultCmd.Exec(nil, "delete", "identity", "--format", "json", "--project", defaultProject, missingID, userID)
+		require.ErrorIs(t, err, cmdx.ErrNoPrintButFail)
+		assert.Contains(t, stdout, userID)
+		assert.Contains(t, stderr, missingID)
+		assert.Contains(t, stderr, "Deleted 1 of 2 identities.")
+	})
+
+	t.Run("is able to delete all identities", func(t *testing.T) {
+		project := testhelpers.CreateProject(t, defaultConfig)
+		first := testhelpers.ImportIdentity(t, defaultCmd, project, nil)
+		second := testhelpers.ImportIdentity(t, defaultCmd, project, nil)
+
+		_, _, err := defaultCmd.Exec(bytes.NewBufferString("n\n"), "delete", "identity", "--all", "--project", project)
+		require.Error(t, err)
+
+		stdout, stderr, err := defaultCmd.Exec(nil, "delete", "identity", "--all", "--yes", "--format", "json", "--project", project)
+		require.NoError(t, err, stderr)
+		assert.ElementsMatch(t, []interface{}{first, second}, gjson.Parse(stdout).Value())
+
+		stdout, stderr, err = defaultCmd.Exec(nil, "list", "identities", "--format", "json", "--project", project)
+		require.NoError(t, err, stderr)
+		assert.Len(t, gjson.Parse(stdout).Array(), 0)
+	})
+
+	t.Run("requires either IDs or --all", func(t *testing.T) {
+		_, _, err := defaultCmd.Exec(nil, "delete", "identity", "--project", defaultProject)
+		require.Error(t, err)
+		_, _, err = defaultCmd.Exec(nil, "delete", "identity", "--all", "--yes", "--project", defaultProject, "some-id")
+		require.Error(t, err)
+	})
 }
@@ -4,15 +4,69 @@
 package identity
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/ory/cli/cmd/cloudx/client"
-	"github.com/ory/kratos/cmd/identities"
+	cloud "github.com/ory/client-go"
 	"github.com/ory/x/cmdx"
 )
 
 func NewGetIdentityCmd() *cobra.Command {
-	cmd := identities.NewGetIdentityCmd()
+	cmd := &cobra.Command{
+		Use:   "identity <id-1> [<id-2> ...]",
+		Args:  cobra.MinimumNArgs(1),
+		Short: "Get one or more identities of an Ory Network project.",
+		Long: `Get one or more identities of an Ory Network project by their IDs. Without --project, the currently
+selected project is used.
+
+For a single ID, --format json prints the complete identity including its traits, verifiable
+addresses, and recovery addresses. For multiple IDs, a table of all found identities is printed.
+If some identities do not exist, their IDs are printed to stderr and the command fails.`,
+		Example: `$ ory get identity 6ab92b75-f2ca-4e5a-9e79-2d9e7d4a6a5e --format json
+
+$ ory get identity $(ory list identities --quiet)`,
+		PreRunE: client.ResolveProject,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			project, err := h.ProjectID()
+			if err != nil {
+				return err
+			}
+
+			identities := make([]cloud.Identity, 0, len(args))
+			var missing []string
+			for _, id := range args {
+				identity, err := h.GetIdentity(project, id)
+				if errors.Is(err, client.ErrIdentityNotFound) {
+					missing = append(missing, id)
+					continue
+				} else if err != nil {
+					return client.PrintOpenAPIError(cmd, err)
+				}
+				identities = append(identities, *identity)
+			}
+
+			if len(args) == 1 && len(identities) == 1 {
+				cmdx.PrintRow(cmd, (*outputIdentity)(&identities[0]))
+			} else if len(identities) > 0 {
+				cmdx.PrintTable(cmd, &outputIdentityCollection{identities})
+			}
+
+			if len(missing) > 0 {
+				return client.FailWithDetails(cmd, cmd.ErrOrStderr(), fmt.Sprintf("Could not find the identities: %s", strings.Join(missing, ", ")), map[string][]string{"missing_ids": missing})
+			}
+			return nil
+		},
+	}
+
 	client.RegisterProjectFlag(cmd.Flags())
 	cmdx.RegisterFormatFlags(cmd.Flags())
 	return cmd
@@ -8,7 +8,9 @@ import (
 
 	"github.com/ory/cli/cmd/cloudx/client"
 	"github.com/ory/cli/cmd/cloudx/testhelpers"
+	"github.com/ory/x/cmdx"
 
+	"github.com/gofrs/uuid/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson"
@@ -29,8 +31,26 @@ func TestGetIdentity(t *testing.T) {
 		require.NoError(t, err, stderr)
 		out := gjson.Parse(stdout)
 		assert.True(t, gjson.Valid(stdout))
-		assert.Len(t, out.Array(), 1)
+		assert.Equal(t, userID, out.Get("id").String())
+		assert.True(t, out.Get("traits").IsObject())
+	})
+
+	t.Run("is able to get multiple identities", func(t *testing.T) {
+		otherID := testhelpers.ImportIdentity(t, defaultCmd, defaultProject, nil)
+		stdout, stderr, err := defaultCmd.Exec(nil, "get", "identity", "--format", "json", "--project", defaultProject, userID, otherID)
+		require.NoError(t, err, stderr)
+		out := gjson.Parse(stdout)
+		assert.Len(t, out.Array(), 2)
 		assert.Equal(t, userID, out.Array()[0].Get("id").String())
+		assert.Equal(t, otherID, out.Array()[1].Get("id").String())
+	})
+
+	t.Run("lists the missing identities", func(t *testing.T) {
+		missingID := uuid.Must(uuid.NewV4()).String()
+		stdout, stderr, err := defaultCmd.Exec(nil, "get", "identity", "--format", "json", "--project", defaultProject, userID, missingID)
+		require.ErrorIs(t, err, cmdx.ErrNoPrintButFail)
+		assert.Equal(t, userID, gjson.Parse(stdout).Array()[0].Get("id").String())
+		assert.Contains(t, stderr, missingID)
 	})
 
 	t.Run("is able to get identity after authenticating", func(t *testing.T) {
@@ -38,8 +58,6 @@ func TestGetIdentity(t *testing.T) {
 		stdout, stderr, err := cmd.Exec(r, "get", "identity", "--format", "json", "--project", defaultProject, userID)
 		require.NoError(t, err, stderr)
 		assert.True(t, gjson.Valid(stdout))
-		out := gjson.Parse(stdout)
-		assert.Len(t, out.Array(), 1)
-		assert.Equal(t, userID, out.Array()[0].Get("id").String())
+		assert.Equal(t, userID, gjson.Parse(stdout).Get("id").String())
 	})
 }
@@ -4,14 +4,104 @@
 package identity
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/tidwall/sjson"
 
 	"github.com/ory/cli/cmd/cloudx/client"
-	"github.com/ory/kratos/cmd/identities"
+	cloud "github.com/ory/client-go"
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
 )
 
-func NewImportIdentityCmd() *cobra.Command {
-	cmd := identities.NewImportIdentitiesCmd()
+func NewImportIdentitiesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "identities [file.json] [file-2.jsonl] [file-n.json]",
+		Short: "Import identities into an Ory Network project.",
+		Long: `Import identities into an Ory Network project from files or STD_IN. Without --project, the currently
+selected project is used.
+
+Files can contain a single identity, an array of identities, or one identity per line (JSONL).
+Identities may include credentials such as hashed passwords, which makes this command useful to
+migrate users from other systems. Every identity is validated before it is imported, and invalid
+identities or failed imports do not stop the others from being imported. A summary is printed
+at the end, and the command fails if any identity was not imported.`,
+		Example: `$ ory import identities users.json --schema-id preset://email
+
+$ cat users.json | ory import identities --project ecaaa3cb-0730-4ee8-a6df-9553cdfeef89`,
+		PreRunE: client.ResolveProject,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			project, err := h.ProjectID()
+			if err != nil {
+				return err
+			}
+
+			is, err := readIdentitySources(cmd, append(args, flagx.MustGetStringSlice(cmd, fileFlag)...))
+			if err != nil {
+				return err
+			}
+
+			if schemaID := flagx.MustGetString(cmd, schemaIDFlag); len(schemaID) > 0 {
+				for k := range is {
+					is[k].identity, err = sjson.Set(is[k].identity, "schema_id", schemaID)
+					if err != nil {
+						return errors.Wrapf(err, "%s: could not set the schema ID", is[k].src)
+					}
+				}
+			}
+
+			getSchema := func(_ context.Context, id string) (map[string]interface{}, *http.Response, error) {
+				schema, err := h.GetIdentitySchema(project, id)
+				return schema, nil, err
+			}
+
+			var (
+				imported = make([]cloud.Identity, 0, len(is))
+				failed   = make(map[string]error)
+			)
+			for _, i := range is {
+				if err := validateIdentity(cmd, i.src, i.identity, getSchema); errors.Is(err, errInvalidIdentity) {
+					failed[i.src] = err
+					continue
+				} else if err != nil {
+					return err
+				}
+
+				identity, err := h.ImportIdentity(project, json.RawMessage(i.identity))
+				if err != nil {
+					failed[i.src] = err
+					continue
+				}
+				imported = append(imported, *identity)
+			}
+
+			if len(imported) == 1 {
+				cmdx.PrintRow(cmd, (*outputIdentity)(&imported[0]))
+			} else {
+				cmdx.PrintTable(cmd, &outputIdentityCollection{identities: imported})
+			}
+
+			summary := fmt.Sprintf("Imported %d of %d identities, %d failed.", len(imported), len(is), len(failed))
+			if len(failed) != 0 {
+				return client.FailWithErrors(cmd, h.VerboseErrWriter, summary, failed)
+			}
+			_, _ = fmt.Fprintln(h.VerboseErrWriter, summary)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSlice(fileFlag, nil, "Read identities from this file. Can be repeated.")
+	cmd.Flags().String(schemaIDFlag, "", "Import all identities with this identity schema, regardless of their schema_id.")
 	client.RegisterProjectFlag(cmd.Flags())
 	return cmd
 }
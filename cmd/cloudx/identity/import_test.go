@@ -4,13 +4,17 @@
 package identity_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/ory/cli/cmd/cloudx/testhelpers"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
 
 	"github.com/ory/cli/cmd/cloudx/client"
+	"github.com/ory/x/cmdx"
 )
 
 func TestImportIdentity(t *testing.T) {
@@ -29,4 +33,32 @@ func TestImportIdentity(t *testing.T) {
 		cmd, r := testhelpers.WithReAuth(t, defaultEmail, defaultPassword)
 		testhelpers.ImportIdentity(t, cmd, defaultProject, r)
 	})
+
+	t.Run("is able to import an array of identities with hashed passwords from STD_IN", func(t *testing.T) {
+		first, second := testhelpers.FakeEmail(), testhelpers.FakeEmail()
+		stdin := bytes.NewBufferString(`[
+  {"traits": {"username": "` + first + `"}, "credentials": {"password": {"config": {"hashed_password": "$2a$10$ZsCsoVQ3xfBG/K2z2XpBf.tm90GZmtOqtqWcB5.pYd5Eq8y7RlDyq"}}}},
+  {"traits": {"username": "` + second + `"}}
+]`)
+		stdout, stderr, err := defaultCmd.Exec(stdin, "import", "identities", "--format", "json", "--project", defaultProject, "--schema-id", "preset://username")
+		require.NoError(t, err, stderr)
+		out := gjson.Parse(stdout).Array()
+		require.Len(t, out, 2)
+		assert.Equal(t, first, out[0].Get("traits.username").String())
+		assert.Equal(t, second, out[1].Get("traits.username").String())
+		assert.Contains(t, stderr, "Imported 2 of 2 identities, 0 failed.")
+	})
+
+	t.Run("continues after invalid identities", func(t *testing.T) {
+		email := testhelpers.FakeEmail()
+		stdin := bytes.NewBufferString(`[
+  {"schema_id": "preset://username", "traits": {"not-a-trait": true}},
+  {"schema_id": "preset://username", "traits": {"username": "` + email + `"}}
+]`)
+		stdout, stderr, err := defaultCmd.Exec(stdin, "import", "identities", "--format", "json", "--project", defaultProject)
+		require.ErrorIs(t, err, cmdx.ErrNoPrintButFail)
+		assert.Equal(t, email, gjson.Get(stdout, "traits.username").String())
+		assert.Contains(t, stderr, "STD_IN[0]")
+		assert.Contains(t, stderr, "Imported 1 of 2 identities, 1 failed.")
+	})
 }
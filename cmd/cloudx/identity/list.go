@@ -4,14 +4,62 @@
 package identity
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
 	"github.com/ory/cli/cmd/cloudx/client"
-	"github.com/ory/kratos/cmd/identities"
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
 )
 
-func NewListIdentityCmd() *cobra.Command {
-	cmd := identities.NewListIdentitiesCmd()
+func NewListIdentitiesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "identities",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		Short:   "List the identities of an Ory Network project.",
+		Long: `List the identities of an Ory Network project. Without --project, the currently selected project is used.
+
+The table shows the ID, schema, and primary identifier of every identity. Use --format json to
+print the full identity objects.`,
+		Example: `$ ory list identities
+
+$ ory list identities --project ecaaa3cb-0730-4ee8-a6df-9553cdfeef89 --page-size 10
+
+$ ory list identities --format json`,
+		PreRunE: client.ResolveProject,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			pageToken, pageSize, err := cmdx.ParseTokenPaginationArgs(cmd)
+			if err != nil {
+				return err
+			}
+
+			project, err := h.ProjectID()
+			if err != nil {
+				return err
+			}
+
+			identities, nextPageToken, err := h.ListIdentities(project, pageSize, pageToken)
+			if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+
+			cmdx.PrintTable(cmd, &outputIdentityCollection{identities})
+			if len(nextPageToken) > 0 && !flagx.MustGetBool(cmd, cmdx.FlagQuiet) {
+				// The footer goes to stderr so that the output stays parseable.
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "\nThere are more identities. To list them, add --%s %s\n", cmdx.FlagPageToken, nextPageToken)
+			}
+			return nil
+		},
+	}
+
 	client.RegisterProjectFlag(cmd.Flags())
+	cmdx.RegisterTokenPaginationFlags(cmd)
 	return cmd
 }
@@ -5,6 +5,7 @@ package identity_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/ory/cli/cmd/cloudx/client"
@@ -47,4 +48,29 @@ func TestListIdentities(t *testing.T) {
 		assert.Len(t, out.Array(), 1)
 		assert.Equal(t, userID, out.Array()[0].Get("id").String())
 	})
+
+	t.Run("is able to paginate identities", func(t *testing.T) {
+		secondID := testhelpers.ImportIdentity(t, defaultCmd, project, nil)
+
+		stdout, stderr, err := defaultCmd.Exec(nil, "list", "identities", "--format", "json", "--project", project, "--page-size", "1")
+		require.NoError(t, err, stderr)
+		first := gjson.Parse(stdout).Array()
+		require.Len(t, first, 1)
+		require.Contains(t, stderr, "--page-token")
+
+		token := stderr[strings.LastIndex(stderr, " ")+1:]
+		stdout, stderr, err = defaultCmd.Exec(nil, "list", "identities", "--format", "json", "--project", project, "--page-size", "1", "--page-token", strings.TrimSpace(token))
+		require.NoError(t, err, stderr)
+		second := gjson.Parse(stdout).Array()
+		require.Len(t, second, 1)
+
+		assert.ElementsMatch(t, []string{userID, secondID}, []string{first[0].Get("id").String(), second[0].Get("id").String()})
+	})
+
+	t.Run("prints the primary identifier", func(t *testing.T) {
+		stdout, stderr, err := defaultCmd.Exec(nil, "list", "identities", "--project", project)
+		require.NoError(t, err, stderr)
+		assert.Contains(t, stdout, "PRIMARY IDENTIFIER")
+		assert.Contains(t, stdout, userID)
+	})
 }
@@ -0,0 +1,184 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package identity
+
+import (
+	"time"
+
+	cloud "github.com/ory/client-go"
+)
+
+type (
+	outputIdentity           cloud.Identity
+	outputIdentityCollection struct {
+		identities []cloud.Identity
+	}
+)
+
+func (i *outputIdentity) ID() string {
+	return i.Id
+}
+
+func (*outputIdentity) Header() []string {
+	return []string{"ID", "SCHEMA ID", "STATE", "VERIFIABLE ADDRESS"}
+}
+
+func (i *outputIdentity) Columns() []string {
+	return identityColumns((*cloud.Identity)(i))
+}
+
+func (i *outputIdentity) Interface() interface{} {
+	return (*cloud.Identity)(i)
+}
+
+func (*outputIdentityCollection) Header() []string {
+	return []string{"ID", "SCHEMA ID", "PRIMARY IDENTIFIER"}
+}
+
+func (c *outputIdentityCollection) Table() [][]string {
+	rows := make([][]string, len(c.identities))
+	for i := range c.identities {
+		identity := &c.identities[i]
+		rows[i] = []string{identity.Id, identity.SchemaId, primaryIdentifier(identity)}
+	}
+	return rows
+}
+
+func (c *outputIdentityCollection) IDs() []string {
+	ids := make([]string, len(c.identities))
+	for i := range c.identities {
+		ids[i] = c.identities[i].Id
+	}
+	return ids
+}
+
+func (c *outputIdentityCollection) Interface() interface{} {
+	return c.identities
+}
+
+func (c *outputIdentityCollection) Len() int {
+	return len(c.identities)
+}
+
+func identityColumns(i *cloud.Identity) []string {
+	state := "<none>"
+	if i.State != nil {
+		state = string(*i.State)
+	}
+
+	address := "<none>"
+	if len(i.VerifiableAddresses) > 0 {
+		address = i.VerifiableAddresses[0].Value
+	}
+
+	return []string{
+		i.Id,
+		i.SchemaId,
+		state,
+		address,
+	}
+}
+
+// primaryIdentifier returns the first verifiable address of the identity, or its email or username trait if it has no
+// verifiable addresses.
+func primaryIdentifier(i *cloud.Identity) string {
+	if len(i.VerifiableAddresses) > 0 {
+		return i.VerifiableAddresses[0].Value
+	}
+
+	if traits, ok := i.Traits.(map[string]interface{}); ok {
+		for _, key := range []string{"email", "username"} {
+			if value, ok := traits[key].(string); ok && len(value) > 0 {
+				return value
+			}
+		}
+	}
+	return "<none>"
+}
+
+type outputSessionCollection struct {
+	sessions []cloud.Session
+}
+
+func (*outputSessionCollection) Header() []string {
+	return []string{"ID", "ISSUED AT", "EXPIRES AT", "STATE"}
+}
+
+func (c *outputSessionCollection) Table() [][]string {
+	rows := make([][]string, len(c.sessions))
+	for i := range c.sessions {
+		s := &c.sessions[i]
+		rows[i] = []string{s.Id, formatTime(s.IssuedAt), formatTime(s.ExpiresAt), sessionState(s, time.Now())}
+	}
+	return rows
+}
+
+func (c *outputSessionCollection) IDs() []string {
+	ids := make([]string, len(c.sessions))
+	for i := range c.sessions {
+		ids[i] = c.sessions[i].Id
+	}
+	return ids
+}
+
+func (c *outputSessionCollection) Interface() interface{} {
+	return c.sessions
+}
+
+func (c *outputSessionCollection) Len() int {
+	return len(c.sessions)
+}
+
+// sessionState tells revoked sessions, which are inactive, apart from expired ones.
+func sessionState(s *cloud.Session, now time.Time) string {
+	switch {
+	case s.ExpiresAt != nil && s.ExpiresAt.Before(now):
+		return "expired"
+	case !s.GetActive():
+		return "revoked"
+	default:
+		return "active"
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "<none>"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+type (
+	validationResult struct {
+		Source string `json:"source"`
+		Valid  bool   `json:"valid"`
+	}
+	outputValidationResults struct {
+		results []validationResult
+	}
+)
+
+func (*outputValidationResults) Header() []string {
+	return []string{"SOURCE", "RESULT"}
+}
+
+func (c *outputValidationResults) Table() [][]string {
+	rows := make([][]string, len(c.results))
+	for i, r := range c.results {
+		result := "valid"
+		if !r.Valid {
+			result = "invalid"
+		}
+		rows[i] = []string{r.Source, result}
+	}
+	return rows
+}
+
+func (c *outputValidationResults) Interface() interface{} {
+	return c.results
+}
+
+func (c *outputValidationResults) Len() int {
+	return len(c.results)
+}
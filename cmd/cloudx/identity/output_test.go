@@ -0,0 +1,38 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package identity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cloud "github.com/ory/client-go"
+)
+
+func TestOutputIdentityCollection(t *testing.T) {
+	active := cloud.IDENTITYSTATE_ACTIVE
+	c := &outputIdentityCollection{identities: []cloud.Identity{
+		{Id: "a", SchemaId: "default", State: &active, VerifiableAddresses: []cloud.VerifiableIdentityAddress{{Value: "a@example.com"}}},
+		{Id: "b", SchemaId: "customer", Traits: map[string]interface{}{"email": "b@example.com"}},
+		{Id: "c", SchemaId: "customer", Traits: map[string]interface{}{"username": "c"}},
+		{Id: "d", SchemaId: "default"},
+	}}
+
+	assert.Equal(t, []string{"ID", "SCHEMA ID", "PRIMARY IDENTIFIER"}, c.Header())
+	assert.Equal(t, [][]string{
+		{"a", "default", "a@example.com"},
+		{"b", "customer", "b@example.com"},
+		{"c", "customer", "c"},
+		{"d", "default", "<none>"},
+	}, c.Table())
+	assert.Equal(t, []string{"a", "b", "c", "d"}, c.IDs())
+	assert.Equal(t, 4, c.Len())
+	assert.Equal(t, c.identities, c.Interface())
+
+	t.Run("case=single identity", func(t *testing.T) {
+		assert.Equal(t, []string{"a", "default", "active", "a@example.com"}, (*outputIdentity)(&c.identities[0]).Columns())
+		assert.Equal(t, []string{"d", "default", "<none>", "<none>"}, (*outputIdentity)(&c.identities[3]).Columns())
+	})
+}
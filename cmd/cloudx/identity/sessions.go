@@ -0,0 +1,120 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package identity
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+)
+
+const identityFlag = "identity"
+
+func NewListSessionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions <identity-id>",
+		Args:  cobra.ExactArgs(1),
+		Short: "List the sessions of an identity.",
+		Long: `List all sessions of an identity of an Ory Network project, including revoked and expired ones.
+Without --project, the currently selected project is used.`,
+		Example: `$ ory list sessions 6ab92b75-f2ca-4e5a-9e79-2d9e7d4a6a5e
+
+ID					ISSUED AT		EXPIRES AT		STATE
+e2d6b0a6-41e5-4c4b-82d2-6b6b5d0c1e9f	2023-01-02T15:04:05Z	2023-01-05T15:04:05Z	active`,
+		PreRunE: client.ResolveProject,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			project, err := h.ProjectID()
+			if err != nil {
+				return err
+			}
+
+			sessions, err := h.ListIdentitySessions(project, args[0])
+			if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+
+			cmdx.PrintTable(cmd, &outputSessionCollection{sessions})
+			return nil
+		},
+	}
+
+	client.RegisterProjectFlag(cmd.Flags())
+	return cmd
+}
+
+func NewRevokeSessionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session [<session-id>]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Revoke a session, or all sessions of an identity.",
+		Long: `Revoke a session of an Ory Network project, or all sessions of an identity with --all and --identity.
+Without --project, the currently selected project is used.
+
+Revoking a session that does not exist or has already expired is not an error.`,
+		Example: `$ ory revoke session e2d6b0a6-41e5-4c4b-82d2-6b6b5d0c1e9f
+
+$ ory revoke session --all --identity 6ab92b75-f2ca-4e5a-9e79-2d9e7d4a6a5e`,
+		PreRunE: client.ResolveProject,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, identityID := flagx.MustGetBool(cmd, allFlag), flagx.MustGetString(cmd, identityFlag)
+			switch {
+			case all && len(args) > 0:
+				return errors.Errorf("please pass either a session ID or --%s, but not both", allFlag)
+			case all && len(identityID) == 0:
+				return errors.Errorf("--%s requires --%s", allFlag, identityFlag)
+			case !all && len(args) == 0:
+				return errors.Errorf("please pass a session ID or --%s with --%s", allFlag, identityFlag)
+			}
+
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			project, err := h.ProjectID()
+			if err != nil {
+				return err
+			}
+
+			if all {
+				err := h.RevokeIdentitySessions(project, identityID)
+				if errors.Is(err, client.ErrSessionNotFound) {
+					_, _ = fmt.Fprintf(h.VerboseErrWriter, "The identity %s has no active sessions.\n", identityID)
+				} else if err != nil {
+					return client.PrintOpenAPIError(cmd, err)
+				} else {
+					_, _ = fmt.Fprintf(h.VerboseErrWriter, "Revoked all sessions of the identity %s.\n", identityID)
+				}
+
+				cmdx.PrintRow(cmd, cmdx.OutputIder(identityID))
+				return nil
+			}
+
+			err = h.RevokeSession(project, args[0])
+			if errors.Is(err, client.ErrSessionNotFound) {
+				_, _ = fmt.Fprintf(h.VerboseErrWriter, "The session %s does not exist or has already expired.\n", args[0])
+			} else if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+
+			cmdx.PrintRow(cmd, cmdx.OutputIder(args[0]))
+			return nil
+		},
+	}
+
+	client.RegisterProjectFlag(cmd.Flags())
+	cmd.Flags().Bool(allFlag, false, "Revoke all sessions of the identity set with --"+identityFlag+".")
+	cmd.Flags().String(identityFlag, "", "The identity whose sessions to revoke with --"+allFlag+".")
+	return cmd
+}
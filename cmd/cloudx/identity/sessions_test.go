@@ -0,0 +1,56 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package identity_test
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+	"github.com/ory/cli/cmd/cloudx/testhelpers"
+)
+
+func TestListSessions(t *testing.T) {
+	userID := testhelpers.ImportIdentity(t, defaultCmd, defaultProject, nil)
+
+	t.Run("is not able to list sessions if not authenticated and quiet flag", func(t *testing.T) {
+		configDir := testhelpers.NewConfigDir(t)
+		cmd := testhelpers.ConfigAwareCmd(configDir)
+		_, _, err := cmd.Exec(nil, "list", "sessions", "--quiet", "--project", defaultProject, userID)
+		require.ErrorIs(t, err, client.ErrNoConfigQuiet)
+	})
+
+	t.Run("is able to list the sessions of an identity without sessions", func(t *testing.T) {
+		stdout, stderr, err := defaultCmd.Exec(nil, "list", "sessions", "--format", "json", "--project", defaultProject, userID)
+		require.NoError(t, err, stderr)
+		assert.True(t, gjson.Parse(stdout).IsArray(), stdout)
+		assert.Len(t, gjson.Parse(stdout).Array(), 0)
+	})
+}
+
+func TestRevokeSession(t *testing.T) {
+	t.Run("ignores sessions that do not exist", func(t *testing.T) {
+		sessionID := uuid.Must(uuid.NewV4()).String()
+		stdout, stderr, err := defaultCmd.Exec(nil, "revoke", "session", "--format", "json", "--project", defaultProject, sessionID)
+		require.NoError(t, err, stderr)
+		assert.Equal(t, sessionID, gjson.Parse(stdout).String())
+		assert.Contains(t, stderr, "does not exist or has already expired")
+	})
+
+	t.Run("is able to revoke all sessions of an identity", func(t *testing.T) {
+		userID := testhelpers.ImportIdentity(t, defaultCmd, defaultProject, nil)
+		stdout, stderr, err := defaultCmd.Exec(nil, "revoke", "session", "--all", "--identity", userID, "--format", "json", "--project", defaultProject)
+		require.NoError(t, err, stderr)
+		assert.Equal(t, userID, gjson.Parse(stdout).String())
+	})
+
+	t.Run("requires --identity with --all", func(t *testing.T) {
+		_, _, err := defaultCmd.Exec(nil, "revoke", "session", "--all", "--project", defaultProject)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,187 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package identity
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+	"github.com/ory/kratos/cmd/cliclient"
+	"github.com/ory/kratos/cmd/identities"
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+)
+
+const (
+	fileFlag     = "file"
+	schemaIDFlag = "schema-id"
+)
+
+type identitySource struct {
+	src, identity string
+}
+
+func NewValidateIdentityCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "identity [file.json] [file-2.jsonl] [file-n.json]",
+		Short: "Validate identity files without importing them",
+		Long: `Validate identity files against the API payload and the identity schema of the project without importing them.
+
+Files can contain a single identity, an array of identities, or one identity per line (JSONL). Identities are
+read from STD_IN if no files are given. All identities are validated, and the command fails if any one of
+them is invalid.`,
+		Example: `$ ory validate identity --file identities.jsonl --schema-id preset://email
+
+$ ory validate identity identity-1.json identity-2.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := cliclient.NewClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			is, err := readIdentitySources(cmd, append(args, flagx.MustGetStringSlice(cmd, fileFlag)...))
+			if err != nil {
+				return err
+			}
+
+			if schemaID := flagx.MustGetString(cmd, schemaIDFlag); len(schemaID) > 0 {
+				for k := range is {
+					is[k].identity, err = sjson.Set(is[k].identity, "schema_id", schemaID)
+					if err != nil {
+						return errors.Wrapf(err, "%s: could not set the schema ID", is[k].src)
+					}
+				}
+			}
+
+			results := make([]validationResult, len(is))
+			invalid := make(map[string]error)
+			for k, i := range is {
+				err := validateIdentity(cmd, i.src, i.identity, func(ctx context.Context, id string) (map[string]interface{}, *http.Response, error) {
+					return c.V0alpha2Api.GetIdentitySchema(ctx, id).Execute()
+				})
+				if errors.Is(err, errInvalidIdentity) {
+					invalid[i.src] = err
+				} else if err != nil {
+					return err
+				}
+
+				results[k] = validationResult{Source: i.src, Valid: err == nil}
+			}
+
+			cmdx.PrintTable(cmd, &outputValidationResults{results: results})
+			if len(invalid) == 0 {
+				return nil
+			} else if client.JSONErrors(cmd) {
+				return client.FailWithErrors(cmd, cmd.ErrOrStderr(), fmt.Sprintf("%d of %d identities are invalid.", len(invalid), len(is)), invalid)
+			}
+			// The validation errors were already printed.
+			return cmdx.FailSilently(cmd)
+		},
+	}
+
+	cmd.Flags().StringSlice(fileFlag, nil, "Read identities from this file. Can be repeated.")
+	cmd.Flags().String(schemaIDFlag, "", "Validate all identities against this identity schema, regardless of their schema_id.")
+	client.RegisterProjectFlag(cmd.Flags())
+	return cmd
+}
+
+// errInvalidIdentity is returned by validateIdentity if the identity is invalid.
+var errInvalidIdentity = errors.New("the identity is invalid")
+
+// validateIdentity validates the identity with identities.ValidateIdentity, which prints why the identity is invalid
+// to stderr. When errors are printed as JSON, that output is returned as part of the error instead.
+func validateIdentity(cmd *cobra.Command, src, identity string, getSchema identities.SchemaGetter) error {
+	if !client.JSONErrors(cmd) {
+		if err := identities.ValidateIdentity(cmd, src, identity, getSchema); errors.Is(err, cmdx.ErrNoPrintButFail) {
+			return errors.WithStack(errInvalidIdentity)
+		} else if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	var out bytes.Buffer
+	stderr := cmd.ErrOrStderr()
+	cmd.SetErr(&out)
+	defer cmd.SetErr(stderr)
+
+	if err := identities.ValidateIdentity(cmd, src, identity, getSchema); errors.Is(err, cmdx.ErrNoPrintButFail) {
+		return errors.Wrap(errInvalidIdentity, strings.TrimSpace(out.String()))
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// readIdentitySources reads the identities from the files, or STD_IN if no files are given.
+func readIdentitySources(cmd *cobra.Command, files []string) ([]identitySource, error) {
+	if len(files) == 0 {
+		contents, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read from STD_IN")
+		}
+		return parseIdentitySources("STD_IN", contents)
+	}
+
+	var is []identitySource
+	for _, file := range files {
+		contents, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not open file %s", file)
+		}
+		parsed, err := parseIdentitySources(file, contents)
+		if err != nil {
+			return nil, err
+		}
+		is = append(is, parsed...)
+	}
+	return is, nil
+}
+
+// parseIdentitySources splits the contents into single identities. Single objects and arrays are parsed as JSON,
+// everything else as JSONL with one identity per line.
+func parseIdentitySources(src string, contents []byte) ([]identitySource, error) {
+	if gjson.ValidBytes(contents) {
+		parsed := gjson.ParseBytes(contents)
+		if !parsed.IsArray() {
+			return []identitySource{{src: src, identity: parsed.Raw}}, nil
+		}
+
+		var is []identitySource
+		for k, i := range parsed.Array() {
+			is = append(is, identitySource{src: fmt.Sprintf("%s[%d]", src, k), identity: i.Raw})
+		}
+		return is, nil
+	}
+
+	var is []identitySource
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		identity := strings.TrimSpace(scanner.Text())
+		if len(identity) == 0 {
+			continue
+		}
+		if !gjson.Valid(identity) {
+			return nil, errors.Errorf("%s:%d: not valid JSON", src, line)
+		}
+		is = append(is, identitySource{src: fmt.Sprintf("%s:%d", src, line), identity: identity})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "could not read %s", src)
+	}
+	return is, nil
+}
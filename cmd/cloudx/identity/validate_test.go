@@ -0,0 +1,37 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package identity_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/cli/cmd/cloudx/testhelpers"
+)
+
+func TestValidateIdentity(t *testing.T) {
+	t.Run("is able to validate a single identity", func(t *testing.T) {
+		stdout, stderr, err := defaultCmd.Exec(nil, "validate", "identity", "--format", "json", "--project", defaultProject, testhelpers.MakeRandomIdentity(t, testhelpers.FakeEmail()))
+		require.NoError(t, err, stderr)
+		assert.True(t, gjson.Get(stdout, "0.valid").Bool(), stdout)
+	})
+
+	t.Run("is able to validate a batch of identities", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "identities.jsonl")
+		require.NoError(t, os.WriteFile(path, []byte(`{"traits":{"username":"`+testhelpers.FakeEmail()+`"}}
+{"traits":{}}
+{"traits":{"username":"`+testhelpers.FakeEmail()+`"}}
+`), 0600))
+
+		stdout, stderr, err := defaultCmd.Exec(nil, "validate", "identity", "--format", "json", "--project", defaultProject, "--schema-id", "preset://username", "--file", path)
+		require.Error(t, err)
+		assert.Contains(t, stderr, path+":2: not valid")
+		assert.Equal(t, []interface{}{true, false, true}, gjson.Get(stdout, "#.valid").Value(), stdout)
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/ory/cli/cmd/cloudx/client"
 	"github.com/ory/cli/cmd/cloudx/identity"
+	"github.com/ory/cli/cmd/cloudx/project"
 	"github.com/ory/x/cmdx"
 )
 
@@ -20,12 +21,14 @@ func NewImportCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(
-		identity.NewImportIdentityCmd(),
+		project.NewImportConfigCmd(),
+		identity.NewImportIdentitiesCmd(),
 		oauth2.NewImportOAuth2Client(),
 		oauth2.NewImportJWK(),
 	)
 
 	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
 	client.RegisterYesFlag(cmd.PersistentFlags())
 	cmdx.RegisterNoiseFlags(cmd.PersistentFlags())
 	cmdx.RegisterJSONFormatFlags(cmd.PersistentFlags())
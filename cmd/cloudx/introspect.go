@@ -20,6 +20,7 @@ func NewIntrospectCmd() *cobra.Command {
 	cmd.AddCommand(oauth2.NewIntrospectToken())
 
 	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
 	client.RegisterYesFlag(cmd.PersistentFlags())
 	cmdx.RegisterHTTPClientFlags(cmd.PersistentFlags())
 	cmdx.RegisterFormatFlags(cmd.PersistentFlags())
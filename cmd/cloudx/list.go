@@ -24,12 +24,14 @@ func NewListCmd() *cobra.Command {
 
 	cmd.AddCommand(
 		project.NewListProjectsCmd(),
-		identity.NewListIdentityCmd(),
+		identity.NewListIdentitiesCmd(),
+		identity.NewListSessionsCmd(),
 		oauth2.NewListOAuth2Clients(),
 		relationtuples.NewListCmd(),
 	)
 
 	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
 	client.RegisterYesFlag(cmd.PersistentFlags())
 	cmdx.RegisterNoiseFlags(cmd.PersistentFlags())
 	cmdx.RegisterJSONFormatFlags(cmd.PersistentFlags())
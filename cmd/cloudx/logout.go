@@ -28,5 +28,6 @@ func NewLogoutCmd() *cobra.Command {
 		},
 	}
 	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
 	return cmd
 }
@@ -4,6 +4,7 @@
 package oauth2_test
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 
@@ -11,6 +12,7 @@ import (
 
 	"github.com/ory/cli/cmd/cloudx/client"
 	"github.com/ory/cli/cmd/cloudx/testhelpers"
+	"github.com/ory/x/cmdx"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -92,6 +94,18 @@ func TestGetClient(t *testing.T) {
 		assert.Len(t, out.Array(), 1)
 		assert.Equal(t, userID, out.Array()[0].Get("client_id").String())
 	})
+
+	t.Run("returns the error for --json-errors", func(t *testing.T) {
+		_, stderr, err := defaultCmd.Exec(nil, "get", "oauth2-client", "--json-errors", "--project", defaultProject, uuid.Must(uuid.NewV4()).String())
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, cmdx.ErrNoPrintButFail)
+		assert.Empty(t, stderr)
+
+		var buf bytes.Buffer
+		client.PrintJSONError(&buf, err)
+		assert.Equal(t, "error", gjson.Get(buf.String(), "type").String(), buf.String())
+		assert.NotEmpty(t, gjson.Get(buf.String(), "message").String(), buf.String())
+	})
 }
 
 func TestImportClient(t *testing.T) {
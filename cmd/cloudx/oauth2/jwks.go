@@ -15,6 +15,7 @@ func wrapHydraCmd(newCmd func() *cobra.Command) *cobra.Command {
 	c := newCmd()
 	client.RegisterProjectFlag(c.Flags())
 	cmdx.RegisterFormatFlags(c.Flags())
+	client.WrapJSONErrors(c)
 	return c
 }
 
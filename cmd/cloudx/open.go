@@ -19,6 +19,7 @@ func NewOpenCmd() *cobra.Command {
 	cmd.AddCommand(accountexperience.NewAccountExperienceOpenCmd())
 	client.RegisterProjectFlag(cmd.PersistentFlags())
 	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
 	cmdx.RegisterNoiseFlags(cmd.PersistentFlags())
 	client.RegisterYesFlag(cmd.PersistentFlags())
 
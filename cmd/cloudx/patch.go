@@ -16,6 +16,7 @@ func NewPatchCmd() *cobra.Command {
 		Short: "Patch resources",
 	}
 	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
 	cmd.AddCommand(
 		project.NewProjectsPatchCmd(),
 		project.NewPatchKratosConfigCmd(),
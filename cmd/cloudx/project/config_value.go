@@ -0,0 +1,201 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+	cloud "github.com/ory/client-go"
+	"github.com/ory/x/cmdx"
+)
+
+func NewGetProjectConfigValueCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "project-config-value <json-path> [project-id]",
+		Args:  cobra.RangeArgs(1, 2),
+		Short: "Get a single value of the Ory Network project configuration.",
+		Long: `Get a single value of the Ory Network project configuration.
+
+The path uses dot notation, for example ` + "`services.identity.config.selfservice.methods.password.enabled`" + `.
+Dots which are part of a key must be escaped with a backslash.`,
+		Example: `$ ory get project-config-value services.identity.config.selfservice.methods.password.enabled ecaaa3cb-0730-4ee8-a6df-9553cdfeef89
+
+true`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			path := args[0]
+			if _, err := configPathToPointer(path); err != nil {
+				return err
+			}
+
+			id, err := getSelectedProjectId(h, args[1:])
+			if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+
+			project, err := h.GetProject(id)
+			if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+
+			value, err := projectConfigValue(project, path)
+			if err != nil {
+				return err
+			} else if !value.Exists() {
+				return errors.Errorf("the project configuration has no value at path %q", path)
+			}
+
+			_, _ = cmd.OutOrStdout().Write([]byte(value.Raw + "\n"))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func NewSetProjectConfigValueCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "project-config-value <json-path> <value> [project-id]",
+		Args:  cobra.RangeArgs(2, 3),
+		Short: "Set a single value of the Ory Network project configuration.",
+		Long: `Set a single value of the Ory Network project configuration.
+
+The path uses dot notation, for example ` + "`services.identity.config.selfservice.methods.password.enabled`" + `.
+Dots which are part of a key must be escaped with a backslash.
+
+The value is parsed as JSON, so ` + "`true`" + `, ` + "`42`" + `, or ` + "`{\"enabled\":true}`" + ` keep their types. Values which are
+not valid JSON are stored as strings.`,
+		Example: `$ ory set project-config-value services.identity.config.selfservice.methods.password.enabled false ecaaa3cb-0730-4ee8-a6df-9553cdfeef89
+
+PATH	services.identity.config.selfservice.methods.password.enabled
+OLD	true
+NEW	false`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			path, raw := args[0], args[1]
+			pointer, err := configPathToPointer(path)
+			if err != nil {
+				return err
+			}
+
+			value := json.RawMessage(raw)
+			if !gjson.Valid(raw) {
+				if value, err = json.Marshal(raw); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+
+			id, err := getSelectedProjectId(h, args[2:])
+			if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+
+			project, err := h.GetProject(id)
+			if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+
+			old, err := projectConfigValue(project, path)
+			if err != nil {
+				return err
+			}
+
+			var add, replace []string
+			if old.Exists() {
+				replace = []string{pointer + "=" + string(value)}
+			} else {
+				add = []string{pointer + "=" + string(value)}
+			}
+
+			p, err := h.PatchProject(project.Id, nil, add, replace, nil)
+			if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+
+			updated, err := projectConfigValue(&p.Project, path)
+			if err != nil {
+				return err
+			}
+
+			cmdx.PrintRow(cmd, &outputConfigValue{Path: path, Old: rawOrNull(old), New: rawOrNull(updated)})
+			return h.PrintUpdateProjectWarnings(p)
+		},
+	}
+
+	cmdx.RegisterFormatFlags(cmd.Flags())
+	return cmd
+}
+
+func projectConfigValue(project *cloud.Project, path string) (*gjson.Result, error) {
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(project); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Make sure the path can also be written to, otherwise it is not a plain key path.
+	if _, err := sjson.SetBytes(b.Bytes(), path, nil); err != nil {
+		return nil, errors.Errorf("invalid configuration path %q: %s", path, err)
+	}
+
+	result := gjson.GetBytes(b.Bytes(), path)
+	return &result, nil
+}
+
+// configPathToPointer converts a dot-separated configuration path to a JSON pointer usable in JSON patches.
+func configPathToPointer(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("the configuration path must not be empty")
+	} else if strings.ContainsAny(path, "*?#|@") {
+		return "", errors.Errorf("invalid configuration path %q: wildcards, queries, and modifiers are not supported", path)
+	}
+
+	var keys []string
+	var key strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; {
+		case c == '\\' && i+1 < len(path):
+			i++
+			key.WriteByte(path[i])
+		case c == '.':
+			keys = append(keys, key.String())
+			key.Reset()
+		default:
+			key.WriteByte(c)
+		}
+	}
+	keys = append(keys, key.String())
+
+	var pointer strings.Builder
+	for _, k := range keys {
+		if k == "" {
+			return "", errors.Errorf("invalid configuration path %q: keys must not be empty", path)
+		}
+		pointer.WriteString("/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(k))
+	}
+
+	return pointer.String(), nil
+}
+
+func rawOrNull(r *gjson.Result) json.RawMessage {
+	if !r.Exists() {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(r.Raw)
+}
@@ -0,0 +1,47 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package project_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestProjectConfigValue(t *testing.T) {
+	const path = "services.identity.config.selfservice.flows.error.ui_url"
+
+	t.Run("is able to set and get a value", func(t *testing.T) {
+		runWithProject(t, func(t *testing.T, exec execFunc, _ string) {
+			stdout, _, err := exec(nil, "set", "project-config-value", path, "https://example.com/config-value-error", "--format", "json")
+			require.NoError(t, err)
+			assert.Equal(t, path, gjson.Get(stdout, "path").String())
+			assert.Equal(t, "https://example.com/config-value-error", gjson.Get(stdout, "new").String())
+
+			stdout, _, err = exec(nil, "get", "project-config-value", path)
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com/config-value-error", gjson.Parse(stdout).String())
+		}, WithDefaultProject, WithPositionalProject)
+	})
+
+	t.Run("keeps the type of JSON values", func(t *testing.T) {
+		runWithProject(t, func(t *testing.T, exec execFunc, _ string) {
+			stdout, _, err := exec(nil, "set", "project-config-value", "services.identity.config.selfservice.methods.password.enabled", "false", "--format", "json")
+			require.NoError(t, err)
+			assert.Equal(t, gjson.False, gjson.Get(stdout, "new").Type)
+		}, WithDefaultProject)
+	})
+
+	t.Run("fails on invalid paths before writing", func(t *testing.T) {
+		runWithProject(t, func(t *testing.T, exec execFunc, _ string) {
+			_, _, err := exec(nil, "set", "project-config-value", "services.*.config", "true")
+			require.Error(t, err)
+
+			_, _, err = exec(nil, "get", "project-config-value", "services..identity")
+			require.Error(t, err)
+		}, WithDefaultProject)
+	})
+}
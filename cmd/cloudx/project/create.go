@@ -5,6 +5,8 @@ package project
 
 import (
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/ory/cli/cmd/cloudx/client"
 
@@ -21,6 +23,18 @@ func NewCreateProjectCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "project",
 		Short: "Create a new Ory Network project",
+		Long: `Create a new Ory Network project.
+
+The name is taken from the --name flag, or entered interactively when the flag is omitted.
+Pass --use-project to make the new project the default for all other commands.`,
+		Example: `$ ory create project --name "Example Project" --use-project
+
+ID		ecaaa3cb-0730-4ee8-a6df-9553cdfeef89
+SLUG	good-wright-t7kzy3vugf
+STATE	running
+NAME	Example Project
+
+$ ory create project --name "Example Project" --format json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			h, err := client.NewCommandHelper(cmd)
 			if err != nil {
@@ -33,10 +47,12 @@ func NewCreateProjectCmd() *cobra.Command {
 			}
 
 			stdin := h.Stdin
-			for name == "" {
+			for strings.TrimSpace(name) == "" {
 				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Enter a name for your project: ")
 				name, err = stdin.ReadString('\n')
-				if err != nil {
+				if errors.Is(err, io.EOF) && strings.TrimSpace(name) == "" {
+					return errors.New("no project name was entered, please specify one with the --name flag")
+				} else if err != nil && !errors.Is(err, io.EOF) {
 					return errors.Wrap(err, "failed to read from stdin")
 				}
 			}
@@ -44,7 +60,7 @@ func NewCreateProjectCmd() *cobra.Command {
 			use := flagx.MustGetBool(cmd, useProjectFlag)
 			p, err := h.CreateProject(name, use)
 			if err != nil {
-				return cmdx.PrintOpenAPIError(cmd, err)
+				return client.PrintOpenAPIError(cmd, err)
 			}
 
 			_, _ = fmt.Fprintln(h.VerboseErrWriter, "Project created successfully!")
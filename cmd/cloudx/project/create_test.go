@@ -58,6 +58,19 @@ func TestCreateProject(t *testing.T) {
 		assertResult(t, defaultConfig, stdout, name)
 	})
 
+	t.Run("is able to create a project and skip blank names from stdin", func(t *testing.T) {
+		name := testhelpers.TestProjectName()
+		stdin := bytes.NewBufferString("\n  \n" + name)
+		stdout, _, err := defaultCmd.Exec(stdin, "create", "project", "--format", "json")
+		require.NoError(t, err)
+		assertResult(t, defaultConfig, stdout, name)
+	})
+
+	t.Run("is not able to create a project without a name", func(t *testing.T) {
+		_, _, err := defaultCmd.Exec(bytes.NewBufferString("\n"), "create", "project", "--format", "json")
+		require.ErrorContains(t, err, "please specify one with the --name flag")
+	})
+
 	t.Run("is not able to create a project if no name flag and quiet flag", func(t *testing.T) {
 		name := testhelpers.TestProjectName()
 		stdin := bytes.NewBufferString(name)
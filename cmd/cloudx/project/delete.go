@@ -0,0 +1,67 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package project
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+	"github.com/ory/x/cmdx"
+)
+
+func NewDeleteProjectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "project <id>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Delete an Ory Network project.",
+		Long: `Delete an Ory Network project and all of its data, such as identities and OAuth2 clients.
+
+The project is identified by its ID or full slug, partial slugs are not accepted. If the project is the default
+project, no project is selected afterwards. You are asked to confirm the deletion unless the --yes flag is set.
+This cannot be undone.`,
+		Example: `$ ory delete project ecaaa3cb-0730-4ee8-a6df-9553cdfeef89
+
+Do you really want to delete the project "Example Project" (ecaaa3cb-0730-4ee8-a6df-9553cdfeef89)? This cannot be undone. [y/n]: y
+Project "Example Project" deleted successfully!
+
+$ ory delete project good-wright-t7kzy3vugf --yes`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			id, err := h.ResolveExactProjectID(args[0])
+			if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+			project, err := h.GetProject(id)
+			if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+
+			if !h.NoConfirm {
+				ok, err := cmdx.AskScannerForConfirmation(fmt.Sprintf("Do you really want to delete the project %q (%s)? This cannot be undone.", project.Name, project.Id), h.Stdin, h.VerboseErrWriter)
+				if err != nil {
+					return err
+				} else if !ok {
+					return errors.New("the project was not deleted")
+				}
+			}
+
+			if err := h.DeleteProject(project.Id); err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+
+			_, _ = fmt.Fprintf(h.VerboseErrWriter, "Project %q deleted successfully!\n", project.Name)
+			cmdx.PrintRow(cmd, (*outputProject)(project))
+			return nil
+		},
+	}
+
+	return cmd
+}
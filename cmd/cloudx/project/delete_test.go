@@ -0,0 +1,70 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package project_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gofrs/uuid/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+	"github.com/ory/cli/cmd/cloudx/testhelpers"
+)
+
+func TestDeleteProject(t *testing.T) {
+	t.Run("is able to delete a project", func(t *testing.T) {
+		id := testhelpers.CreateProject(t, defaultConfig)
+
+		stdout, stderr, err := defaultCmd.Exec(nil, "delete", "project", id, "--yes", "--format", "json")
+		require.NoError(t, err)
+		assert.Equal(t, id, gjson.Get(stdout, "id").String())
+		assert.Contains(t, stderr, "deleted successfully")
+
+		_, _, err = defaultCmd.Exec(nil, "get", "project", id, "--format", "json")
+		require.ErrorIs(t, err, client.ErrProjectNotFound)
+	})
+
+	t.Run("requires a project", func(t *testing.T) {
+		_, _, err := defaultCmd.Exec(nil, "delete", "project", "--yes")
+		require.ErrorContains(t, err, "accepts 1 arg(s)")
+	})
+
+	t.Run("does not delete a project by a partial slug", func(t *testing.T) {
+		id := testhelpers.CreateProject(t, defaultConfig)
+		stdout, _, err := defaultCmd.Exec(nil, "get", "project", id, "--format", "json")
+		require.NoError(t, err)
+		slug := gjson.Get(stdout, "slug").String()
+
+		_, _, err = defaultCmd.Exec(nil, "delete", "project", slug[:len(slug)-1], "--yes")
+		require.ErrorContains(t, err, "no project found with slug")
+
+		_, _, err = defaultCmd.Exec(nil, "get", "project", id, "--format", "json")
+		require.NoError(t, err)
+	})
+
+	t.Run("clears the default project", func(t *testing.T) {
+		previous := testhelpers.GetDefaultProject(t, defaultConfig)
+		t.Cleanup(func() { testhelpers.SetDefaultProject(t, defaultConfig, previous) })
+		id := testhelpers.CreateAndUseProject(t, defaultConfig)
+
+		_, _, err := defaultCmd.Exec(nil, "delete", "project", id, "--yes")
+		require.NoError(t, err)
+		assert.Equal(t, uuid.Nil, testhelpers.ReadConfig(t, defaultConfig).SelectedProject)
+	})
+
+	t.Run("does not delete the project if not confirmed", func(t *testing.T) {
+		id := testhelpers.CreateProject(t, defaultConfig)
+
+		_, _, err := defaultCmd.Exec(bytes.NewBufferString("n\n"), "delete", "project", id)
+		require.ErrorContains(t, err, "the project was not deleted")
+
+		stdout, _, err := defaultCmd.Exec(nil, "get", "project", id, "--format", "json")
+		require.NoError(t, err)
+		assert.Equal(t, id, gjson.Get(stdout, "id").String())
+	})
+}
@@ -0,0 +1,212 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+	cloud "github.com/ory/client-go"
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+)
+
+const (
+	changeAdded   = "added"
+	changeRemoved = "removed"
+	changeChanged = "changed"
+)
+
+func NewDiffProjectsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "projects <project-a> <project-b>",
+		Args:  cobra.ExactArgs(2),
+		Short: "Show the differences between the configurations of two Ory Network projects.",
+		Long: `Show the differences between the configurations of two Ory Network projects.
+
+Every added, removed, or changed key is printed with its JSON pointer, which can be passed to
+"ory patch project --replace". Changes are printed with colors when writing to a terminal and
+as JSON otherwise. Use --no-color or set NO_COLOR to print them without colors.`,
+		Example: `$ ory diff projects ecaaa3cb-0730-4ee8-a6df-9553cdfeef89 good-wright-t7kzy3vugf
+
+~ /services/identity/config/selfservice/methods/code/enabled: false -> true
++ /services/identity/config/session/lifespan: "72h0m0s"
+
+$ ory diff projects ecaaa3cb-0730-4ee8-a6df-9553cdfeef89 good-wright-t7kzy3vugf --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			configs := make([]interface{}, len(args))
+			for k, id := range args {
+				project, err := h.GetProject(id)
+				if err != nil {
+					return client.PrintOpenAPIError(cmd, errors.WithMessagef(err, "unable to get project %q", id))
+				}
+
+				configs[k], err = projectConfig(project)
+				if err != nil {
+					return client.PrintOpenAPIError(cmd, err)
+				}
+			}
+
+			return printConfigChanges(cmd, diffConfig("/services", configs[0], configs[1]))
+		},
+	}
+
+	cmdx.RegisterJSONFormatFlags(cmd.Flags())
+	return cmd
+}
+
+type configChange struct {
+	Path string      `json:"path"`
+	Kind string      `json:"kind"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+type outputConfigChanges []configChange
+
+func (c outputConfigChanges) String() string {
+	var b strings.Builder
+	printChanges(&b, c, false)
+	return b.String()
+}
+
+// printConfigChanges prints the changes as text to terminals, with colors unless disabled, and as JSON otherwise. A
+// format passed with --format or saved with `ory use format` is always used.
+func printConfigChanges(cmd *cobra.Command, configChanges []configChange) error {
+	changes := outputConfigChanges(configChanges)
+	if changes == nil {
+		changes = outputConfigChanges{}
+	}
+
+	if format := flagx.MustGetString(cmd, cmdx.FlagFormat); format == string(cmdx.FormatDefault) || format == string(cmdx.FormatTable) {
+		if isTerminal(cmd.OutOrStdout()) {
+			printChanges(cmd.OutOrStdout(), changes, client.ColorEnabled(cmd))
+			return nil
+		}
+		// Scripts and pipes get JSON unless asked otherwise.
+		if err := cmd.Flags().Set(cmdx.FlagFormat, string(cmdx.FormatJSONPretty)); err != nil {
+			return err
+		}
+	}
+
+	cmdx.PrintJSONAble(cmd, changes)
+	return nil
+}
+
+// projectConfig returns the service configurations of the project as generic JSON values.
+func projectConfig(project *cloud.Project) (interface{}, error) {
+	raw, err := json.Marshal(project.Services)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var config interface{}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return config, nil
+}
+
+// diffConfig returns the changes from a to b, sorted by path. Objects are compared key by key, all other values,
+// including arrays, are compared as a whole.
+func diffConfig(path string, a, b interface{}) []configChange {
+	am, aIsObject := a.(map[string]interface{})
+	bm, bIsObject := b.(map[string]interface{})
+	if !aIsObject || !bIsObject {
+		if reflect.DeepEqual(a, b) {
+			return nil
+		}
+		return []configChange{{Path: path, Kind: changeChanged, From: a, To: b}}
+	}
+
+	keys := make([]string, 0, len(am)+len(bm))
+	for k := range am {
+		keys = append(keys, k)
+	}
+	for k := range bm {
+		if _, ok := am[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []configChange
+	for _, k := range keys {
+		p := path + "/" + escapePointer(k)
+		av, inA := am[k]
+		bv, inB := bm[k]
+		switch {
+		case !inA:
+			changes = append(changes, configChange{Path: p, Kind: changeAdded, To: bv})
+		case !inB:
+			changes = append(changes, configChange{Path: p, Kind: changeRemoved, From: av})
+		default:
+			changes = append(changes, diffConfig(p, av, bv)...)
+		}
+	}
+	return changes
+}
+
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+const (
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorReset  = "\033[0m"
+)
+
+func printChanges(w io.Writer, changes []configChange, colored bool) {
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintln(w, "The project configurations are identical.")
+		return
+	}
+
+	for _, c := range changes {
+		var line, color string
+		switch c.Kind {
+		case changeAdded:
+			line, color = fmt.Sprintf("+ %s: %s", c.Path, marshalValue(c.To)), colorGreen
+		case changeRemoved:
+			line, color = fmt.Sprintf("- %s: %s", c.Path, marshalValue(c.From)), colorRed
+		default:
+			line, color = fmt.Sprintf("~ %s: %s -> %s", c.Path, marshalValue(c.From), marshalValue(c.To)), colorYellow
+		}
+
+		if colored {
+			line = color + line + colorReset
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+}
+
+func marshalValue(v interface{}) string {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(out)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,64 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package project
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+	"github.com/ory/x/cmdx"
+)
+
+func TestPrintConfigChanges(t *testing.T) {
+	changes := []configChange{{Path: "/services/identity/config/session/lifespan", Kind: changeAdded, To: "72h0m0s"}}
+
+	for _, tc := range []struct {
+		name, config, expected string
+		args                   []string
+	}{
+		{
+			name:     "json without a format",
+			config:   `{}`,
+			expected: "[\n  {\n    \"path\": \"/services/identity/config/session/lifespan\",\n    \"kind\": \"added\",\n    \"to\": \"72h0m0s\"\n  }\n]\n",
+		},
+		{
+			name:     "saved format",
+			config:   `{"format":"yaml"}`,
+			expected: "- path: /services/identity/config/session/lifespan\n  kind: added\n  to: 72h0m0s\n",
+		},
+		{
+			name:     "explicit format wins",
+			config:   `{"format":"yaml"}`,
+			args:     []string{"--" + cmdx.FlagFormat, "json"},
+			expected: `[{"path":"/services/identity/config/session/lifespan","kind":"added","to":"72h0m0s"}]` + "\n",
+		},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(t, os.WriteFile(path, []byte(tc.config), 0600))
+
+			cmd := NewDiffProjectsCmd()
+			cmd.SetContext(context.Background())
+			client.RegisterConfigFlag(cmd.Flags())
+			client.RegisterYesFlag(cmd.Flags())
+			cmdx.RegisterNoiseFlags(cmd.Flags())
+			require.NoError(t, cmd.ParseFlags(append([]string{"--" + client.ConfigFlag, path}, tc.args...)))
+
+			var stdout bytes.Buffer
+			cmd.SetOut(&stdout)
+			_, err := client.NewCommandHelper(cmd)
+			require.NoError(t, err)
+
+			require.NoError(t, printConfigChanges(cmd, changes))
+			assert.Equal(t, tc.expected, stdout.String())
+		})
+	}
+}
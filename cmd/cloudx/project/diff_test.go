@@ -0,0 +1,37 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package project_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestDiffProjects(t *testing.T) {
+	t.Run("case=identical projects have no changes", func(t *testing.T) {
+		stdout, _, err := defaultCmd.Exec(nil, "diff", "projects", defaultProject, defaultProject, "--format", "json")
+		require.NoError(t, err)
+		assert.JSONEq(t, "[]", stdout)
+	})
+
+	t.Run("case=changed keys are listed by path", func(t *testing.T) {
+		_, _, err := defaultCmd.Exec(nil, "patch", "identity-config", extraProject, "--replace", `/courier/smtp/from_name="diff test"`)
+		require.NoError(t, err)
+
+		stdout, _, err := defaultCmd.Exec(nil, "diff", "projects", defaultProject, extraProject, "--format", "json")
+		require.NoError(t, err)
+		change := gjson.Get(stdout, `#(path=="/services/identity/config/courier/smtp/from_name")`)
+		require.True(t, change.Exists(), stdout)
+		assert.Equal(t, "diff test", change.Get("to").String())
+	})
+
+	t.Run("case=unknown project", func(t *testing.T) {
+		_, stderr, err := defaultCmd.Exec(nil, "diff", "projects", defaultProject, "00000000-0000-0000-0000-000000000000")
+		require.Error(t, err)
+		assert.Contains(t, stderr, "00000000-0000-0000-0000-000000000000")
+	})
+}
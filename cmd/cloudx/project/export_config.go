@@ -0,0 +1,131 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package project
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+	cloud "github.com/ory/client-go"
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+)
+
+const (
+	serviceFlag = "service"
+	outFlag     = "out"
+)
+
+// projectServices maps the service names and their aliases to the key of the service in the project's services.
+var projectServices = map[string]string{
+	"identity":   "identity",
+	"kratos":     "identity",
+	"oauth2":     "oauth2",
+	"hydra":      "oauth2",
+	"permission": "permission",
+	"keto":       "permission",
+}
+
+// serviceFromFlag returns the key of the service selected with --service.
+func serviceFromFlag(cmd *cobra.Command) (string, error) {
+	service := flagx.MustGetString(cmd, serviceFlag)
+	key, ok := projectServices[service]
+	if !ok {
+		return "", errors.Errorf("--%s must be one of %s but got: %s", serviceFlag, projectServiceNames(), service)
+	}
+	return key, nil
+}
+
+// serviceConfig returns the configuration of the service, as expected by the respective update command.
+func serviceConfig(services *cloud.ProjectServices, key string) map[string]interface{} {
+	switch key {
+	case "oauth2":
+		return services.GetOauth2().Config
+	case "permission":
+		return services.GetPermission().Config
+	default:
+		return services.GetIdentity().Config
+	}
+}
+
+func projectServiceNames() string {
+	names := make([]string, 0, len(projectServices))
+	for name := range projectServices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func NewExportConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "project-config [project-id]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Export the configuration of an Ory Network project service.",
+		Long: `Export the configuration of one service of an Ory Network project to stdout or a file.
+
+The output is the configuration the respective update command expects, so it can be imported again:
+
+	$ ory export project-config --service identity --format yaml --out identity-config.yaml
+	$ ory update identity-config --file identity-config.yaml
+
+The configuration is exported as pretty-printed JSON unless you set --format.`,
+		Example: `$ ory export project-config ecaaa3cb-0730-4ee8-a6df-9553cdfeef89 --service oauth2 --format yaml
+
+$ ory export project-config --service keto --out permission-config.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			service, err := serviceFromFlag(cmd)
+			if err != nil {
+				return err
+			}
+
+			id, err := getSelectedProjectId(h, args)
+			if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+			project, err := h.GetProject(id)
+			if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+
+			config := serviceConfig(&project.Services, service)
+			if len(config) == 0 {
+				return errors.Errorf("the %s configuration of project %s is not set, there is nothing to export", service, project.Id)
+			}
+
+			if !cmd.Flags().Changed(cmdx.FlagFormat) {
+				if err := cmd.Flags().Set(cmdx.FlagFormat, string(cmdx.FormatJSONPretty)); err != nil {
+					return err
+				}
+			}
+
+			if out := flagx.MustGetString(cmd, outFlag); len(out) > 0 {
+				f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+				if err != nil {
+					return errors.Wrapf(err, "unable to open --%s", outFlag)
+				}
+				defer f.Close()
+				cmd.SetOut(f)
+			}
+
+			cmdx.PrintJSONAble(cmd, outputConfig(config))
+			return nil
+		},
+	}
+
+	cmd.Flags().String(serviceFlag, "identity", "The service to export the configuration of, one of "+projectServiceNames()+".")
+	cmd.Flags().StringP(outFlag, "o", "", "Write the configuration to this file instead of stdout.")
+	cmdx.RegisterJSONFormatFlags(cmd.Flags())
+	return cmd
+}
@@ -0,0 +1,42 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package project_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v2"
+)
+
+func TestExportConfig(t *testing.T) {
+	runWithProject(t, func(t *testing.T, exec execFunc, _ string) {
+		t.Run("is able to export the identity config", func(t *testing.T) {
+			stdout, _, err := exec(nil, "export", "project-config")
+			require.NoError(t, err)
+			assert.True(t, gjson.Get(stdout, "selfservice.flows.error.ui_url").Exists(), stdout)
+		})
+
+		t.Run("is able to export the oauth2 config as YAML to a file", func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "oauth2-config.yaml")
+			_, _, err := exec(nil, "export", "project-config", "--service", "hydra", "--format", "yaml", "--out", out)
+			require.NoError(t, err)
+
+			raw, err := os.ReadFile(out)
+			require.NoError(t, err)
+			var config map[string]interface{}
+			require.NoError(t, yaml.Unmarshal(raw, &config))
+			assert.Contains(t, config, "oauth2")
+		})
+
+		t.Run("is not able to export an unknown service", func(t *testing.T) {
+			_, _, err := exec(nil, "export", "project-config", "--service", "unknown")
+			require.ErrorContains(t, err, "--service must be one of")
+		})
+	}, WithDefaultProject, WithPositionalProject)
+}
@@ -47,11 +47,11 @@ $ ory get project ecaaa3cb-0730-4ee8-a6df-9553cdfeef89 --format json
 
 			id, err := getSelectedProjectId(h, args)
 			if err != nil {
-				return cmdx.PrintOpenAPIError(cmd, err)
+				return client.PrintOpenAPIError(cmd, err)
 			}
 			project, err := h.GetProject(id)
 			if err != nil {
-				return cmdx.PrintOpenAPIError(cmd, err)
+				return client.PrintOpenAPIError(cmd, err)
 			}
 
 			cmdx.PrintRow(cmd, (*outputProject)(project))
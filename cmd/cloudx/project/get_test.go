@@ -9,6 +9,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson"
+
+	"github.com/ory/cli/cmd/cloudx/client"
 )
 
 func TestGetProject(t *testing.T) {
@@ -20,6 +22,11 @@ func TestGetProject(t *testing.T) {
 	}, WithDefaultProject, WithPositionalProject)
 }
 
+func TestGetUnknownProject(t *testing.T) {
+	_, _, err := defaultCmd.Exec(nil, "get", "project", "ecaaa3cb-0730-4ee8-a6df-9553cdfeef89", "--format", "json")
+	require.ErrorIs(t, err, client.ErrProjectNotFound)
+}
+
 func TestGetServiceConfig(t *testing.T) {
 	t.Run("service=kratos", func(t *testing.T) {
 		runWithProject(t, func(t *testing.T, exec execFunc, _ string) {
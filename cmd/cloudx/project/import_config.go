@@ -0,0 +1,216 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+	cloud "github.com/ory/client-go"
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+)
+
+const (
+	fileFlag   = "file"
+	patchFlag  = "patch"
+	dryRunFlag = "dry-run"
+)
+
+var jsonPatchOps = map[string]bool{"add": true, "remove": true, "replace": true, "move": true, "copy": true, "test": true}
+
+func NewImportConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "project-config [project-id]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Import the configuration of an Ory Network project service.",
+		Long: `Import the configuration of one service of an Ory Network project from a YAML or JSON file,
+for example one written by ` + "`ory export project-config`" + `.
+
+By default, the file replaces the complete configuration of the service. With --patch, the file
+is a JSON Patch (RFC 6902) whose paths are relative to the service configuration, and only the
+patched keys change. With --dry-run, the changes are printed but not applied.`,
+		Example: `$ ory import project-config --service identity --file identity-config.yaml
+
+$ ory import project-config ecaaa3cb-0730-4ee8-a6df-9553cdfeef89 --service identity --patch --dry-run --file patch.json
+
+~ /services/identity/config/selfservice/methods/code/enabled: false -> true`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			service, err := serviceFromFlag(cmd)
+			if err != nil {
+				return err
+			}
+
+			file := flagx.MustGetString(cmd, fileFlag)
+			if len(file) == 0 {
+				return errors.Errorf("please pass the configuration file with --%s", fileFlag)
+			}
+			configs, err := client.ReadConfigFiles([]string{file})
+			if err != nil {
+				return err
+			}
+
+			patch := flagx.MustGetBool(cmd, patchFlag)
+			if patch {
+				err = validateConfigPatch(configs[0])
+			} else {
+				err = validateConfigObject(configs[0])
+			}
+			if err != nil {
+				return errors.WithMessagef(err, "invalid --%s %s", fileFlag, file)
+			}
+
+			id, err := getSelectedProjectId(h, args)
+			if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+			project, err := h.GetProject(id)
+			if err != nil {
+				return client.PrintOpenAPIError(cmd, err)
+			}
+
+			prefix := "/services/" + service + "/config"
+			if flagx.MustGetBool(cmd, dryRunFlag) {
+				current := serviceConfig(&project.Services, service)
+				updated, err := importedConfig(current, configs[0], patch)
+				if err != nil {
+					return errors.WithMessagef(err, "unable to apply --%s %s", fileFlag, file)
+				}
+
+				var before interface{} = map[string]interface{}{}
+				if current != nil {
+					before = current
+				}
+				return printConfigChanges(cmd, diffConfig(prefix, before, updated))
+			}
+
+			var p *cloud.SuccessfulProjectUpdate
+			if patch {
+				patches, err := prefixConfigPatch(prefix, configs[0])
+				if err != nil {
+					return err
+				}
+				p, err = h.PatchProject(project.Id, []json.RawMessage{patches}, nil, nil, nil)
+				if err != nil {
+					return client.PrintOpenAPIError(cmd, err)
+				}
+			} else {
+				configs, err := prefixFileConfig("services."+service+".config", configs)
+				if err != nil {
+					return err
+				}
+				p, err = h.UpdateProject(project.Id, "", configs)
+				if err != nil {
+					return client.PrintOpenAPIError(cmd, err)
+				}
+			}
+
+			cmdx.PrintJSONAble(cmd, outputConfig(serviceConfig(&p.Project.Services, service)))
+			return h.PrintUpdateProjectWarnings(p)
+		},
+	}
+
+	cmd.Flags().String(serviceFlag, "identity", "The service to import the configuration of, one of "+projectServiceNames()+".")
+	cmd.Flags().StringP(fileFlag, "f", "", "The configuration file (file://config.json, https://example.org/config.yaml, ...) to import.")
+	cmd.Flags().Bool(patchFlag, false, "The file is a JSON Patch to apply to the service configuration.")
+	cmd.Flags().Bool(dryRunFlag, false, "Print the changes without applying them.")
+	cmdx.RegisterJSONFormatFlags(cmd.Flags())
+	return cmd
+}
+
+// validateConfigObject checks that the service configuration is a non-empty JSON object.
+func validateConfigObject(raw json.RawMessage) error {
+	var config map[string]interface{}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return errors.New("the configuration must be an object")
+	}
+	if len(config) == 0 {
+		return errors.New("the configuration must not be empty")
+	}
+	return nil
+}
+
+// validateConfigPatch checks that the JSON Patch only uses known operations on JSON Pointers.
+func validateConfigPatch(raw json.RawMessage) error {
+	var patches []cloud.JsonPatch
+	if err := json.Unmarshal(raw, &patches); err != nil {
+		return errors.New("the patch must be a list of JSON Patch operations")
+	}
+	if len(patches) == 0 {
+		return errors.New("the patch must not be empty")
+	}
+
+	for k, p := range patches {
+		if !jsonPatchOps[p.Op] {
+			return errors.Errorf("operation %d has the unknown op %q", k, p.Op)
+		}
+		if len(p.Path) == 0 || p.Path[0] != '/' {
+			return errors.Errorf("operation %d must have a path starting with a slash but got: %q", k, p.Path)
+		}
+	}
+	return nil
+}
+
+// prefixConfigPatch prepends the prefix to the paths of the JSON Patch, so that it applies to the project.
+func prefixConfigPatch(prefix string, raw json.RawMessage) (json.RawMessage, error) {
+	var patches []cloud.JsonPatch
+	if err := json.Unmarshal(raw, &patches); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for k := range patches {
+		patches[k].Path = prefix + patches[k].Path
+		if patches[k].From != nil {
+			from := prefix + *patches[k].From
+			patches[k].From = &from
+		}
+	}
+
+	out, err := json.Marshal(patches)
+	return out, errors.WithStack(err)
+}
+
+// importedConfig returns the service configuration after the import, without sending it to the API.
+func importedConfig(current map[string]interface{}, raw json.RawMessage, patch bool) (interface{}, error) {
+	if !patch {
+		var config interface{}
+		if err := json.Unmarshal(raw, &config); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return config, nil
+	}
+
+	p, err := jsonpatch.DecodePatch(raw)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if current == nil {
+		current = map[string]interface{}{}
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	patched, err := p.Apply(doc)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var config interface{}
+	if err := json.NewDecoder(bytes.NewReader(patched)).Decode(&config); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return config, nil
+}
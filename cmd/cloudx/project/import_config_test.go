@@ -0,0 +1,65 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package project_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestImportConfig(t *testing.T) {
+	const key = "selfservice.flows.error.ui_url"
+	writeFile := func(t *testing.T, name, contents string) string {
+		path := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+		return path
+	}
+
+	runWithProject(t, func(t *testing.T, exec execFunc, _ string) {
+		t.Run("is able to preview a patch", func(t *testing.T) {
+			before, _, err := exec(nil, "get", "identity-config", "--format", "json")
+			require.NoError(t, err)
+
+			patch := writeFile(t, "patch.json", `[{"op":"replace","path":"/selfservice/flows/error/ui_url","value":"https://example.com/import-dry-run"}]`)
+			stdout, _, err := exec(nil, "import", "project-config", "--patch", "--dry-run", "--file", patch)
+			require.NoError(t, err)
+			assert.Equal(t, "/services/identity/config/selfservice/flows/error/ui_url", gjson.Get(stdout, "0.path").String(), stdout)
+			assert.Equal(t, "https://example.com/import-dry-run", gjson.Get(stdout, "0.to").String(), stdout)
+
+			after, _, err := exec(nil, "get", "identity-config", "--format", "json")
+			require.NoError(t, err)
+			assert.Equal(t, gjson.Get(before, key).String(), gjson.Get(after, key).String())
+		})
+
+		t.Run("is able to apply a patch", func(t *testing.T) {
+			patch := writeFile(t, "patch.yaml", "- op: replace\n  path: /selfservice/flows/error/ui_url\n  value: https://example.com/import-patch\n")
+			stdout, _, err := exec(nil, "import", "project-config", "--patch", "--file", patch, "--format", "json")
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com/import-patch", gjson.Get(stdout, key).String(), stdout)
+		})
+
+		t.Run("is able to import an exported config", func(t *testing.T) {
+			exported := filepath.Join(t.TempDir(), "identity-config.json")
+			_, _, err := exec(nil, "export", "project-config", "--out", exported)
+			require.NoError(t, err)
+
+			stdout, _, err := exec(nil, "import", "project-config", "--file", exported, "--format", "json")
+			require.NoError(t, err)
+			assert.True(t, gjson.Get(stdout, key).Exists(), stdout)
+		})
+
+		t.Run("is not able to import an invalid file", func(t *testing.T) {
+			_, _, err := exec(nil, "import", "project-config", "--file", writeFile(t, "config.json", `[]`))
+			require.ErrorContains(t, err, "the configuration must be an object")
+
+			_, _, err = exec(nil, "import", "project-config", "--patch", "--file", writeFile(t, "patch.json", `[{"op":"merge","path":"/a"}]`))
+			require.ErrorContains(t, err, `unknown op "merge"`)
+		})
+	}, WithDefaultProject, WithPositionalProject)
+}
@@ -4,31 +4,125 @@
 package project
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/ory/cli/cmd/cloudx/client"
+	cloud "github.com/ory/client-go"
 	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+)
+
+const (
+	filterFlag = "filter"
+	sortByFlag = "sort-by"
+	orderFlag  = "order"
+
+	sortByName    = "name"
+	sortByCreated = "created"
+	orderAsc      = "asc"
+	orderDesc     = "desc"
 )
 
 func NewListProjectsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "projects",
 		Short: "List your Ory Network projects.",
+		Example: `$ ory list projects --filter staging --sort-by created --order desc
+
+$ ory list projects --page-size 10
+
+$ ory list projects --format yaml
+
+$ ory list projects --filter staging --quiet | xargs -n1 ory get project`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			h, err := client.NewCommandHelper(cmd)
 			if err != nil {
 				return err
 			}
 
+			pageToken, pageSize, err := cmdx.ParseTokenPaginationArgs(cmd)
+			if err != nil {
+				return err
+			}
+
 			projects, err := h.ListProjects()
 			if err != nil {
-				return cmdx.PrintOpenAPIError(cmd, err)
+				return client.PrintOpenAPIError(cmd, err)
+			}
+
+			projects = filterProjects(projects, flagx.MustGetString(cmd, filterFlag))
+			if err := sortProjects(projects, flagx.MustGetString(cmd, sortByFlag), flagx.MustGetString(cmd, orderFlag)); err != nil {
+				return err
+			}
+
+			projects, nextPageToken, err := client.PaginateProjects(projects, pageSize, pageToken)
+			if err != nil {
+				return err
 			}
 
 			cmdx.PrintTable(cmd, &outputProjectCollection{projects})
+			if len(nextPageToken) > 0 && !flagx.MustGetBool(cmd, cmdx.FlagQuiet) {
+				// The footer goes to stderr so that the output stays parseable.
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "\nThere are more projects. To list them, add --%s %s\n", cmdx.FlagPageToken, nextPageToken)
+			}
 			return nil
 		},
 	}
 
+	cmdx.RegisterTokenPaginationFlags(cmd)
+	cmd.Flags().String(filterFlag, "", "Only list projects whose name or slug contains this text, ignoring case.")
+	cmd.Flags().String(sortByFlag, "", "Sort the projects by "+sortByName+" or "+sortByCreated+". Defaults to the order returned by the API.")
+	cmd.Flags().String(orderFlag, orderAsc, "The sort order, "+orderAsc+" or "+orderDesc+".")
 	return cmd
 }
+
+// filterProjects returns the projects whose name or slug contains filter, ignoring case.
+func filterProjects(projects []cloud.ProjectMetadata, filter string) []cloud.ProjectMetadata {
+	if len(filter) == 0 {
+		return projects
+	}
+
+	filter = strings.ToLower(filter)
+	filtered := make([]cloud.ProjectMetadata, 0, len(projects))
+	for _, p := range projects {
+		if strings.Contains(strings.ToLower(p.Name), filter) || strings.Contains(strings.ToLower(p.GetSlug()), filter) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// sortProjects sorts the projects in place. Projects that compare equal keep their order.
+func sortProjects(projects []cloud.ProjectMetadata, sortBy, order string) error {
+	if order != orderAsc && order != orderDesc {
+		return errors.Errorf("--%s must be %s or %s but got: %s", orderFlag, orderAsc, orderDesc, order)
+	}
+
+	var less func(a, b *cloud.ProjectMetadata) bool
+	switch sortBy {
+	case "":
+		if order == orderDesc {
+			return errors.Errorf("--%s requires --%s", orderFlag, sortByFlag)
+		}
+		return nil
+	case sortByName:
+		less = func(a, b *cloud.ProjectMetadata) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case sortByCreated:
+		less = func(a, b *cloud.ProjectMetadata) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	default:
+		return errors.Errorf("--%s must be %s or %s but got: %s", sortByFlag, sortByName, sortByCreated, sortBy)
+	}
+
+	sort.SliceStable(projects, func(i, j int) bool {
+		if order == orderDesc {
+			return less(&projects[j], &projects[i])
+		}
+		return less(&projects[i], &projects[j])
+	})
+	return nil
+}
@@ -5,6 +5,7 @@ package project_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/ory/cli/cmd/cloudx/client"
@@ -13,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v2"
 )
 
 func TestListProject(t *testing.T) {
@@ -43,6 +45,57 @@ func TestListProject(t *testing.T) {
 		})
 	}
 
+	t.Run("is able to list projects as YAML", func(t *testing.T) {
+		stdout, _, err := cmd.Exec(nil, "list", "projects", "--format", "yaml")
+		require.NoError(t, err)
+		var out []map[string]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(stdout), &out))
+		require.Len(t, out, len(projects))
+		for _, project := range out {
+			assert.Contains(t, projects, project["id"])
+			assert.Contains(t, project, "created_at")
+		}
+	})
+
+	t.Run("is able to list only project IDs", func(t *testing.T) {
+		stdout, stderr, err := cmd.Exec(nil, "list", "projects", "--quiet", "--page-size", "2")
+		require.NoError(t, err)
+		ids := strings.Split(strings.TrimSpace(stdout), "\n")
+		require.Len(t, ids, 2)
+		for _, id := range ids {
+			assert.Contains(t, projects, id)
+		}
+		assert.Empty(t, stderr)
+
+		stdout, _, err = cmd.Exec(nil, "list", "projects", "-q", "--filter", "does-not-match-any-project")
+		require.NoError(t, err)
+		assert.Empty(t, strings.TrimSpace(stdout))
+	})
+
+	t.Run("is able to page through projects", func(t *testing.T) {
+		stdout, stderr, err := cmd.Exec(nil, "list", "projects", "--format", "json", "--page-size", "2")
+		require.NoError(t, err)
+		assert.Len(t, gjson.Parse(stdout).Array(), 2)
+		assert.Contains(t, stderr, "--page-token")
+	})
+
+	t.Run("is able to filter and sort projects", func(t *testing.T) {
+		stdout, _, err := cmd.Exec(nil, "list", "projects", "--format", "json", "--filter", "does-not-match-any-project")
+		require.NoError(t, err)
+		assert.JSONEq(t, "[]", stdout)
+
+		stdout, _, err = cmd.Exec(nil, "list", "projects", "--format", "json", "--sort-by", "created", "--order", "desc")
+		require.NoError(t, err)
+		created := gjson.Get(stdout, "#.created_at").Array()
+		require.Len(t, created, len(projects))
+		for k := 1; k < len(created); k++ {
+			assert.False(t, created[k].Time().After(created[k-1].Time()))
+		}
+
+		_, _, err = cmd.Exec(nil, "list", "projects", "--sort-by", "color")
+		require.ErrorContains(t, err, "--sort-by must be name or created")
+	})
+
 	t.Run("is not able to list projects if not authenticated and quiet flag", func(t *testing.T) {
 		configDir := testhelpers.NewConfigDir(t)
 		cmd := testhelpers.ConfigAwareCmd(configDir)
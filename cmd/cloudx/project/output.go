@@ -4,6 +4,7 @@
 package project
 
 import (
+	"encoding/json"
 	"fmt"
 
 	cloud "github.com/ory/client-go"
@@ -72,6 +73,14 @@ func (c *outputProjectCollection) Len() int {
 	return len(c.projects)
 }
 
+func (c *outputProjectCollection) IDs() []string {
+	ids := make([]string, len(c.projects))
+	for i := range c.projects {
+		ids[i] = c.projects[i].Id
+	}
+	return ids
+}
+
 type selectedProject struct {
 	ID string `json:"id"`
 }
@@ -97,3 +106,64 @@ func (i *selectedProject) Columns() []string {
 func (i *selectedProject) Interface() interface{} {
 	return i
 }
+
+type outputConfigValue struct {
+	Path string          `json:"path"`
+	Old  json.RawMessage `json:"old"`
+	New  json.RawMessage `json:"new"`
+}
+
+func (*outputConfigValue) Header() []string {
+	return []string{"PATH", "OLD", "NEW"}
+}
+
+func (i *outputConfigValue) Columns() []string {
+	return []string{
+		i.Path,
+		string(i.Old),
+		string(i.New),
+	}
+}
+
+func (i *outputConfigValue) Interface() interface{} {
+	return i
+}
+
+type outputResolvedSlug struct {
+	Slug      string `json:"slug"`
+	URL       string `json:"url"`
+	Checked   bool   `json:"checked"`
+	DNSError  string `json:"dns_error,omitempty"`
+	HTTPError string `json:"http_error,omitempty"`
+}
+
+func (*outputResolvedSlug) Header() []string {
+	return []string{"SLUG", "URL", "DNS", "HTTP"}
+}
+
+func (o *outputResolvedSlug) Columns() []string {
+	status := func(err string) string {
+		if !o.Checked {
+			return "<not checked>"
+		} else if err != "" {
+			return err
+		}
+		return "ok"
+	}
+
+	httpStatus := status(o.HTTPError)
+	if o.DNSError != "" {
+		httpStatus = "<skipped>"
+	}
+
+	return []string{
+		o.Slug,
+		o.URL,
+		status(o.DNSError),
+		httpStatus,
+	}
+}
+
+func (o *outputResolvedSlug) Interface() interface{} {
+	return o
+}
@@ -83,11 +83,11 @@ func runPatch(patchPrefixer func([]string) []string, filePrefixer func([]json.Ra
 
 		id, err := getSelectedProjectId(h, args)
 		if err != nil {
-			return cmdx.PrintOpenAPIError(cmd, err)
+			return client.PrintOpenAPIError(cmd, err)
 		}
 		p, err := h.PatchProject(id, configs, add, replace, remove)
 		if err != nil {
-			return cmdx.PrintOpenAPIError(cmd, err)
+			return client.PrintOpenAPIError(cmd, err)
 		}
 
 		outputter(cmd, p)
@@ -0,0 +1,89 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package project
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+)
+
+const checkFlag = "check"
+
+func NewResolveSlugCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "slug <slug>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Resolve a project slug to its API endpoint.",
+		Long: `Resolve a project slug to the API endpoint used by the Ory Proxy and Ory Tunnel.
+
+Use the ` + "`--check`" + ` flag to also verify that the endpoint resolves in DNS and answers HTTP requests.`,
+		Example: `$ ory resolve slug good-wright-t7kzy3vugf --check
+
+SLUG		good-wright-t7kzy3vugf
+URL		https://good-wright-t7kzy3vugf.projects.oryapis.com/
+DNS		ok
+HTTP		ok`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := &outputResolvedSlug{
+				Slug: args[0],
+				URL:  client.ProjectAPIsURL(args[0]).String(),
+			}
+
+			if flagx.MustGetBool(cmd, checkFlag) {
+				out.check(cmd.Context(), client.ProjectAPIsURL(args[0]), net.DefaultResolver, &http.Client{Timeout: 10 * time.Second})
+			}
+
+			cmdx.PrintRow(cmd, out)
+			if !out.Checked || (out.DNSError == "" && out.HTTPError == "") {
+				return nil
+			} else if client.JSONErrors(cmd) {
+				return client.FailWithDetails(cmd, cmd.ErrOrStderr(), fmt.Sprintf("The endpoint of the project %s is not reachable.", out.Slug), out)
+			}
+			// The failed checks were already printed.
+			return cmdx.FailSilently(cmd)
+		},
+	}
+
+	cmd.Flags().Bool(checkFlag, false, "Check that the endpoint resolves in DNS and answers HTTP requests.")
+	cmdx.RegisterFormatFlags(cmd.Flags())
+	return cmd
+}
+
+// hostResolver is implemented by net.Resolver.
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// check verifies that the host of the URL resolves and that the URL answers HTTP requests.
+func (o *outputResolvedSlug) check(ctx context.Context, u *url.URL, resolver hostResolver, hc *http.Client) {
+	o.Checked = true
+
+	if _, err := resolver.LookupHost(ctx, u.Hostname()); err != nil {
+		o.DNSError = err.Error()
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		o.HTTPError = err.Error()
+		return
+	}
+
+	res, err := hc.Do(req)
+	if err != nil {
+		o.HTTPError = err.Error()
+		return
+	}
+	_ = res.Body.Close()
+}
@@ -0,0 +1,130 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package project
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/urlx"
+)
+
+type resolverFunc func(ctx context.Context, host string) ([]string, error)
+
+func (f resolverFunc) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return f(ctx, host)
+}
+
+func TestResolveSlugCmd(t *testing.T) {
+	for _, tc := range []struct {
+		name, oryAPIs, consoleURL, expected string
+	}{
+		{name: "default", expected: "https://good-wright-t7kzy3vugf.projects.oryapis.com/"},
+		{name: "custom oryapis host", oryAPIs: "https://staging.oryapis.dev", expected: "https://good-wright-t7kzy3vugf.projects.staging.oryapis.dev/"},
+		{name: "custom oryapis host with port", oryAPIs: "http://localhost:8080", expected: "http://good-wright-t7kzy3vugf.projects.localhost:8080/"},
+		{name: "custom console does not change the host", consoleURL: "https://console.staging.ory.dev", expected: "https://good-wright-t7kzy3vugf.projects.oryapis.com/"},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			t.Setenv("ORY_CLOUD_ORYAPIS_URL", tc.oryAPIs)
+			t.Setenv("ORY_CONSOLE_URL", tc.consoleURL)
+
+			cmd := NewResolveSlugCmd()
+			var stdout bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetArgs([]string{"good-wright-t7kzy3vugf", "--format", "json"})
+			require.NoError(t, cmd.ExecuteContext(context.Background()))
+
+			var out outputResolvedSlug
+			require.NoError(t, json.Unmarshal(stdout.Bytes(), &out), stdout.String())
+			assert.Equal(t, outputResolvedSlug{Slug: "good-wright-t7kzy3vugf", URL: tc.expected}, out)
+		})
+	}
+}
+
+func TestResolveSlugCmdCheckFails(t *testing.T) {
+	// The .invalid top level domain never resolves.
+	t.Setenv("ORY_CLOUD_ORYAPIS_URL", "https://oryapis.invalid")
+
+	cmd := NewResolveSlugCmd()
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"good-wright-t7kzy3vugf", "--check", "--format", "json"})
+	assert.ErrorIs(t, cmd.ExecuteContext(context.Background()), cmdx.ErrNoPrintButFail)
+
+	var out outputResolvedSlug
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &out), stdout.String())
+	assert.True(t, out.Checked)
+	assert.NotEmpty(t, out.DNSError)
+	assert.Empty(t, out.HTTPError)
+}
+
+func TestResolveSlugCheck(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(ts.Close)
+
+	resolves := resolverFunc(func(context.Context, string) ([]string, error) {
+		return []string{"127.0.0.1"}, nil
+	})
+
+	t.Run("case=reachable", func(t *testing.T) {
+		out := &outputResolvedSlug{Slug: "good-wright-t7kzy3vugf"}
+		out.check(context.Background(), urlx.ParseOrPanic(ts.URL), resolves, ts.Client())
+
+		assert.True(t, out.Checked)
+		assert.Empty(t, out.DNSError)
+		assert.Empty(t, out.HTTPError)
+		assert.Equal(t, []string{"good-wright-t7kzy3vugf", "", "ok", "ok"}, out.Columns())
+	})
+
+	t.Run("case=does not resolve", func(t *testing.T) {
+		var requested bool
+		hc := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			requested = true
+			return nil, errors.New("must not be called")
+		})}
+
+		out := &outputResolvedSlug{Slug: "good-wright-t7kzy3vugf"}
+		out.check(context.Background(), urlx.ParseOrPanic(ts.URL), resolverFunc(func(_ context.Context, host string) ([]string, error) {
+			return nil, errors.Errorf("no such host %s", host)
+		}), hc)
+
+		assert.False(t, requested, "the HTTP check is skipped")
+		assert.Equal(t, "no such host 127.0.0.1", out.DNSError)
+		assert.Equal(t, []string{"good-wright-t7kzy3vugf", "", "no such host 127.0.0.1", "<skipped>"}, out.Columns())
+	})
+
+	t.Run("case=unreachable", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+
+		out := &outputResolvedSlug{Slug: "good-wright-t7kzy3vugf"}
+		out.check(context.Background(), urlx.ParseOrPanic(closed.URL), resolves, closed.Client())
+
+		assert.Empty(t, out.DNSError)
+		assert.NotEmpty(t, out.HTTPError)
+		assert.Equal(t, out.HTTPError, out.Columns()[3])
+	})
+
+	t.Run("case=not checked", func(t *testing.T) {
+		out := &outputResolvedSlug{Slug: "good-wright-t7kzy3vugf"}
+		assert.Equal(t, []string{"good-wright-t7kzy3vugf", "", "<not checked>", "<not checked>"}, out.Columns())
+	})
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
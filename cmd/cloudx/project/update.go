@@ -123,11 +123,11 @@ func runUpdate(filePrefixer func([]json.RawMessage) ([]json.RawMessage, error),
 		}
 		id, err := getSelectedProjectId(h, args)
 		if err != nil {
-			return cmdx.PrintOpenAPIError(cmd, err)
+			return client.PrintOpenAPIError(cmd, err)
 		}
 		p, err := h.UpdateProject(id, name, configs)
 		if err != nil {
-			return cmdx.PrintOpenAPIError(cmd, err)
+			return client.PrintOpenAPIError(cmd, err)
 		}
 
 		outputter(cmd, p)
@@ -55,7 +55,7 @@ class Example implements Namespace {}
 
 			p, err := h.PatchProject(project.Id, nil, nil, []string{patch}, nil)
 			if err != nil {
-				return cmdx.PrintOpenAPIError(cmd, err)
+				return client.PrintOpenAPIError(cmd, err)
 			}
 
 			cmdx.PrintJSONAble(cmd, outputConfig(p.Project.Services.Permission.Config))
@@ -42,7 +42,7 @@ $ ory use project ecaaa3cb-0730-4ee8-a6df-9553cdfeef89 --format json
 				id = args[0]
 				err = h.SetDefaultProject(id)
 				if err != nil {
-					return cmdx.PrintOpenAPIError(cmd, err)
+					return client.PrintOpenAPIError(cmd, err)
 				}
 			}
 
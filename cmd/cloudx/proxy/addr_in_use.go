@@ -0,0 +1,18 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+// +build !windows
+
+package proxy
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// isAddrInUse reports whether binding failed because the address is already in use.
+func isAddrInUse(err error) bool {
+	return errors.Is(err, syscall.EADDRINUSE)
+}
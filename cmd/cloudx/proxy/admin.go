@@ -0,0 +1,75 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ory/herodot"
+)
+
+var (
+	errMethodNotAllowed = herodot.DefaultError{
+		StatusField: http.StatusText(http.StatusMethodNotAllowed),
+		ErrorField:  "The request method is not allowed",
+		CodeField:   http.StatusMethodNotAllowed,
+	}
+	errDraining = herodot.DefaultError{
+		StatusField: http.StatusText(http.StatusServiceUnavailable),
+		ErrorField:  "The proxy is draining and does not accept new requests",
+		CodeField:   http.StatusServiceUnavailable,
+	}
+)
+
+// admin serves the admin endpoint below /proxy/admin, which allows draining the proxy before restarting it:
+//
+//   - POST /proxy/admin/drain starts draining. It requires the admin token as a bearer token.
+//
+// While draining, requests in flight finish but all other requests are answered with 503 and their connections
+// closed. The health endpoints are served before this middleware, and /health/ready reports the drain, so that load
+// balancers stop sending traffic while liveness probes keep passing.
+type admin struct {
+	token    string
+	draining int32
+}
+
+func (a *admin) isDraining() bool {
+	return atomic.LoadInt32(&a.draining) == 1
+}
+
+func (a *admin) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return len(a.token) > 0 && subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
+
+func (a *admin) middleware(conf *config, writer herodot.Writer) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
+	prefix := path.Join("/", conf.pathPrefix, "/proxy/admin")
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if r.URL.Path == prefix+"/drain" {
+			if r.Method != http.MethodPost {
+				writer.WriteError(w, r, errMethodNotAllowed)
+				return
+			}
+			if !a.authorized(r) {
+				writer.WriteError(w, r, herodot.ErrUnauthorized.WithReason("The admin token is missing or invalid."))
+				return
+			}
+			atomic.StoreInt32(&a.draining, 1)
+			writer.WriteCode(w, r, http.StatusAccepted, map[string]string{"status": "draining"})
+			return
+		}
+
+		if a.isDraining() {
+			w.Header().Set("Connection", "close")
+			writer.WriteError(w, r, errDraining)
+			return
+		}
+
+		next(w, r)
+	}
+}
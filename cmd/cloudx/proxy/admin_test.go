@@ -0,0 +1,57 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/logrusx"
+)
+
+func TestAdminDrain(t *testing.T) {
+	l := logrusx.New("test", "test")
+	handler := (&admin{token: "secret"}).middleware(&config{pathPrefix: "/.ory"}, herodot.NewJSONWriter(l))
+
+	serve := func(method, target, token string) (*httptest.ResponseRecorder, bool) {
+		r := httptest.NewRequest(method, target, nil)
+		if len(token) > 0 {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		var forwarded bool
+		rec := httptest.NewRecorder()
+		handler(rec, r, func(http.ResponseWriter, *http.Request) {
+			forwarded = true
+		})
+		return rec, forwarded
+	}
+
+	_, forwarded := serve("GET", "/dashboard", "")
+	assert.True(t, forwarded)
+
+	rec, _ := serve("GET", "/.ory/proxy/admin/drain", "secret")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec, _ = serve("POST", "/.ory/proxy/admin/drain", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, _ = serve("POST", "/.ory/proxy/admin/drain", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	_, forwarded = serve("GET", "/dashboard", "")
+	assert.True(t, forwarded, "failed drain attempts must not start draining")
+
+	rec, _ = serve("POST", "/.ory/proxy/admin/drain", "secret")
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	rec, forwarded = serve("GET", "/dashboard", "")
+	assert.False(t, forwarded)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "close", rec.Header().Get("Connection"))
+}
@@ -0,0 +1,49 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/ory/herodot"
+)
+
+var errPayloadTooLarge = herodot.DefaultError{
+	StatusField: http.StatusText(http.StatusRequestEntityTooLarge),
+	ErrorField:  "The request body is too large",
+	CodeField:   http.StatusRequestEntityTooLarge,
+}
+
+// limitBody rejects requests with bodies larger than --max-body-size before the session is checked. The body is
+// read upfront, which the reverse proxy does anyway, so that chunked bodies are rejected before reaching the
+// application as well.
+func limitBody(conf *config, writer herodot.Writer) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
+	limit := int64(conf.maxBodySize)
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if limit <= 0 || r.Body == nil || r.Body == http.NoBody || isUpgrade(r) {
+			next(w, r)
+			return
+		}
+
+		if r.ContentLength > limit {
+			writer.WriteError(w, r, errPayloadTooLarge)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+		if err != nil {
+			if int64(len(body)) >= limit {
+				writer.WriteError(w, r, errPayloadTooLarge)
+				return
+			}
+			writer.WriteError(w, r, herodot.ErrBadRequest.WithReasonf("Unable to read the request body: %s", err))
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next(w, r)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/logrusx"
+)
+
+func TestLimitBody(t *testing.T) {
+	writer := herodot.NewJSONWriter(logrusx.New("test", "test"))
+
+	for _, tc := range []struct {
+		name           string
+		limit          int
+		body           string
+		chunked        bool
+		expectedStatus int
+	}{
+		{name: "no limit", limit: 0, body: strings.Repeat("a", 100), expectedStatus: http.StatusOK},
+		{name: "below the limit", limit: 10, body: "12345", expectedStatus: http.StatusOK},
+		{name: "at the limit", limit: 5, body: "12345", expectedStatus: http.StatusOK},
+		{name: "content length above the limit", limit: 4, body: "12345", expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "chunked body above the limit", limit: 4, body: "12345", chunked: true, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "chunked body below the limit", limit: 10, body: "12345", chunked: true, expectedStatus: http.StatusOK},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", strings.NewReader(tc.body))
+			if tc.chunked {
+				r.ContentLength = -1
+			}
+
+			var forwarded string
+			rec := httptest.NewRecorder()
+			limitBody(&config{maxBodySize: tc.limit}, writer)(rec, r, func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				forwarded = string(body)
+			})
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if tc.expectedStatus == http.StatusOK {
+				assert.Equal(t, tc.body, forwarded, "the full body reaches the next handler")
+			} else {
+				assert.Empty(t, forwarded, "the request must not be forwarded")
+				assert.Contains(t, rec.Body.String(), "The request body is too large")
+			}
+		})
+	}
+}
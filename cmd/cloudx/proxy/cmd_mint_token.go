@@ -0,0 +1,100 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/flagx"
+	"github.com/ory/x/logrusx"
+)
+
+const SessionFileFlag = "session"
+
+func newMintTokenCommand(self string, version string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mint-token",
+		Short: "Mint a JSON Web Token for a session",
+		Long: fmt.Sprintf(`Mint the JSON Web Token the proxy would inject for the given session.
+
+Use this to create sample tokens for the tests of your application. The token is written to
+standard out, and the JSON Web Key Set to verify it with is written to standard error. A new
+signing key is generated on every run, just as when running the proxy, unless --jwt-key-file
+is set. Pass the same key file to the proxy to mint tokens your application already trusts.
+
+	$ %[1]s proxy mint-token --project <your-project-slug> --session session.json
+`, self),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			contents, err := os.ReadFile(flagx.MustGetString(cmd, SessionFileFlag))
+			if err != nil {
+				return errors.Wrap(err, "unable to read the session file")
+			}
+
+			var session json.RawMessage
+			if err := json.Unmarshal(contents, &session); err != nil {
+				return errors.Wrap(err, "unable to decode the session file as JSON")
+			}
+
+			issuer, err := getEndpointURL(cmd)
+			if err != nil {
+				return err
+			}
+
+			ttl := flagx.MustGetDuration(cmd, JWTTTLFlag)
+			if err := validateJWTTTL(ttl); err != nil {
+				return err
+			}
+
+			claims, err := parseJWTClaims(flagx.MustGetStringArray(cmd, JWTClaimFlag))
+			if err != nil {
+				return err
+			}
+
+			conf := &config{
+				jwtTTL:       ttl,
+				jwtAlgorithm: flagx.MustGetString(cmd, JWTAlgorithmFlag),
+				jwtAudience:  flagx.MustGetStringSlice(cmd, JWTAudienceFlag),
+				jwtClaims:    claims,
+				jwtKeyFile:   flagx.MustGetString(cmd, JWTKeyFileFlag),
+				jwtKeyID:     flagx.MustGetString(cmd, JWTKeyIDFlag),
+			}
+			signer, keys, err := newSigner(logrusx.New("ory/proxy", version), conf)
+			if err != nil {
+				return err
+			}
+
+			token, err := mintToken(signer, issuer, session, conf)
+			if err != nil {
+				return errors.Wrap(err, "unable to mint the JSON Web Token")
+			}
+
+			jwks, err := json.MarshalIndent(publicKeySet(keys), "", "  ")
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Verify the token with this JSON Web Key Set:\n\n%s\n\n", jwks)
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), token)
+			return nil
+		},
+	}
+
+	cmd.Flags().Duration(JWTTTLFlag, defaultJWTTTL, "How long the JSON Web Token is valid, for example 5m or 30s.")
+	cmd.Flags().String(JWTAlgorithmFlag, defaultJWTAlgorithm, "The algorithm to sign the JSON Web Token with. One of "+strings.Join(jwtAlgorithms, ", ")+".")
+	cmd.Flags().StringSlice(JWTAudienceFlag, []string{}, "The audience of the JSON Web Token. Can be repeated.")
+	cmd.Flags().StringArray(JWTClaimFlag, []string{}, "Add a custom string claim to the JSON Web Token, in the form key=value. Can be repeated.")
+	cmd.Flags().String(JWTKeyFileFlag, "", "Sign with the private JSON Web Key in this file. A new key is generated and written to the file if it does not exist.")
+	cmd.Flags().String(JWTKeyIDFlag, "", "The key ID (kid) of the signing key. Defaults to the key ID stored in --jwt-key-file, or a random one.")
+	cmd.Flags().String(SessionFileFlag, "", "Path to a JSON file containing the session, for example the response of /sessions/whoami.")
+	cmd.Flags().String(ProjectFlag, "", "The slug of your Ory Network project.")
+	_ = cmd.MarkFlagRequired(SessionFileFlag)
+	return cmd
+}
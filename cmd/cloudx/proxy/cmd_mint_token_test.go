@@ -0,0 +1,114 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/square/go-jose/v3"
+	"github.com/square/go-jose/v3/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMintTokenCommand(t *testing.T) {
+	t.Setenv(envVarSlug, "")
+	t.Setenv(envVarSDK, "")
+	t.Setenv(envVarKratos, "")
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	require.NoError(t, os.WriteFile(path, []byte(testSession), 0600))
+
+	var stdout, stderr bytes.Buffer
+	cmd := newMintTokenCommand("ory", "test")
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs([]string{"--project", "my-project", "--session", path})
+	require.NoError(t, cmd.Execute())
+
+	_, jwks, found := strings.Cut(stderr.String(), "JSON Web Key Set:")
+	require.True(t, found, stderr.String())
+	var keys jose.JSONWebKeySet
+	require.NoError(t, json.Unmarshal([]byte(jwks), &keys))
+	require.Len(t, keys.Keys, 1)
+
+	token, err := jwt.ParseSigned(strings.TrimSpace(stdout.String()))
+	require.NoError(t, err)
+
+	var claims jwt.Claims
+	var custom struct {
+		Session json.RawMessage `json:"session"`
+	}
+	require.NoError(t, token.Claims(keys.Keys[0].Key, &claims, &custom))
+	assert.Equal(t, "https://my-project.projects.oryapis.com/", claims.Issuer)
+	assert.Equal(t, "a2a9a3b5-6c9b-4d5a-9a5e-1c3b21c6a2b1", claims.Subject)
+	assert.JSONEq(t, testSession, string(custom.Session))
+}
+
+func TestMintTokenCommandTTL(t *testing.T) {
+	t.Setenv(envVarSlug, "")
+	t.Setenv(envVarSDK, "")
+	t.Setenv(envVarKratos, "")
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	require.NoError(t, os.WriteFile(path, []byte(testSession), 0600))
+
+	t.Run("case=custom ttl", func(t *testing.T) {
+		var stdout bytes.Buffer
+		cmd := newMintTokenCommand("ory", "test")
+		cmd.SetOut(&stdout)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs([]string{"--project", "my-project", "--session", path, "--jwt-ttl", "5m"})
+		require.NoError(t, cmd.Execute())
+
+		token, err := jwt.ParseSigned(strings.TrimSpace(stdout.String()))
+		require.NoError(t, err)
+		var claims jwt.Claims
+		require.NoError(t, token.UnsafeClaimsWithoutVerification(&claims))
+		assert.Equal(t, 5*time.Minute, claims.Expiry.Time().Sub(claims.IssuedAt.Time()))
+	})
+
+	for _, ttl := range []string{"0", "-1m"} {
+		t.Run("case=rejects "+ttl, func(t *testing.T) {
+			cmd := newMintTokenCommand("ory", "test")
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs([]string{"--project", "my-project", "--session", path, "--jwt-ttl", ttl})
+			assert.ErrorContains(t, cmd.Execute(), "--jwt-ttl")
+		})
+	}
+}
+
+func TestMintTokenCommandClaims(t *testing.T) {
+	t.Setenv(envVarSlug, "")
+	t.Setenv(envVarSDK, "")
+	t.Setenv(envVarKratos, "")
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	require.NoError(t, os.WriteFile(path, []byte(testSession), 0600))
+
+	var stdout bytes.Buffer
+	cmd := newMintTokenCommand("ory", "test")
+	cmd.SetOut(&stdout)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"--project", "my-project", "--session", path, "--jwt-audience", "my-api", "--jwt-claim", "tenant=a,b"})
+	require.NoError(t, cmd.Execute())
+
+	token, err := jwt.ParseSigned(strings.TrimSpace(stdout.String()))
+	require.NoError(t, err)
+	var claims jwt.Claims
+	var custom struct {
+		Tenant string `json:"tenant"`
+	}
+	require.NoError(t, token.UnsafeClaimsWithoutVerification(&claims, &custom))
+	assert.Equal(t, jwt.Audience{"my-api"}, claims.Audience)
+	assert.Equal(t, "a,b", custom.Tenant)
+}
@@ -5,6 +5,7 @@ package proxy
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"sort"
@@ -16,6 +17,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/ory/graceful"
 	"github.com/ory/x/corsx"
 	"github.com/ory/x/flagx"
 	"github.com/ory/x/stringsx"
@@ -25,7 +27,8 @@ func NewProxyCommand(self string, version string) *cobra.Command {
 	proxyCmd := &cobra.Command{
 		Use:   "proxy application-url [publish-url]",
 		Short: "Run your app and Ory on the same domain using a reverse proxy",
-		Args:  cobra.RangeArgs(1, 2),
+		// The arguments may also come from --proxy-config, so they are checked in RunE.
+		Args: cobra.MaximumNArgs(2),
 		Example: fmt.Sprintf(`%[1]s proxy http://localhost:3000 --dev
 %[1]s proxy http://localhost:3000 https://app.example.com \
 	--allowed-cors-origins https://www.example.org \
@@ -93,6 +96,29 @@ development:
 
 Please note that you can not set a path in the `+"`"+`[publish-url]`+"`"+`!
 
+On SIGINT or SIGTERM, the proxy waits up to --shutdown-timeout (5s by default) for requests in
+flight to finish before closing the remaining connections. Raise it if you serve long-lived
+streaming responses.
+
+For liveness and readiness probes, the proxy answers /.ory/health/alive and /.ory/health/ready
+(below the mount path set with --mount-path) without calling the upstream or Ory. With
+--health-check-upstream, readiness responds with 503 while the upstream is unreachable. With
+--admin-endpoints, readiness also responds with 503 while the proxy drains, and liveness keeps
+passing.
+
+### HTTPS
+
+The proxy serves HTTPS with the certificate passed with --tls-cert and --tls-key. It never
+creates certificates or installs them into the system, Firefox, or Java trust stores. To trust a
+local certificate only in some stores, create it with mkcert and choose the stores with the
+TRUST_STORES environment variable:
+
+	$ TRUST_STORES=system mkcert -install
+	$ mkcert -cert-file localhost.pem -key-file localhost-key.pem localhost
+	$ %[1]s proxy --project <your-project-slug> \
+		--tls-cert localhost.pem --tls-key localhost-key.pem \
+		http://localhost:3000
+
 ### Ports
 
 Per default, the proxy listens on port 4000. If you want to listen on another port, use the
@@ -121,6 +147,18 @@ domain. You will also need to allow your subdomains in the CORS headers:
 		http://127.0.0.1:3000 \
 		https://ory.example.org
 
+### Host Header
+
+Per default, requests to your application keep the Host header the proxy was called with, for
+example localhost:4000. If your application routes requests by host, use the
+`+"`"+`--rewrite-host`+"`"+` flag to send the host of the application URL instead. The original host is
+then passed in the X-Forwarded-Host header:
+
+	$ %[1]s proxy --project <your-project-slug> --rewrite-host \
+		http://app.local:3000
+
+Requests to Ory always use the host of Ory, with or without this flag.
+
 ### Redirects
 
 Per default all default redirects will go to to `+"`"+`[publish-url]`+"`"+`. You can change this behavior using
@@ -153,8 +191,12 @@ The JSON Web Token claims contain:
 * The "sub" field which is set to the Ory Identity ID.
 * The "session" field which contains the full Ory Session.
 
-The JSON Web Token is signed using the ES256 algorithm. The public key can be found by fetching the /.ory/jwks.json path
-when calling the proxy - for example: `+"`"+`http://127.0.0.1:4000/.ory/jwks.json`+"`"+`
+The JSON Web Token is signed with the algorithm set by --jwt-algorithm: ES256 (the default), ES384, ES512, or RS256.
+The public key for the chosen algorithm can be found by fetching the /.ory/jwks.json path when calling the proxy - for
+example: `+"`"+`http://127.0.0.1:4000/.ory/jwks.json`+"`"+`
+
+A new key is generated on every start. To keep verifying tokens across restarts, store the key with --jwt-key-file.
+The key ID (kid) is part of both the token header and the JSON Web Key Set. Set it with --jwt-kid to pin it.
 
 An example payload of the JSON Web Token is:
 
@@ -174,11 +216,81 @@ An example payload of the JSON Web Token is:
 		}
 	  }
 	}
+
+### Other Credentials
+
+Some endpoints of your application might expect the Ory Session Token instead of a JSON Web Token, or no credential
+at all. Use the `+"`"+`--inject-credential`+"`"+` flag to choose the credential per path prefix. The longest matching
+prefix wins, and all other paths receive a JSON Web Token:
+
+	$ %[1]s proxy --project <your-project-slug> \
+		--inject-credential /api=session-token \
+		--inject-credential /assets=none \
+		http://localhost:3000
+
+With `+"`"+`session-token`+"`"+`, the Ory Session Token or Ory Session Cookie value is sent in the X-Session-Token header.
+
+### Multiple Applications
+
+Use the `+"`"+`--route`+"`"+` flag to send requests whose path starts with a prefix to another application. The longest
+matching prefix wins, and all other paths go to the application passed as the first argument:
+
+	$ %[1]s proxy --project <your-project-slug> \
+		--route /api=http://localhost:8080 \
+		http://localhost:3000
+
+Append `+"`"+`?strip=true`+"`"+` to the upstream to remove the prefix before forwarding, so /api/items becomes /items.
+
+For many routes, list them in a YAML or JSON file and pass it with `+"`"+`--routes-file`+"`"+`:
+
+	routes:
+	  - prefix: /api
+	    upstream: http://localhost:8080
+	    strip_prefix: true
+	  - prefix: /admin
+	    upstream: http://localhost:9000
+
+### Configuration File
+
+To check the proxy configuration into your repository, write it to a YAML or JSON file and pass it with
+`+"`"+`--proxy-config`+"`"+`. Every key is the name of a flag, and the keys upstream and public-url stand in for
+the arguments. Flags passed on the command line take precedence over the file:
+
+	upstream: http://localhost:3000
+	project: <your-project-slug>
+	port: 4000
+	open: true
+	route:
+	  - /api=http://localhost:8080
+
+	$ %[1]s proxy --proxy-config ory-proxy.yaml
 `, self),
 
 		RunE: func(cmd *cobra.Command, args []string) error {
+			args, err := applyConfigFile(cmd, args)
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				return errors.Errorf("requires the application-url argument or the %s key in --%s", configFileUpstreamKey, ProxyConfigFlag)
+			}
+
+			if flagx.MustGetBool(cmd, PrintConfigFlag) {
+				printCommandLine(cmd.ErrOrStderr(), cmd, args)
+			}
+
 			port := flagx.MustGetInt(cmd, PortFlag)
-			selfURLString := fmt.Sprintf("http://localhost:%d", port)
+			host := flagx.MustGetString(cmd, HostFlag)
+			tlsCert, tlsKey, err := tlsFilesFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			scheme := "http"
+			if len(tlsCert) > 0 {
+				scheme = "https"
+			}
+			selfURLString := defaultPublicURL(scheme, host, port)
 			if len(args) == 2 {
 				selfURLString = args[1]
 			}
@@ -193,32 +305,142 @@ An example payload of the JSON Web Token is:
 				return err
 			}
 
+			var portURLs []*url.URL
+			if len(args) < 2 {
+				portURLs = append(portURLs, selfURL)
+				if len(flagx.MustGetString(cmd, DefaultRedirectURLFlag)) == 0 {
+					portURLs = append(portURLs, redirectURL)
+				}
+			}
+
 			oryURL, err := getEndpointURL(cmd)
 			if err != nil {
 				return err
 			}
 
+			sameSite, err := parseCookieSameSite(flagx.MustGetString(cmd, CookieSameSiteFlag))
+			if err != nil {
+				return err
+			}
+
+			openCmd, err := parseOpenCommand(flagx.MustGetString(cmd, OpenCmdFlag))
+			if err != nil {
+				return err
+			}
+
+			upstreamHeaders, err := parseUpstreamHeaders(flagx.MustGetStringArray(cmd, UpstreamHeaderFlag))
+			if err != nil {
+				return err
+			}
+
 			origins, err := corsx.NormalizeOriginStrings(append(
-				flagx.MustGetStringSlice(cmd, CORSFlag), selfURL.String()),
+				corsOriginsFromFlags(cmd), selfURL.String()),
 			)
 			if err != nil {
 				return err
 			}
 
+			credentials, err := parseCredentialRules(flagx.MustGetStringSlice(cmd, CredentialFlag), flagx.MustGetBool(cmd, WithoutJWTFlag))
+			if err != nil {
+				return err
+			}
+
+			mountPath, err := parseMountPath(flagx.MustGetString(cmd, MountPathFlag))
+			if err != nil {
+				return err
+			}
+
+			jwtClaims, err := parseJWTClaims(flagx.MustGetStringArray(cmd, JWTClaimFlag))
+			if err != nil {
+				return err
+			}
+
+			jwtTTL := flagx.MustGetDuration(cmd, JWTTTLFlag)
+			if err := validateJWTTTL(jwtTTL); err != nil {
+				return err
+			}
+
+			routes, err := parseRoutes(flagx.MustGetString(cmd, RoutesFileFlag), flagx.MustGetStringSlice(cmd, RouteFlag))
+			if err != nil {
+				return err
+			}
+
+			resolve, err := parseResolveRules(flagx.MustGetStringSlice(cmd, ResolveFlag))
+			if err != nil {
+				return err
+			}
+
 			conf := &config{
-				port:              flagx.MustGetInt(cmd, PortFlag),
-				noJWT:             flagx.MustGetBool(cmd, WithoutJWTFlag),
-				noOpen:            !flagx.MustGetBool(cmd, OpenFlag),
-				upstream:          args[0],
-				cookieDomain:      flagx.MustGetString(cmd, CookieDomainFlag),
-				publicURL:         selfURL,
-				oryURL:            oryURL,
-				pathPrefix:        "/.ory",
-				defaultRedirectTo: redirectURL,
-				isDev:             flagx.MustGetBool(cmd, DevFlag),
-				isDebug:           flagx.MustGetBool(cmd, DebugFlag),
-				rewriteHost:       flagx.MustGetBool(cmd, RewriteHostFlag),
-				corsOrigins:       origins,
+				host:                 host,
+				tlsCertFile:          tlsCert,
+				tlsKeyFile:           tlsKey,
+				port:                 flagx.MustGetInt(cmd, PortFlag),
+				portAuto:             flagx.MustGetBool(cmd, PortAutoFlag),
+				portURLs:             portURLs,
+				noJWT:                flagx.MustGetBool(cmd, WithoutJWTFlag),
+				noOpen:               !flagx.MustGetBool(cmd, OpenFlag),
+				openDelay:            flagx.MustGetDuration(cmd, OpenDelayFlag),
+				openCmd:              openCmd,
+				upstream:             args[0],
+				cookieDomain:         flagx.MustGetString(cmd, CookieDomainFlag),
+				cookieDomainMatch:    flagx.MustGetString(cmd, CookieDomainMatchFlag),
+				cookieSameSite:       sameSite,
+				upstreamHeaders:      upstreamHeaders,
+				upstreamHTTP2:        flagx.MustGetBool(cmd, UpstreamHTTP2Flag),
+				publicURL:            selfURL,
+				oryURL:               oryURL,
+				pathPrefix:           mountPath,
+				defaultRedirectTo:    redirectURL,
+				isDev:                flagx.MustGetBool(cmd, DevFlag),
+				isDebug:              flagx.MustGetBool(cmd, DebugFlag),
+				pprofPort:            flagx.MustGetInt(cmd, PprofPortFlag),
+				requestIDHeader:      flagx.MustGetString(cmd, RequestIDHeaderFlag),
+				maxHeaderBytes:       flagx.MustGetInt(cmd, MaxHeaderBytesFlag),
+				keepHSTS:             flagx.MustGetBool(cmd, KeepHSTSFlag),
+				maxBodySize:          flagx.MustGetInt(cmd, MaxBodySizeFlag),
+				adminEndpoints:       flagx.MustGetBool(cmd, AdminEndpointsFlag),
+				adminToken:           flagx.MustGetString(cmd, AdminTokenFlag),
+				traceWhoami:          flagx.MustGetBool(cmd, TraceWhoamiFlag),
+				resolve:              resolve,
+				upstreamMaxConns:     flagx.MustGetInt(cmd, UpstreamMaxConnsFlag),
+				rewriteHost:          flagx.MustGetBool(cmd, RewriteHostFlag),
+				corsMethods:          flagx.MustGetStringSlice(cmd, CORSAllowedMethodsFlag),
+				corsAllowCredentials: flagx.MustGetBool(cmd, CORSCredentialsFlag),
+				corsOrigins:          origins,
+				logRequests:          flagx.MustGetBool(cmd, LogRequestsFlag),
+				metricsPort:          flagx.MustGetInt(cmd, MetricsPortFlag),
+				credentials:          credentials,
+				bypassPaths:          flagx.MustGetStringSlice(cmd, BypassPathFlag),
+				upstreamCookies:      flagx.MustGetStringSlice(cmd, UpstreamCookiesFlag),
+				landingPage:          flagx.MustGetBool(cmd, LandingPageFlag),
+				logUpstreamTiming:    flagx.MustGetBool(cmd, LogUpstreamTimingFlag),
+				logOryTiming:         flagx.MustGetBool(cmd, LogOryTimingFlag),
+				noForwardedPort:      flagx.MustGetBool(cmd, NoForwardedPortFlag),
+				trustForwardedFor:    flagx.MustGetBool(cmd, TrustForwardedForFlag),
+				redirectExpired:      flagx.MustGetBool(cmd, RedirectExpiredFlag),
+				sessionTokenHeader:   flagx.MustGetString(cmd, SessionTokenHeaderFlag),
+				prewarmWhoami:        flagx.MustGetBool(cmd, PrewarmWhoamiFlag),
+				jwksCacheMaxAge:      flagx.MustGetDuration(cmd, JWKSCacheMaxAgeFlag),
+				routes:               routes,
+				jwtTTL:               jwtTTL,
+				jwtAlgorithm:         flagx.MustGetString(cmd, JWTAlgorithmFlag),
+				jwtAudience:          flagx.MustGetStringSlice(cmd, JWTAudienceFlag),
+				jwtClaims:            jwtClaims,
+				jwtKeyFile:           flagx.MustGetString(cmd, JWTKeyFileFlag),
+				jwtKeyID:             flagx.MustGetString(cmd, JWTKeyIDFlag),
+				sessionCacheTTL:      flagx.MustGetDuration(cmd, SessionCacheTTLFlag),
+				strictSession:        flagx.MustGetBool(cmd, StrictSessionFlag),
+				shutdownTimeout:      flagx.MustGetDuration(cmd, ShutdownTimeoutFlag),
+				healthCheckUpstream:  flagx.MustGetBool(cmd, HealthCheckUpstreamFlag),
+			}
+			if err := sessionClientFromFlags(cmd, conf); err != nil {
+				return err
+			}
+			if conf.logOptions, err = loggerOptions(flagx.MustGetString(cmd, LogFormatFlag), flagx.MustGetString(cmd, LogLevelFlag)); err != nil {
+				return err
+			}
+			if conf.shutdownTimeout <= 0 {
+				return errors.Errorf("the value of --%s must be positive but got: %s", ShutdownTimeoutFlag, conf.shutdownTimeout)
 			}
 
 			return run(cmd, conf, version, "cloud")
@@ -226,20 +448,79 @@ An example payload of the JSON Web Token is:
 	}
 
 	proxyCmd.Flags().Bool(OpenFlag, false, "Open the browser when the proxy starts.")
-	proxyCmd.Flags().String(CookieDomainFlag, "", "Set a dedicated cookie domain.")
+	proxyCmd.Flags().String(OpenCmdFlag, "", "Open the proxy URL with this command instead of the default browser, for example firefox -P dev. The URL is appended as the last argument.")
+	proxyCmd.Flags().Duration(OpenDelayFlag, 0, "Wait this long after the proxy accepts connections before opening the browser, for example 2s.")
+	proxyCmd.Flags().String(CookieDomainFlag, "", "Rewrite the domain of cookies set by Ory to this domain, for example app.localhost. Cookies are host-only if not set.")
+	proxyCmd.Flags().String(CookieSameSiteFlag, "", "Set the SameSite attribute of all cookies to lax, strict, or none. None also sets Secure, which browsers require. Cookies are passed through unchanged if not set.")
+	proxyCmd.Flags().String(CookieDomainMatchFlag, cookieDomainMatchAll, "Which cookies to rewrite to the cookie domain: all rewrites every cookie, exact only rewrites cookies set for the upstream host, suffix also rewrites cookies set for its parent domains.")
 	proxyCmd.Flags().StringP(ProjectFlag, ProjectFlag[:0], "", "The slug of your Ory Network project.")
+	proxyCmd.Flags().String(HostFlag, "", "The interface to listen on, for example 127.0.0.1. Listens on all interfaces if not set.")
+	proxyCmd.Flags().String(TLSCertFlag, "", "Serve HTTPS with this PEM encoded certificate, for example one created by mkcert. Requires --tls-key. Its SHA-256 fingerprint is logged on startup.")
+	proxyCmd.Flags().String(TLSKeyFlag, "", "The PEM encoded private key of the certificate set with --tls-cert.")
+	proxyCmd.Flags().Bool(NoTLSFlag, false, "Serve plain HTTP even if --tls-cert and --tls-key are set, for example when TLS is terminated by a load balancer in front of the proxy.")
 	proxyCmd.Flags().Int(PortFlag, portFromEnv(), "The port the proxy should listen on.")
+	proxyCmd.Flags().Bool(PortAutoFlag, false, "Listen on a free port if the port is already in use, for example in CI. The default URLs use the port picked.")
 	proxyCmd.Flags().Bool(WithoutJWTFlag, false, "Do not create a JWT from the Ory Session. Useful if you need fast start up times of the Ory Proxy.")
+	proxyCmd.Flags().Duration(JWKSCacheMaxAgeFlag, 0, "Allow caching the JSON Web Key Set for this long, for example 5m. The keys change whenever the proxy restarts, so keep it short.")
+	proxyCmd.Flags().String(MountPathFlag, defaultMountPath, "The path prefix Ory's endpoints are served at, for example /_auth if your application already uses /.ory.")
+	proxyCmd.Flags().StringSlice(RouteFlag, []string{}, "Send requests whose path starts with this prefix to another application, for example /api=http://localhost:8080. The prefix matches whole path segments, so /api does not match /apiary. The upstream must not have a path. Append ?strip=true to remove the prefix from the path. The longest matching prefix wins. Can not route Ory's own paths.")
+	proxyCmd.Flags().String(RoutesFileFlag, "", "Read routes from this YAML or JSON file with a list of routes, each with a prefix and an upstream. Routes set with --route replace routes with the same prefix.")
+	proxyCmd.Flags().StringSlice(JWTAudienceFlag, []string{}, "The audience of the JSON Web Tokens sent to your application. Can be repeated.")
+	proxyCmd.Flags().StringArray(JWTClaimFlag, []string{}, "Add a custom string claim to the JSON Web Tokens sent to your application, in the form key=value. Can be repeated.")
+	proxyCmd.Flags().String(JWTKeyFileFlag, "", "Sign the JSON Web Tokens with the private JSON Web Key in this file, so that they stay valid across restarts. A new key is generated and written to the file if it does not exist.")
+	proxyCmd.Flags().String(JWTKeyIDFlag, "", "The key ID (kid) of the signing key. Defaults to the key ID stored in --jwt-key-file, or a random one.")
+	proxyCmd.Flags().Duration(JWTTTLFlag, defaultJWTTTL, "How long the JSON Web Tokens sent to your application are valid, for example 5m or 30s.")
+	proxyCmd.Flags().String(JWTAlgorithmFlag, defaultJWTAlgorithm, "The algorithm to sign JSON Web Tokens with. One of "+strings.Join(jwtAlgorithms, ", ")+".")
+	proxyCmd.Flags().StringSlice(BypassPathFlag, []string{}, "Pass requests whose path starts with this prefix to the application without checking the session, for example /__vite_ping. Can not bypass Ory's own paths.")
+	proxyCmd.Flags().Bool(NoForwardedPortFlag, false, "Do not add the X-Forwarded-Port header to requests to your application. The X-Forwarded-Host header set with --"+RewriteHostFlag+" is not affected.")
+	proxyCmd.Flags().Bool(TrustForwardedForFlag, false, "Keep the X-Forwarded-For and X-Forwarded-Port headers of incoming requests and append the client address to X-Forwarded-For. X-Real-IP is set to the last X-Forwarded-For entry, which the other proxy appended. Only use this behind another proxy that sets the headers, otherwise clients can spoof them.")
+	proxyCmd.Flags().Bool(LogUpstreamTimingFlag, false, "Log the status and latency of every request to your application at the debug level. Set --log-level debug to see them.")
+	proxyCmd.Flags().Bool(LogOryTimingFlag, false, "Also log the status and latency of every request to Ory. Requires --log-upstream-timing.")
+	proxyCmd.Flags().Bool(LandingPageFlag, false, "Show a page with links to sign in and sign up at / while your application is not reachable.")
+	proxyCmd.Flags().StringSlice(UpstreamCookiesFlag, []string{}, "Only forward cookies with these names to the application. All cookies are still used to check the session. Forwards all cookies if not set.")
+	proxyCmd.Flags().Bool(RedirectExpiredFlag, false, "Redirect page requests with an expired session to the login flow instead of passing them to the application without credentials.")
+	proxyCmd.Flags().String(SessionTokenHeaderFlag, "", "Also read the Ory Session Token from this request header, for clients that can not send the X-Session-Token header.")
+	proxyCmd.Flags().StringSlice(CredentialFlag, []string{}, "Choose the credential injected into requests matching a path prefix, for example /api=session-token. One of jwt, session-token, or none. The longest matching prefix wins. Can not route Ory's own paths. session-token only forwards Ory Session Tokens sent by the client, never session cookies.")
 	proxyCmd.Flags().String(DefaultRedirectURLFlag, "", "Set the URL to redirect to per default after e.g. login or account creation.")
-	proxyCmd.Flags().StringSlice(CORSFlag, []string{}, "A list of allowed CORS origins. Wildcards are allowed.")
+	proxyCmd.Flags().StringSlice(CORSAllowedOriginsFlag, []string{}, "A list of allowed CORS origins. Wildcards are allowed.")
+	proxyCmd.Flags().StringSlice(CORSFlag, []string{}, "A list of allowed CORS origins. Wildcards are allowed. Alias of --"+CORSAllowedOriginsFlag+".")
+	proxyCmd.Flags().StringSlice(CORSAllowedMethodsFlag, corsx.CORSDefaultAllowedMethods, "The HTTP methods allowed in CORS requests.")
+	proxyCmd.Flags().Bool(CORSCredentialsFlag, true, "Allow CORS requests to include cookies and other credentials. The request origin is sent back instead of a wildcard, so together with the origin \"*\" every website can make requests with credentials.")
 	proxyCmd.Flags().Bool(DevFlag, false, "Use this flag when developing locally.")
+	proxyCmd.Flags().Bool(LogRequestsFlag, false, "Log every request and response at the info level. Credentials are always redacted.")
 	proxyCmd.Flags().Bool(DebugFlag, false, "Use this flag to debug, for example, CORS requests.")
-	proxyCmd.Flags().Bool(RewriteHostFlag, false, "Use this flag to rewrite the host header to the upstream host.")
+	proxyCmd.Flags().Bool(PrintConfigFlag, false, "Print the full command line with all resolved flags to reproduce this configuration.")
+	proxyCmd.Flags().StringArray(UpstreamHeaderFlag, []string{}, "Add a header to every request to your application, in the form key=value, for example a shared secret. Applied after the forwarded headers, so it can override them. The Authorization header carries the JSON Web Token and can not be set. Can be repeated.")
+	proxyCmd.Flags().Bool(UpstreamHTTP2Flag, false, "Connect to plain HTTP applications using HTTP/2 without TLS (h2c), for example for gRPC-web or streaming. HTTPS applications negotiate HTTP/2 on their own.")
+	proxyCmd.Flags().Int(UpstreamMaxConnsFlag, 0, "The maximum number of connections to your application and to Ory, each. Defaults to no limit.")
+	proxyCmd.Flags().StringSlice(ResolveFlag, []string{}, "Connect to this IP when proxying to this host, in the form host:ip. Does not affect session checks.")
+	registerSessionClientFlags(proxyCmd.Flags())
+	proxyCmd.Flags().Bool(StrictSessionFlag, false, "Respond with 502 Bad Gateway if the session can not be checked because Ory is unreachable, instead of forwarding the request without credentials.")
+	proxyCmd.Flags().Duration(SessionCacheTTLFlag, 0, "Reuse active sessions for this long instead of checking them with Ory on every request, for example 10s. Revoked sessions stay valid until the entry expires. Disabled by default.")
+	proxyCmd.Flags().Bool(PrewarmWhoamiFlag, false, "Check the session once without credentials at startup, so the first request reuses the connection to Ory.")
+	proxyCmd.Flags().Bool(TraceWhoamiFlag, false, "Log the URL, status, and a summary of every session check at the debug level. Set --log-level debug to see them.")
+	proxyCmd.Flags().Bool(AdminEndpointsFlag, false, "Serve the drain endpoint below /.ory/proxy/admin, or the mount path set with --mount-path, for zero-downtime restarts. While draining, /.ory/health/ready responds with 503.")
+	proxyCmd.Flags().String(AdminTokenFlag, "", "The bearer token required to drain the proxy. A random token is generated and printed if not set.")
+	proxyCmd.Flags().Duration(ShutdownTimeoutFlag, graceful.DefaultShutdownTimeout, "How long to wait for requests in flight, such as streaming responses, to finish when shutting down. Remaining connections are closed afterwards.")
+	proxyCmd.Flags().String(LogFormatFlag, "", "The log format, one of "+strings.Join(logFormats, ", ")+". Defaults to the LOG_FORMAT environment variable or text.")
+	proxyCmd.Flags().String(LogLevelFlag, "", "The log level, for example debug or warn. Defaults to the LOG_LEVEL environment variable or info.")
+	proxyCmd.Flags().Bool(HealthCheckUpstreamFlag, false, "Report the proxy as not ready at /.ory/health/ready while the upstream is unreachable.")
+	proxyCmd.Flags().Int(MaxBodySizeFlag, 0, "The maximum size of request bodies in bytes. Larger requests are answered with 413. Defaults to no limit.")
+	proxyCmd.Flags().Bool(KeepHSTSFlag, false, "Pass the Strict-Transport-Security header of upstream responses through. By default it is replaced with max-age=0, so that browsers do not pin localhost to HTTPS.")
+	proxyCmd.Flags().Int(MaxHeaderBytesFlag, http.DefaultMaxHeaderBytes, "The maximum size in bytes of the request headers the proxy accepts from clients. Raise it if large cookies cause 431 errors. The JSON Web Token is added to the request the proxy sends to your application, so it does not count against this limit but against the header limit of your application.")
+	proxyCmd.Flags().String(RequestIDHeaderFlag, defaultRequestIDHeader, "The header carrying the request ID. A request ID is generated if the header is missing.")
+	proxyCmd.Flags().Int(MetricsPortFlag, 0, "Serve Prometheus metrics at /metrics on this port. Disabled by default.")
+	proxyCmd.Flags().Int(PprofPortFlag, 0, "Serve runtime profiles on this port on localhost.")
+	_ = proxyCmd.Flags().MarkHidden(PprofPortFlag)
+	proxyCmd.Flags().String(ProxyConfigFlag, "", "Read flags and arguments from this YAML or JSON file. Flags passed on the command line take precedence.")
+	proxyCmd.Flags().Bool(RewriteHostFlag, false, "Set the Host header of requests to your application to the host of the application URL instead of the host the proxy was called with.")
 
 	client.RegisterConfigFlag(proxyCmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(proxyCmd.PersistentFlags())
 	client.RegisterYesFlag(proxyCmd.PersistentFlags())
 	cmdx.RegisterNoiseFlags(proxyCmd.PersistentFlags())
 
+	proxyCmd.AddCommand(newMintTokenCommand(self, version))
 	return proxyCmd
 }
 
@@ -248,13 +529,15 @@ const envVarSDK = "ORY_SDK_URL"
 const envVarKratos = "ORY_KRATOS_URL"
 
 func getEndpointURL(cmd *cobra.Command) (*url.URL, error) {
-	var target string
+	var target, source string
 	if slug := os.Getenv(envVarSlug); len(slug) > 0 {
-		target = fmt.Sprintf("https://%s.projects.oryapis.com/", slug)
-	} else if url := stringsx.Coalesce(os.Getenv(envVarSDK), os.Getenv(envVarKratos)); len(url) > 0 {
-		target = url
+		target, source = client.ProjectAPIsURL(slug).String(), envVarSlug
+	} else if url := os.Getenv(envVarSDK); len(url) > 0 {
+		target, source = url, envVarSDK
+	} else if url := os.Getenv(envVarKratos); len(url) > 0 {
+		target, source = url, envVarKratos
 	} else if slug := flagx.MustGetString(cmd, ProjectFlag); len(slug) > 0 {
-		target = fmt.Sprintf("https://%s.projects.oryapis.com/", slug)
+		target, source = client.ProjectAPIsURL(slug).String(), "--"+ProjectFlag
 	}
 
 	if len(target) == 0 {
@@ -262,8 +545,8 @@ func getEndpointURL(cmd *cobra.Command) (*url.URL, error) {
 	}
 
 	upstream, err := url.ParseRequestURI(target)
-	if err != nil {
-		return nil, errors.Errorf("Unable to parse \"%s\" as an URL: %s", target, err)
+	if err != nil || (upstream.Scheme != "http" && upstream.Scheme != "https") || len(upstream.Host) == 0 {
+		return nil, errors.Errorf("the Ory URL %q from %s is not an absolute http or https URL", target, source)
 	}
 
 	if err = printDeprecations(cmd, target); err != nil {
@@ -55,3 +55,19 @@ func TestGetEndpointURL(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestGetEndpointURLValidation(t *testing.T) {
+	for _, tc := range []struct{ env, value string }{
+		{env: envVarSDK, value: "localhost:4000"},
+		{env: envVarSDK, value: "ftp://example.com"},
+		{env: envVarKratos, value: "/just/a/path"},
+	} {
+		t.Run("case="+tc.value, func(t *testing.T) {
+			t.Setenv(tc.env, tc.value)
+			_, err := getEndpointURL(newEndpointCmd(""))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.env)
+			assert.Contains(t, err.Error(), tc.value)
+		})
+	}
+}
@@ -5,13 +5,18 @@ package proxy
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
 
 	"github.com/ory/cli/cmd/cloudx/client"
 	"github.com/ory/x/cmdx"
 
 	"github.com/spf13/cobra"
 
+	"github.com/ory/graceful"
 	"github.com/ory/x/corsx"
 	"github.com/ory/x/flagx"
 	"github.com/ory/x/stringsx"
@@ -109,8 +114,13 @@ TO use a different default redirect URL, use the `+"`"+`--default-redirect-url`+
 `, self),
 
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if flagx.MustGetBool(cmd, PrintConfigFlag) {
+				printCommandLine(cmd.ErrOrStderr(), cmd, args)
+			}
+
 			port := flagx.MustGetInt(cmd, PortFlag)
-			selfURLString := fmt.Sprintf("http://localhost:%d", port)
+			host := flagx.MustGetString(cmd, HostFlag)
+			selfURLString := defaultPublicURL("http", host, port)
 			if len(args) == 2 {
 				selfURLString = args[1]
 			}
@@ -125,6 +135,11 @@ TO use a different default redirect URL, use the `+"`"+`--default-redirect-url`+
 				return err
 			}
 
+			var portURLs []*url.URL
+			if len(args) < 2 {
+				portURLs = append(portURLs, selfURL)
+			}
+
 			oryURL, err := getEndpointURL(cmd)
 			if err != nil {
 				return err
@@ -135,42 +150,94 @@ TO use a different default redirect URL, use the `+"`"+`--default-redirect-url`+
 				return err
 			}
 
+			sameSite, err := parseCookieSameSite(flagx.MustGetString(cmd, CookieSameSiteFlag))
+			if err != nil {
+				return err
+			}
+
 			origins, err := corsx.NormalizeOriginStrings(append(
-				flagx.MustGetStringSlice(cmd, CORSFlag), appURL.String()),
+				corsOriginsFromFlags(cmd), appURL.String()),
 			)
 			if err != nil {
 				return err
 			}
 
 			conf := &config{
-				port:              flagx.MustGetInt(cmd, PortFlag),
-				noJWT:             true,
-				noOpen:            true,
-				upstream:          oryURL.String(),
-				cookieDomain:      flagx.MustGetString(cmd, CookieDomainFlag),
-				publicURL:         selfURL,
-				oryURL:            oryURL,
-				pathPrefix:        "",
-				isTunnel:          true,
-				defaultRedirectTo: redirectURL,
-				isDev:             flagx.MustGetBool(cmd, DevFlag),
-				isDebug:           flagx.MustGetBool(cmd, DebugFlag),
-				corsOrigins:       origins,
+				host:                 host,
+				port:                 flagx.MustGetInt(cmd, PortFlag),
+				portAuto:             flagx.MustGetBool(cmd, PortAutoFlag),
+				portURLs:             portURLs,
+				noJWT:                true,
+				noOpen:               true,
+				upstream:             oryURL.String(),
+				cookieDomain:         flagx.MustGetString(cmd, CookieDomainFlag),
+				cookieDomainMatch:    flagx.MustGetString(cmd, CookieDomainMatchFlag),
+				cookieSameSite:       sameSite,
+				publicURL:            selfURL,
+				oryURL:               oryURL,
+				pathPrefix:           "",
+				isTunnel:             true,
+				defaultRedirectTo:    redirectURL,
+				isDev:                flagx.MustGetBool(cmd, DevFlag),
+				isDebug:              flagx.MustGetBool(cmd, DebugFlag),
+				pprofPort:            flagx.MustGetInt(cmd, PprofPortFlag),
+				requestIDHeader:      flagx.MustGetString(cmd, RequestIDHeaderFlag),
+				maxHeaderBytes:       flagx.MustGetInt(cmd, MaxHeaderBytesFlag),
+				keepHSTS:             flagx.MustGetBool(cmd, KeepHSTSFlag),
+				adminEndpoints:       flagx.MustGetBool(cmd, AdminEndpointsFlag),
+				adminToken:           flagx.MustGetString(cmd, AdminTokenFlag),
+				corsMethods:          flagx.MustGetStringSlice(cmd, CORSAllowedMethodsFlag),
+				corsAllowCredentials: flagx.MustGetBool(cmd, CORSCredentialsFlag),
+				corsOrigins:          origins,
+				logRequests:          flagx.MustGetBool(cmd, LogRequestsFlag),
+				metricsPort:          flagx.MustGetInt(cmd, MetricsPortFlag),
+				shutdownTimeout:      flagx.MustGetDuration(cmd, ShutdownTimeoutFlag),
+			}
+			if err := sessionClientFromFlags(cmd, conf); err != nil {
+				return err
+			}
+			if conf.logOptions, err = loggerOptions(flagx.MustGetString(cmd, LogFormatFlag), flagx.MustGetString(cmd, LogLevelFlag)); err != nil {
+				return err
+			}
+			if conf.shutdownTimeout <= 0 {
+				return errors.Errorf("the value of --%s must be positive but got: %s", ShutdownTimeoutFlag, conf.shutdownTimeout)
 			}
 
 			return run(cmd, conf, version, "cloud")
 		},
 	}
 
-	proxyCmd.Flags().String(CookieDomainFlag, "", "Set a dedicated cookie domain.")
+	proxyCmd.Flags().String(CookieDomainFlag, "", "Rewrite the domain of cookies set by Ory to this domain, for example app.localhost. Cookies are host-only if not set.")
+	proxyCmd.Flags().String(CookieSameSiteFlag, "", "Set the SameSite attribute of all cookies to lax, strict, or none. None also sets Secure, which browsers require. Cookies are passed through unchanged if not set.")
+	proxyCmd.Flags().String(CookieDomainMatchFlag, cookieDomainMatchAll, "Which cookies to rewrite to the cookie domain: all rewrites every cookie, exact only rewrites cookies set for the upstream host, suffix also rewrites cookies set for its parent domains.")
 	proxyCmd.Flags().StringP(ProjectFlag, ProjectFlag[:0], "", "The slug of your Ory Network project.")
+	proxyCmd.Flags().String(HostFlag, "", "The interface to listen on, for example 127.0.0.1. Listens on all interfaces if not set.")
 	proxyCmd.Flags().Int(PortFlag, portFromEnv(), "The port the proxy should listen on.")
+	proxyCmd.Flags().Bool(PortAutoFlag, false, "Listen on a free port if the port is already in use, for example in CI. The default URLs use the port picked.")
 	proxyCmd.Flags().Bool(DevFlag, false, "Use this flag when developing locally.")
+	proxyCmd.Flags().Bool(LogRequestsFlag, false, "Log every request and response at the info level. Credentials are always redacted.")
 	proxyCmd.Flags().Bool(DebugFlag, false, "Use this flag to debug, for example, CORS requests.")
+	proxyCmd.Flags().Bool(PrintConfigFlag, false, "Print the full command line with all resolved flags to reproduce this configuration.")
+	proxyCmd.Flags().Bool(AdminEndpointsFlag, false, "Serve the drain endpoint below /proxy/admin for zero-downtime restarts. While draining, /health/ready responds with 503.")
+	proxyCmd.Flags().String(AdminTokenFlag, "", "The bearer token required to drain the proxy. A random token is generated and printed if not set.")
+	proxyCmd.Flags().Duration(ShutdownTimeoutFlag, graceful.DefaultShutdownTimeout, "How long to wait for requests in flight, such as streaming responses, to finish when shutting down. Remaining connections are closed afterwards.")
+	proxyCmd.Flags().String(LogFormatFlag, "", "The log format, one of "+strings.Join(logFormats, ", ")+". Defaults to the LOG_FORMAT environment variable or text.")
+	proxyCmd.Flags().String(LogLevelFlag, "", "The log level, for example debug or warn. Defaults to the LOG_LEVEL environment variable or info.")
+	registerSessionClientFlags(proxyCmd.Flags())
+	proxyCmd.Flags().Bool(KeepHSTSFlag, false, "Pass the Strict-Transport-Security header of upstream responses through. By default it is replaced with max-age=0, so that browsers do not pin localhost to HTTPS.")
+	proxyCmd.Flags().Int(MaxHeaderBytesFlag, http.DefaultMaxHeaderBytes, "The maximum size in bytes of the request headers the tunnel accepts from clients. Raise it if large cookies cause 431 errors.")
+	proxyCmd.Flags().String(RequestIDHeaderFlag, defaultRequestIDHeader, "The header carrying the request ID. A request ID is generated if the header is missing.")
+	proxyCmd.Flags().Int(MetricsPortFlag, 0, "Serve Prometheus metrics at /metrics on this port. Disabled by default.")
+	proxyCmd.Flags().Int(PprofPortFlag, 0, "Serve runtime profiles on this port on localhost.")
+	_ = proxyCmd.Flags().MarkHidden(PprofPortFlag)
 	proxyCmd.Flags().String(DefaultRedirectURLFlag, "", "Set the URL to redirect to per default after e.g. login or account creation.")
-	proxyCmd.Flags().StringSlice(CORSFlag, []string{}, "A list of allowed CORS origins. Wildcards are allowed.")
+	proxyCmd.Flags().StringSlice(CORSAllowedOriginsFlag, []string{}, "A list of allowed CORS origins. Wildcards are allowed.")
+	proxyCmd.Flags().StringSlice(CORSFlag, []string{}, "A list of allowed CORS origins. Wildcards are allowed. Alias of --"+CORSAllowedOriginsFlag+".")
+	proxyCmd.Flags().StringSlice(CORSAllowedMethodsFlag, corsx.CORSDefaultAllowedMethods, "The HTTP methods allowed in CORS requests.")
+	proxyCmd.Flags().Bool(CORSCredentialsFlag, true, "Allow CORS requests to include cookies and other credentials. The request origin is sent back instead of a wildcard, so together with the origin \"*\" every website can make requests with credentials.")
 
 	client.RegisterConfigFlag(proxyCmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(proxyCmd.PersistentFlags())
 	client.RegisterYesFlag(proxyCmd.PersistentFlags())
 	cmdx.RegisterNoiseFlags(proxyCmd.PersistentFlags())
 
@@ -0,0 +1,103 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/flagx"
+)
+
+const (
+	SessionTokenFlag  = "session-token"
+	SessionCookieFlag = "cookie"
+
+	envVarSessionToken = "ORY_SESSION_TOKEN"
+)
+
+// NewWhoamiCommand checks a session the same way the proxy does and prints the identity it belongs to.
+func NewWhoamiCommand(self string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "whoami",
+		Short: "Print the identity of a session",
+		Long: fmt.Sprintf(`Check a session with the Ory Network project and print the identity it belongs to.
+
+The session is checked exactly like the proxy checks it, which helps to debug sessions without
+running the proxy. Pass either a session token, with --%[2]s or the %[3]s
+environment variable, or the cookies of a browser session with --%[4]s.
+
+	$ %[1]s whoami --project <your-project-slug> --%[2]s <session-token>
+	$ %[1]s whoami --project <your-project-slug> --%[4]s "ory_session_...=..."
+`, self, SessionTokenFlag, envVarSessionToken, SessionCookieFlag),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			token := flagx.MustGetString(cmd, SessionTokenFlag)
+			if len(token) == 0 {
+				token = os.Getenv(envVarSessionToken)
+			}
+			cookie := flagx.MustGetString(cmd, SessionCookieFlag)
+			if len(token) == 0 && len(cookie) == 0 {
+				return errors.Errorf("Please provide the session token using the --%s flag or the %s environment variable, or the session cookie using the --%s flag.", SessionTokenFlag, envVarSessionToken, SessionCookieFlag)
+			}
+
+			endpoint, err := getEndpointURL(cmd)
+			if err != nil {
+				return err
+			}
+
+			conf := new(config)
+			if err := sessionClientFromFlags(cmd, conf); err != nil {
+				return err
+			}
+
+			r, err := http.NewRequest("GET", "/", nil)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if len(token) > 0 {
+				r.Header.Set("X-Session-Token", token)
+			}
+			if len(cookie) > 0 {
+				r.Header.Set("Cookie", cookie)
+			}
+
+			session, err := checkSession(newSessionClient(conf), r, endpoint, defaultRequestIDHeader, "")
+			if err != nil {
+				return err
+			}
+
+			identity := gjson.GetBytes(session, "identity")
+			if !identity.IsObject() {
+				reason := gjson.GetBytes(session, "error.reason").String()
+				if len(reason) == 0 {
+					reason = gjson.GetBytes(session, "error.message").String()
+				}
+				return errors.Errorf("The session is not valid: %s", reason)
+			}
+
+			var out bytes.Buffer
+			if err := json.Indent(&out, []byte(identity.Raw), "", "  "); err != nil {
+				return errors.WithStack(err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "The session %s is active until %s.\n", gjson.GetBytes(session, "id").String(), gjson.GetBytes(session, "expires_at").String())
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), out.String())
+			return nil
+		},
+	}
+
+	cmd.Flags().String(SessionTokenFlag, "", "The session token to check. Defaults to the "+envVarSessionToken+" environment variable.")
+	cmd.Flags().String(SessionCookieFlag, "", "The Cookie header of a browser session to check.")
+	cmd.Flags().String(ProjectFlag, "", "The slug of your Ory Network project.")
+	registerSessionClientFlags(cmd.Flags())
+	return cmd
+}
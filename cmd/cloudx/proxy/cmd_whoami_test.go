@@ -0,0 +1,73 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhoamiCommand(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/kratos/public/sessions/whoami", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("X-Session-Token") != "valid-token" && r.Header.Get("Cookie") != "ory_session=valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":401,"reason":"No valid session credentials found in the request."}}`))
+			return
+		}
+		_, _ = w.Write([]byte(testSession))
+	}))
+	t.Cleanup(ts.Close)
+
+	t.Setenv(envVarSlug, "")
+	t.Setenv(envVarSDK, ts.URL)
+	t.Setenv(envVarKratos, "")
+	t.Setenv(envVarSessionToken, "")
+
+	run := func(t *testing.T, args ...string) (string, error) {
+		var stdout bytes.Buffer
+		cmd := NewWhoamiCommand("ory")
+		cmd.SetOut(&stdout)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return stdout.String(), err
+	}
+
+	t.Run("case=session token flag", func(t *testing.T) {
+		stdout, err := run(t, "--"+SessionTokenFlag, "valid-token")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"a2a9a3b5-6c9b-4d5a-9a5e-1c3b21c6a2b1"}`, stdout)
+	})
+
+	t.Run("case=session token environment variable", func(t *testing.T) {
+		t.Setenv(envVarSessionToken, "valid-token")
+		stdout, err := run(t)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"a2a9a3b5-6c9b-4d5a-9a5e-1c3b21c6a2b1"}`, stdout)
+	})
+
+	t.Run("case=cookie", func(t *testing.T) {
+		stdout, err := run(t, "--"+SessionCookieFlag, "ory_session=valid")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"a2a9a3b5-6c9b-4d5a-9a5e-1c3b21c6a2b1"}`, stdout)
+	})
+
+	t.Run("case=invalid session", func(t *testing.T) {
+		_, err := run(t, "--"+SessionTokenFlag, "invalid-token")
+		assert.ErrorContains(t, err, "No valid session credentials found in the request.")
+	})
+
+	t.Run("case=no credentials", func(t *testing.T) {
+		_, err := run(t)
+		assert.ErrorContains(t, err, "--"+SessionTokenFlag)
+	})
+}
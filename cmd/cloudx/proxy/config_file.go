@@ -0,0 +1,102 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+// Keys of the proxy configuration file that fill in the positional arguments instead of setting a flag.
+const (
+	configFileUpstreamKey  = "upstream"
+	configFilePublicURLKey = "public-url"
+)
+
+// applyConfigFile reads the YAML or JSON file passed with --proxy-config and sets every flag it names that was not
+// passed on the command line. Flags therefore take precedence over the file, and the file over the flag defaults.
+// The upstream and public-url keys are used if the respective argument is missing. Unknown keys are an error.
+func applyConfigFile(cmd *cobra.Command, args []string) ([]string, error) {
+	file, err := cmd.Flags().GetString(ProxyConfigFlag)
+	if err != nil || len(file) == 0 {
+		return args, nil
+	}
+
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read --%s", ProxyConfigFlag)
+	}
+
+	// JSON is valid YAML, so this reads both.
+	values := map[string]interface{}{}
+	if err := yaml.UnmarshalStrict(raw, &values); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse --%s %s", ProxyConfigFlag, file)
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	arguments := map[string]string{}
+	for _, k := range keys {
+		switch k {
+		case configFileUpstreamKey, configFilePublicURLKey:
+			value, ok := values[k].(string)
+			if !ok {
+				return nil, errors.Errorf("key %s in --%s %s must be a string", k, ProxyConfigFlag, file)
+			}
+			arguments[k] = value
+			continue
+		}
+
+		f := cmd.Flags().Lookup(k)
+		if f == nil || f.Name == ProxyConfigFlag || f.Name == "help" {
+			return nil, errors.Errorf("unknown key %s in --%s %s", k, ProxyConfigFlag, file)
+		}
+		if f.Changed {
+			continue
+		}
+
+		if err := setFlagFromConfigFile(cmd.Flags(), f, values[k]); err != nil {
+			return nil, errors.WithMessagef(err, "key %s in --%s %s", k, ProxyConfigFlag, file)
+		}
+	}
+
+	for k, key := range []string{configFileUpstreamKey, configFilePublicURLKey} {
+		if value, ok := arguments[key]; ok && len(args) == k {
+			args = append(args, value)
+		}
+	}
+
+	return args, nil
+}
+
+func setFlagFromConfigFile(flags *pflag.FlagSet, f *pflag.Flag, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		if _, ok := f.Value.(pflag.SliceValue); !ok {
+			return errors.New("must not be a list")
+		}
+		for _, item := range v {
+			if err := flags.Set(f.Name, fmt.Sprint(item)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[interface{}]interface{}:
+		return errors.New("must not be an object")
+	default:
+		return flags.Set(f.Name, fmt.Sprint(v))
+	}
+}
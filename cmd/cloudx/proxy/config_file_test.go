@@ -0,0 +1,83 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/flagx"
+)
+
+func TestApplyConfigFile(t *testing.T) {
+	apply := func(t *testing.T, contents string, flags ...string) ([]string, error) {
+		file := filepath.Join(t.TempDir(), "proxy.yaml")
+		require.NoError(t, os.WriteFile(file, []byte(contents), 0600))
+
+		cmd := NewProxyCommand("ory", "test")
+		require.NoError(t, cmd.ParseFlags(append([]string{"--" + ProxyConfigFlag, file}, flags...)))
+		return applyConfigFile(cmd, cmd.Flags().Args())
+	}
+
+	t.Run("case=sets flags and arguments from YAML", func(t *testing.T) {
+		cmd := NewProxyCommand("ory", "test")
+		file := filepath.Join(t.TempDir(), "proxy.yaml")
+		require.NoError(t, os.WriteFile(file, []byte(`
+upstream: http://localhost:3000
+public-url: https://app.example.com
+port: 4500
+open: true
+route:
+  - /api=http://localhost:8080
+  - /admin=http://localhost:9000
+`), 0600))
+		require.NoError(t, cmd.ParseFlags([]string{"--" + ProxyConfigFlag, file}))
+
+		args, err := applyConfigFile(cmd, cmd.Flags().Args())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"http://localhost:3000", "https://app.example.com"}, args)
+		assert.Equal(t, 4500, flagx.MustGetInt(cmd, PortFlag))
+		assert.True(t, flagx.MustGetBool(cmd, OpenFlag))
+		assert.Equal(t, []string{"/api=http://localhost:8080", "/admin=http://localhost:9000"}, flagx.MustGetStringSlice(cmd, RouteFlag))
+	})
+
+	t.Run("case=reads JSON", func(t *testing.T) {
+		args, err := apply(t, `{"upstream": "http://localhost:3000", "port": 4500}`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"http://localhost:3000"}, args)
+	})
+
+	t.Run("case=flags and arguments take precedence", func(t *testing.T) {
+		cmd := NewProxyCommand("ory", "test")
+		file := filepath.Join(t.TempDir(), "proxy.yaml")
+		require.NoError(t, os.WriteFile(file, []byte("upstream: http://localhost:3000\nport: 4500\n"), 0600))
+		require.NoError(t, cmd.ParseFlags([]string{"--" + ProxyConfigFlag, file, "--" + PortFlag, "4600", "http://localhost:8000"}))
+
+		args, err := applyConfigFile(cmd, cmd.Flags().Args())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"http://localhost:8000"}, args)
+		assert.Equal(t, 4600, flagx.MustGetInt(cmd, PortFlag))
+	})
+
+	for _, tc := range []struct {
+		name, contents, expected string
+	}{
+		{name: "unknown keys", contents: "upstream: http://localhost:3000\nprot: 4500\n", expected: "unknown key prot"},
+		{name: "the config flag itself", contents: ProxyConfigFlag + ": other.yaml\n", expected: "unknown key " + ProxyConfigFlag},
+		{name: "invalid values", contents: "port: nope\n", expected: "key port"},
+		{name: "lists for scalar flags", contents: "port: [1, 2]\n", expected: "must not be a list"},
+		{name: "objects", contents: "route: {a: b}\n", expected: "must not be an object"},
+		{name: "a non-string upstream", contents: "upstream: 3000\n", expected: "must be a string"},
+		{name: "duplicate keys", contents: "port: 1\nport: 2\n", expected: "unable to parse"},
+	} {
+		t.Run("case=rejects "+tc.name, func(t *testing.T) {
+			_, err := apply(t, tc.contents)
+			assert.ErrorContains(t, err, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/proxy"
+)
+
+const (
+	cookieDomainMatchAll    = "all"
+	cookieDomainMatchExact  = "exact"
+	cookieDomainMatchSuffix = "suffix"
+
+	// preservedCookiesHeader carries cookies which must not be rewritten from the transport to the response middleware.
+	preservedCookiesHeader = "X-Ory-Proxy-Preserved-Set-Cookie"
+)
+
+func validateCookieDomainMatch(mode string) error {
+	switch mode {
+	case cookieDomainMatchAll, cookieDomainMatchExact, cookieDomainMatchSuffix:
+		return nil
+	}
+	return errors.Errorf("the value of --%s must be one of %q, %q, or %q but got: %s", CookieDomainMatchFlag, cookieDomainMatchAll, cookieDomainMatchExact, cookieDomainMatchSuffix, mode)
+}
+
+// validateCookieDomain rejects values which are not a bare domain, such as URLs or hosts with a port. Browsers
+// ignore cookies with such domains.
+func validateCookieDomain(domain string) error {
+	if len(domain) == 0 {
+		return nil
+	}
+	if strings.ContainsAny(domain, ":/?# ") {
+		return errors.Errorf("the value of --%s must be a domain such as app.localhost, without scheme, port, or path, but got: %s", CookieDomainFlag, domain)
+	}
+	return nil
+}
+
+// cookieDomainMatches reports whether a cookie set for domain by host should be rewritten to the cookie domain.
+// In all mode, the default, every cookie matches. Otherwise host-only cookies always match. In exact mode the domain
+// must equal the host, in suffix mode cookies set on a parent domain of the host (e.g. `.oryapis.com`) match as well.
+func cookieDomainMatches(mode, domain, host string) bool {
+	if mode == cookieDomainMatchAll {
+		return true
+	}
+
+	domain = strings.TrimPrefix(domain, ".")
+	if domain == "" || strings.EqualFold(domain, host) {
+		return true
+	}
+
+	if mode == cookieDomainMatchSuffix {
+		return strings.HasSuffix(strings.ToLower(host), "."+strings.ToLower(domain))
+	}
+
+	return false
+}
+
+// cookieDomainTransport moves cookies which do not match the upstream host out of the way, so that the
+// proxy only rewrites the domain of matching cookies.
+type cookieDomainTransport struct {
+	http.RoundTripper
+	match string
+}
+
+func (t *cookieDomainTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.RoundTripper.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The upstream must not be able to pass cookies by the domain rewrite with the internal header.
+	res.Header.Del(preservedCookiesHeader)
+
+	lines := res.Header.Values("Set-Cookie")
+	res.Header.Del("Set-Cookie")
+	for _, line := range lines {
+		cookies := (&http.Response{Header: http.Header{"Set-Cookie": {line}}}).Cookies()
+		if len(cookies) == 1 && !cookieDomainMatches(t.match, cookies[0].Domain, req.URL.Hostname()) {
+			res.Header.Add(preservedCookiesHeader, line)
+			continue
+		}
+		res.Header.Add("Set-Cookie", line)
+	}
+
+	return res, nil
+}
+
+func restorePreservedCookies(resp *http.Response, _ *proxy.HostConfig, body []byte) ([]byte, error) {
+	for _, line := range resp.Header.Values(preservedCookiesHeader) {
+		resp.Header.Add("Set-Cookie", line)
+	}
+	resp.Header.Del(preservedCookiesHeader)
+	return body, nil
+}
+
+var cookieSameSiteModes = map[string]http.SameSite{
+	"":       0,
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+	"none":   http.SameSiteNoneMode,
+}
+
+func parseCookieSameSite(value string) (http.SameSite, error) {
+	mode, ok := cookieSameSiteModes[strings.ToLower(value)]
+	if !ok {
+		return 0, errors.Errorf("the value of --%s must be one of lax, strict, or none but got: %s", CookieSameSiteFlag, value)
+	}
+	return mode, nil
+}
+
+var cookieSameSiteAttributes = map[http.SameSite]string{
+	http.SameSiteLaxMode:    "SameSite=Lax",
+	http.SameSiteStrictMode: "SameSite=Strict",
+	http.SameSiteNoneMode:   "SameSite=None",
+}
+
+// rewriteSameSite sets the SameSite attribute of all cookies in the response. Browsers reject SameSite=None
+// without Secure, so it is forced on for that mode.
+func rewriteSameSite(mode http.SameSite) proxy.RespMiddleware {
+	return func(resp *http.Response, _ *proxy.HostConfig, body []byte) ([]byte, error) {
+		if mode == 0 {
+			return body, nil
+		}
+
+		lines := resp.Header.Values("Set-Cookie")
+		resp.Header.Del("Set-Cookie")
+		for _, line := range lines {
+			resp.Header.Add("Set-Cookie", setCookieSameSite(line, mode))
+		}
+		return body, nil
+	}
+}
+
+// setCookieSameSite replaces the SameSite attribute of the raw Set-Cookie header line. The line is edited instead of
+// parsed and serialized again, so that cookies and attributes the standard library does not understand are kept.
+func setCookieSameSite(line string, mode http.SameSite) string {
+	if len(strings.TrimSpace(line)) == 0 {
+		return line
+	}
+
+	parts := strings.Split(line, ";")
+	kept := []string{strings.TrimSpace(parts[0])}
+	var secure bool
+	for _, attr := range parts[1:] {
+		attr = strings.TrimSpace(attr)
+		name, _, _ := strings.Cut(attr, "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "", "samesite":
+			continue
+		case "secure":
+			secure = true
+		}
+		kept = append(kept, attr)
+	}
+
+	if mode == http.SameSiteNoneMode && !secure {
+		kept = append(kept, "Secure")
+	}
+	return strings.Join(append(kept, cookieSameSiteAttributes[mode]), "; ")
+}
+
+// filterCookies removes all cookies from the request which are not in the allowlist.
+func filterCookies(r *http.Request, allowed []string) {
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, c := range cookies {
+		for _, name := range allowed {
+			if c.Name == name {
+				r.AddCookie(c)
+				break
+			}
+		}
+	}
+}
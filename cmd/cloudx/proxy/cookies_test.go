@@ -0,0 +1,225 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/urlx"
+)
+
+func TestCookieDomainMatches(t *testing.T) {
+	const host = "my-project.projects.oryapis.com"
+
+	for _, tc := range []struct {
+		domain        string
+		exact, suffix bool
+	}{
+		{domain: "", exact: true, suffix: true},
+		{domain: host, exact: true, suffix: true},
+		{domain: "." + host, exact: true, suffix: true},
+		{domain: "My-Project.Projects.OryApis.com", exact: true, suffix: true},
+		{domain: ".projects.oryapis.com", exact: false, suffix: true},
+		{domain: "oryapis.com", exact: false, suffix: true},
+		{domain: "apis.com", exact: false, suffix: false},
+		{domain: "example.org", exact: false, suffix: false},
+		{domain: "other-project.projects.oryapis.com", exact: false, suffix: false},
+	} {
+		t.Run(fmt.Sprintf("domain=%s", tc.domain), func(t *testing.T) {
+			assert.Equal(t, tc.exact, cookieDomainMatches(cookieDomainMatchExact, tc.domain, host), "exact")
+			assert.Equal(t, tc.suffix, cookieDomainMatches(cookieDomainMatchSuffix, tc.domain, host), "suffix")
+			assert.True(t, cookieDomainMatches(cookieDomainMatchAll, tc.domain, host), "all")
+		})
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestCookieDomainTransport(t *testing.T) {
+	upstream := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		http.SetCookie(rec, &http.Cookie{Name: "host-only", Value: "a"})
+		http.SetCookie(rec, &http.Cookie{Name: "parent", Value: "b", Domain: "oryapis.com"})
+		http.SetCookie(rec, &http.Cookie{Name: "foreign", Value: "c", Domain: "example.org"})
+		res := rec.Result()
+		// Cookies in the internal header must not skip the rewrite.
+		res.Header.Add(preservedCookiesHeader, "smuggled=d; Domain=example.org")
+		return res, nil
+	})
+
+	for mode, tc := range map[string]struct{ rewritten, preserved []string }{
+		cookieDomainMatchAll: {
+			rewritten: []string{"host-only=a", "parent=b; Domain=oryapis.com", "foreign=c; Domain=example.org"},
+		},
+		cookieDomainMatchExact: {
+			rewritten: []string{"host-only=a"},
+			preserved: []string{"parent=b; Domain=oryapis.com", "foreign=c; Domain=example.org"},
+		},
+		cookieDomainMatchSuffix: {
+			rewritten: []string{"host-only=a", "parent=b; Domain=oryapis.com"},
+			preserved: []string{"foreign=c; Domain=example.org"},
+		},
+	} {
+		t.Run("mode="+mode, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "https://my-project.projects.oryapis.com/", nil)
+
+			res, err := (&cookieDomainTransport{RoundTripper: upstream, match: mode}).RoundTrip(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.rewritten, res.Header.Values("Set-Cookie"))
+			assert.Equal(t, tc.preserved, res.Header.Values(preservedCookiesHeader))
+
+			_, err = restorePreservedCookies(res, nil, nil)
+			require.NoError(t, err)
+			assert.Empty(t, res.Header.Values(preservedCookiesHeader))
+			assert.Equal(t, append(tc.rewritten, tc.preserved...), res.Header.Values("Set-Cookie"))
+		})
+	}
+}
+
+func TestValidateCookieDomainMatch(t *testing.T) {
+	require.NoError(t, validateCookieDomainMatch(cookieDomainMatchAll))
+	require.NoError(t, validateCookieDomainMatch(cookieDomainMatchExact))
+	require.NoError(t, validateCookieDomainMatch(cookieDomainMatchSuffix))
+	require.Error(t, validateCookieDomainMatch("prefix"))
+}
+
+func TestFilterCookies(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Cookie", "ory_session_abc=session; _ga=tracking; app=state")
+
+	filterCookies(req, []string{"app", "csrf"})
+	assert.Equal(t, "app=state", req.Header.Get("Cookie"))
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Cookie", "_ga=tracking")
+	filterCookies(req, []string{"app"})
+	assert.Empty(t, req.Header.Values("Cookie"))
+}
+
+func TestProxyUpstreamCookies(t *testing.T) {
+	ory, upstream := newHeaderEchoServer(t, "Cookie"), newHeaderEchoServer(t, "Cookie")
+	header := http.Header{"Cookie": {"ory_session_abc=session; _ga=tracking; app=state; csrf=token"}}
+
+	for _, tc := range []struct {
+		name, target, expected string
+		allowlist              []string
+	}{
+		{name: "only allowed cookies reach the application", target: "/", allowlist: []string{"app", "csrf"}, expected: "Cookie=app=state; csrf=token\n"},
+		{name: "no allowed cookie", target: "/", allowlist: []string{"other"}, expected: "Cookie=\n"},
+		{name: "all cookies without an allowlist", target: "/", expected: "Cookie=ory_session_abc=session; _ga=tracking; app=state; csrf=token\n"},
+		{name: "all cookies reach Ory", target: "/.ory/sessions/whoami", allowlist: []string{"app"}, expected: "Cookie=ory_session_abc=session; _ga=tracking; app=state; csrf=token\n"},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			conf := &config{pathPrefix: "/.ory", oryURL: ory, upstreamCookies: tc.allowlist}
+			assert.Equal(t, tc.expected, serveProxyWithHeader(t, conf, upstream, tc.target, header))
+		})
+	}
+}
+
+func TestValidateCookieDomain(t *testing.T) {
+	for _, domain := range []string{"", "app.localhost", ".example.org"} {
+		assert.NoError(t, validateCookieDomain(domain), domain)
+	}
+	for _, domain := range []string{"http://app.localhost", "app.localhost:4000", "app.localhost/path"} {
+		assert.ErrorContains(t, validateCookieDomain(domain), "--cookie-domain", domain)
+	}
+}
+
+func TestProxyCookieDomain(t *testing.T) {
+	ory := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "ory_session_abc", Value: "a", Domain: r.Host})
+	}))
+	t.Cleanup(ory.Close)
+	oryURL := urlx.ParseOrPanic(ory.URL)
+
+	for _, tc := range []struct {
+		cookieDomain, expected string
+	}{
+		{cookieDomain: "", expected: ""},
+		{cookieDomain: "app.localhost", expected: "app.localhost"},
+	} {
+		t.Run("domain="+tc.cookieDomain, func(t *testing.T) {
+			conf := &config{pathPrefix: "/.ory", oryURL: oryURL, cookieDomain: tc.cookieDomain, publicURL: urlx.ParseOrPanic("http://localhost:4000")}
+			ts := httptest.NewServer(newProxyHandler(conf, logrusx.New("test", "test"), oryURL, ""))
+			t.Cleanup(ts.Close)
+
+			res, err := ts.Client().Get(ts.URL + "/.ory/self-service/login/browser")
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			cookies := res.Cookies()
+			require.Len(t, cookies, 1)
+			assert.Equal(t, tc.expected, cookies[0].Domain)
+		})
+	}
+}
+
+func TestRewriteSameSite(t *testing.T) {
+	newResponse := func() *http.Response {
+		rec := httptest.NewRecorder()
+		http.SetCookie(rec, &http.Cookie{Name: "a", Value: "1", SameSite: http.SameSiteLaxMode})
+		http.SetCookie(rec, &http.Cookie{Name: "b", Value: "2"})
+		return rec.Result()
+	}
+
+	for _, tc := range []struct {
+		value    string
+		expected []string
+	}{
+		{value: "", expected: []string{"a=1; SameSite=Lax", "b=2"}},
+		{value: "strict", expected: []string{"a=1; SameSite=Strict", "b=2; SameSite=Strict"}},
+		{value: "Lax", expected: []string{"a=1; SameSite=Lax", "b=2; SameSite=Lax"}},
+		{value: "none", expected: []string{"a=1; Secure; SameSite=None", "b=2; Secure; SameSite=None"}},
+	} {
+		t.Run("value="+tc.value, func(t *testing.T) {
+			mode, err := parseCookieSameSite(tc.value)
+			require.NoError(t, err)
+
+			res := newResponse()
+			_, err = rewriteSameSite(mode)(res, nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, res.Header.Values("Set-Cookie"))
+		})
+	}
+
+	t.Run("case=keeps attributes and cookies the standard library does not parse", func(t *testing.T) {
+		res := &http.Response{Header: http.Header{"Set-Cookie": {
+			"a=1; Path=/; Partitioned; Priority=High; SameSite=Lax",
+			`b c=2; HttpOnly`,
+			"d=3; secure; samesite=strict",
+		}}}
+		require.Len(t, res.Cookies(), 2, "the standard library rejects the cookie name with a space")
+
+		_, err := rewriteSameSite(http.SameSiteNoneMode)(res, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"a=1; Path=/; Partitioned; Priority=High; Secure; SameSite=None",
+			"b c=2; HttpOnly; Secure; SameSite=None",
+			"d=3; secure; SameSite=None",
+		}, res.Header.Values("Set-Cookie"))
+	})
+
+	t.Run("case=leaves empty lines alone", func(t *testing.T) {
+		res := &http.Response{Header: http.Header{"Set-Cookie": {"a=1", ""}}}
+		_, err := rewriteSameSite(http.SameSiteStrictMode)(res, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a=1; SameSite=Strict", ""}, res.Header.Values("Set-Cookie"))
+	})
+
+	_, err := parseCookieSameSite("always")
+	assert.ErrorContains(t, err, "--cookie-samesite")
+}
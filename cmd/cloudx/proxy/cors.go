@@ -0,0 +1,75 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/rs/cors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/corsx"
+	"github.com/ory/x/flagx"
+	"github.com/ory/x/logrusx"
+)
+
+// corsOriginsFromFlags merges --allowed-cors-origins with its alias --cors-allowed-origins.
+func corsOriginsFromFlags(cmd *cobra.Command) []string {
+	return append(flagx.MustGetStringSlice(cmd, CORSAllowedOriginsFlag), flagx.MustGetStringSlice(cmd, CORSFlag)...)
+}
+
+// allowsAnyOriginWithCredentials reports whether the wildcard origin is combined with credentials, which lets every
+// site make credentialed requests through the proxy.
+func allowsAnyOriginWithCredentials(conf *config) bool {
+	if !conf.corsAllowCredentials {
+		return false
+	}
+	for _, origin := range conf.corsOrigins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// warnCORS warns if any site may make credentialed requests through the proxy. In development mode this is expected.
+func warnCORS(conf *config, l *logrusx.Logger) {
+	if conf.isDev || !allowsAnyOriginWithCredentials(conf) {
+		return
+	}
+	l.Warnf("The allowed CORS origins include \"*\" and --%s is enabled, so every website can make requests with the user's cookies through the proxy and read the responses. List the allowed origins explicitly or pass --%s=false.", CORSCredentialsFlag, CORSCredentialsFlag)
+}
+
+// newCORS answers preflight requests without passing them to Ory or the application. Browsers reject a wildcard
+// origin for requests with credentials, so the request's origin is echoed instead whenever credentials are allowed.
+func newCORS(conf *config) *cors.Cors {
+	allowAll := conf.isDev || allowsAnyOriginWithCredentials(conf)
+
+	origins := conf.corsOrigins
+	var originFunc func(r *http.Request, origin string) bool
+	if allowAll {
+		// A wildcard in the allowed origins always results in a wildcard response, so it must not be passed on.
+		origins = nil
+		originFunc = func(r *http.Request, origin string) bool {
+			return true
+		}
+	}
+
+	methods := conf.corsMethods
+	if len(methods) == 0 {
+		methods = corsx.CORSDefaultAllowedMethods
+	}
+
+	return cors.New(cors.Options{
+		AllowedOrigins:         origins,
+		AllowOriginRequestFunc: originFunc,
+		AllowedMethods:         methods,
+		AllowedHeaders:         append(corsx.CORSRequestHeadersSafelist, corsx.CORSRequestHeadersExtended...),
+		ExposedHeaders:         corsx.CORSResponseHeadersSafelist,
+		MaxAge:                 0,
+		AllowCredentials:       conf.corsAllowCredentials,
+		OptionsPassthrough:     false,
+		Debug:                  conf.isDebug,
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+)
+
+func TestCORS(t *testing.T) {
+	serve := func(t *testing.T, conf *config, method, origin string) (*httptest.ResponseRecorder, bool) {
+		var called bool
+		h := newCORS(conf).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+
+		r := httptest.NewRequest(method, "/.ory/sessions/whoami", nil)
+		r.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			r.Header.Set("Access-Control-Request-Method", "GET")
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec, called
+	}
+
+	t.Run("case=preflight is answered directly", func(t *testing.T) {
+		rec, called := serve(t, &config{corsOrigins: []string{"https://app.example.com"}, corsAllowCredentials: true}, http.MethodOptions, "https://app.example.com")
+		assert.False(t, called)
+		assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("case=wildcard echoes the origin with credentials", func(t *testing.T) {
+		rec, called := serve(t, &config{corsOrigins: []string{"*"}, corsAllowCredentials: true}, http.MethodGet, "https://app.example.com")
+		assert.True(t, called)
+		assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("case=wildcard without credentials", func(t *testing.T) {
+		rec, _ := serve(t, &config{corsOrigins: []string{"*"}}, http.MethodGet, "https://app.example.com")
+		assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("case=unknown origin", func(t *testing.T) {
+		rec, _ := serve(t, &config{corsOrigins: []string{"https://app.example.com"}, corsAllowCredentials: true}, http.MethodGet, "https://evil.example.com")
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("case=allowed methods", func(t *testing.T) {
+		conf := &config{corsOrigins: []string{"https://app.example.com"}, corsMethods: []string{"GET"}}
+
+		rec, _ := serve(t, conf, http.MethodOptions, "https://app.example.com")
+		assert.Equal(t, "GET", rec.Header().Get("Access-Control-Allow-Methods"))
+
+		r := httptest.NewRequest(http.MethodOptions, "/", nil)
+		r.Header.Set("Origin", "https://app.example.com")
+		r.Header.Set("Access-Control-Request-Method", "DELETE")
+		rec = httptest.NewRecorder()
+		newCORS(conf).Handler(http.NotFoundHandler()).ServeHTTP(rec, r)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func TestWarnCORS(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		conf *config
+		warn bool
+	}{
+		{name: "wildcard with credentials", conf: &config{corsOrigins: []string{"https://app.example.com", "*"}, corsAllowCredentials: true}, warn: true},
+		{name: "wildcard with credentials in development", conf: &config{corsOrigins: []string{"*"}, corsAllowCredentials: true, isDev: true}},
+		{name: "wildcard without credentials", conf: &config{corsOrigins: []string{"*"}}},
+		{name: "explicit origins with credentials", conf: &config{corsOrigins: []string{"https://app.example.com"}, corsAllowCredentials: true}},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			hook := test.NewGlobal()
+			warnCORS(tc.conf, logrusx.New("test", "test", logrusx.WithHook(hook)))
+
+			if !tc.warn {
+				assert.Empty(t, hook.AllEntries())
+				return
+			}
+			require.Len(t, hook.AllEntries(), 1)
+			assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+			assert.Contains(t, hook.LastEntry().Message, "--"+CORSCredentialsFlag)
+		})
+	}
+}
+
+func TestCORSFlagAlias(t *testing.T) {
+	for _, newCmd := range []func(string, string) *cobra.Command{NewProxyCommand, NewTunnelCommand} {
+		cmd := newCmd("ory", "test")
+		require.NoError(t, cmd.ParseFlags([]string{"--" + CORSFlag, "https://a.example.com", "--" + CORSAllowedOriginsFlag, "https://b.example.com"}))
+		assert.Empty(t, cmd.Flags().Lookup(CORSFlag).Deprecated)
+		assert.ElementsMatch(t, []string{"https://a.example.com", "https://b.example.com"}, corsOriginsFromFlags(cmd))
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	credentialJWT          = "jwt"
+	credentialSessionToken = "session-token"
+	credentialNone         = "none"
+)
+
+// credentialRule maps requests whose path starts with prefix to the credential injected into them.
+type credentialRule struct {
+	prefix     string
+	credential string
+}
+
+func parseCredentialRules(values []string, noJWT bool) ([]credentialRule, error) {
+	rules := make([]credentialRule, 0, len(values))
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], "/") {
+			return nil, errors.Errorf("--%s must be in format of `/path/prefix=credential` but got: %s", CredentialFlag, v)
+		}
+
+		switch parts[1] {
+		case credentialJWT:
+			if noJWT {
+				return nil, errors.Errorf("--%s %s can not inject a JSON Web Token when --%s is set", CredentialFlag, v, WithoutJWTFlag)
+			}
+		case credentialSessionToken, credentialNone:
+		default:
+			return nil, errors.Errorf("--%s credential must be one of %q, %q, or %q but got: %s", CredentialFlag, credentialJWT, credentialSessionToken, credentialNone, parts[1])
+		}
+
+		rules = append(rules, credentialRule{prefix: parts[0], credential: parts[1]})
+	}
+	return rules, nil
+}
+
+// credentialFor returns the credential to inject for the given path. Ory's own paths never receive a credential,
+// otherwise the rule with the longest matching prefix wins. Without a matching rule, a JSON Web Token is injected
+// unless --no-jwt is set.
+func (c *config) credentialFor(path string) string {
+	if len(c.pathPrefix) > 0 && strings.HasPrefix(path, c.pathPrefix) {
+		return credentialNone
+	}
+
+	var match *credentialRule
+	for k, rule := range c.credentials {
+		if strings.HasPrefix(path, rule.prefix) && (match == nil || len(rule.prefix) > len(match.prefix)) {
+			match = &c.credentials[k]
+		}
+	}
+	if match != nil {
+		return match.credential
+	}
+
+	if c.noJWT {
+		return credentialNone
+	}
+	return credentialJWT
+}
+
+// sessionTokenFromRequest returns the Ory Session Token the request was authenticated with, in this order: the
+// X-Session-Token header, the header named by the non-empty header argument, and a bearer token in the Authorization
+// header. Ory Session Cookies are never returned, so that browser sessions are not turned into bearer tokens for the
+// application.
+func sessionTokenFromRequest(r *http.Request, header string) string {
+	if token := r.Header.Get("X-Session-Token"); token != "" {
+		return token
+	}
+
+	if len(header) > 0 {
+		if token := r.Header.Get(header); token != "" {
+			return token
+		}
+	}
+
+	if token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); token != r.Header.Get("Authorization") {
+		return token
+	}
+
+	return ""
+}
+
+// hasSessionCredentials reports whether the request carries an Ory Session Token or an Ory Session Cookie.
+func hasSessionCredentials(r *http.Request, header string) bool {
+	if len(sessionTokenFromRequest(r, header)) > 0 {
+		return true
+	}
+
+	for _, c := range r.Cookies() {
+		if strings.HasPrefix(c.Name, "ory_session_") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCredentialRules(t *testing.T) {
+	rules, err := parseCredentialRules([]string{"/api=session-token", "/=none", "/app=jwt"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []credentialRule{
+		{prefix: "/api", credential: credentialSessionToken},
+		{prefix: "/", credential: credentialNone},
+		{prefix: "/app", credential: credentialJWT},
+	}, rules)
+
+	for _, v := range []string{"/api", "api=jwt", "/api=cookie"} {
+		_, err := parseCredentialRules([]string{v}, false)
+		assert.Error(t, err, v)
+	}
+
+	_, err = parseCredentialRules([]string{"/app=jwt"}, true)
+	assert.Error(t, err)
+}
+
+func TestSessionTokenFromRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	assert.Empty(t, sessionTokenFromRequest(r, ""))
+
+	r.AddCookie(&http.Cookie{Name: "ory_session_slug", Value: "from-cookie"})
+	assert.Empty(t, sessionTokenFromRequest(r, ""), "session cookies are not session tokens")
+
+	r.Header.Set("Authorization", "Bearer from-authorization")
+	assert.Equal(t, "from-authorization", sessionTokenFromRequest(r, "X-App-Session"))
+
+	r.Header.Set("X-App-Session", "from-custom-header")
+	assert.Equal(t, "from-custom-header", sessionTokenFromRequest(r, "X-App-Session"), "the custom header wins over Authorization")
+	assert.Equal(t, "from-authorization", sessionTokenFromRequest(r, ""), "the custom header is only read if configured")
+
+	r.Header.Set("X-Session-Token", "from-header")
+	assert.Equal(t, "from-header", sessionTokenFromRequest(r, "X-App-Session"), "X-Session-Token wins over all others")
+}
+
+func TestHasSessionCredentials(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	assert.False(t, hasSessionCredentials(r, ""))
+
+	r.AddCookie(&http.Cookie{Name: "other", Value: "a"})
+	assert.False(t, hasSessionCredentials(r, ""))
+
+	r.AddCookie(&http.Cookie{Name: "ory_session_slug", Value: "from-cookie"})
+	assert.True(t, hasSessionCredentials(r, ""))
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-App-Session", "from-custom-header")
+	assert.False(t, hasSessionCredentials(r, ""))
+	assert.True(t, hasSessionCredentials(r, "X-App-Session"))
+}
+
+func TestCredentialFor(t *testing.T) {
+	conf := &config{
+		pathPrefix: "/.ory",
+		credentials: []credentialRule{
+			{prefix: "/", credential: credentialSessionToken},
+			{prefix: "/api/public", credential: credentialNone},
+			{prefix: "/api", credential: credentialJWT},
+		},
+	}
+
+	for path, expected := range map[string]string{
+		"/":                  credentialSessionToken,
+		"/dashboard":         credentialSessionToken,
+		"/api/private":       credentialJWT,
+		"/api/public/assets": credentialNone,
+		"/.ory/sessions":     credentialNone,
+	} {
+		assert.Equal(t, expected, conf.credentialFor(path), "the longest prefix wins independent of the flag order, Ory's paths never get a credential: %s", path)
+	}
+
+	assert.Equal(t, credentialJWT, (&config{}).credentialFor("/"), "without rules a JSON Web Token is injected")
+	assert.Equal(t, credentialNone, (&config{noJWT: true}).credentialFor("/"), "without rules and with --no-jwt nothing is injected")
+}
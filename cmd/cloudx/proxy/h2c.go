@@ -0,0 +1,48 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// h2cTransport sends requests to plain HTTP applications over HTTP/2 without TLS (h2c). Requests to Ory, to HTTPS
+// applications, which negotiate HTTP/2 on their own, and protocol upgrades, which HTTP/2 does not support, use the
+// regular transport.
+type h2cTransport struct {
+	http.RoundTripper
+	h2c     *http2.Transport
+	oryHost string
+}
+
+func newH2CTransport(conf *config, base http.RoundTripper) *h2cTransport {
+	dial := (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	if len(conf.resolve) > 0 {
+		dial = resolvingDialContext(conf.resolve)
+	}
+
+	return &h2cTransport{
+		RoundTripper: base,
+		h2c: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dial(ctx, network, addr)
+			},
+		},
+		oryHost: conf.oryURL.Host,
+	}
+}
+
+func (t *h2cTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "http" || req.URL.Host == t.oryHost || isUpgrade(req) {
+		return t.RoundTripper.RoundTrip(req)
+	}
+	return t.h2c.RoundTrip(req)
+}
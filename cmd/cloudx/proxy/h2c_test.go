@@ -0,0 +1,58 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/ory/x/urlx"
+)
+
+func TestUpstreamHTTP2(t *testing.T) {
+	newProtoServer := func(t *testing.T) *httptest.Server {
+		ts := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, r.Proto)
+		}), &http2.Server{}))
+		t.Cleanup(ts.Close)
+		return ts
+	}
+	ory, upstream := newProtoServer(t), newProtoServer(t)
+
+	for _, tc := range []struct {
+		http2                    bool
+		expectedApp, expectedOry string
+	}{
+		{http2: false, expectedApp: "HTTP/1.1", expectedOry: "HTTP/1.1"},
+		{http2: true, expectedApp: "HTTP/2.0", expectedOry: "HTTP/1.1"},
+	} {
+		t.Run(fmt.Sprintf("http2=%v", tc.http2), func(t *testing.T) {
+			conf := &config{pathPrefix: "/.ory", oryURL: urlx.ParseOrPanic(ory.URL), upstreamHTTP2: tc.http2}
+			assert.Equal(t, tc.expectedApp, serveProxy(t, conf, urlx.ParseOrPanic(upstream.URL), "/"))
+			assert.Equal(t, tc.expectedOry, serveProxy(t, conf, urlx.ParseOrPanic(upstream.URL), "/.ory/sessions/whoami"))
+		})
+	}
+
+	t.Run("case=upgrades use http/1.1", func(t *testing.T) {
+		conf := &config{oryURL: urlx.ParseOrPanic("https://ory.example.org"), upstreamHTTP2: true}
+		c := &http.Client{Transport: newUpstreamTransport(conf)}
+
+		req, err := http.NewRequest("GET", upstream.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+
+		res, err := c.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, 1, res.ProtoMajor)
+	})
+}
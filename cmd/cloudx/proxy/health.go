@@ -0,0 +1,89 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+)
+
+// healthCheckTimeout caps how long the readiness check waits for the upstream.
+const healthCheckTimeout = 2 * time.Second
+
+// health serves /health/alive and /health/ready below the mount path. Both answer without checking the session or
+// calling the upstream, except that readiness checks the upstream with --health-check-upstream. Liveness keeps
+// answering while the proxy drains, readiness then reports 503.
+type health struct {
+	writer   herodot.Writer
+	client   *http.Client
+	upstream string
+	draining func() bool
+}
+
+func newHealth(conf *config, writer herodot.Writer) *health {
+	h := &health{writer: writer}
+	if conf.healthCheckUpstream {
+		h.client = &http.Client{
+			Transport: newUpstreamTransport(conf),
+			Timeout:   healthCheckTimeout,
+			// Redirects are answers, too.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		}
+		h.upstream = conf.upstream
+	}
+	return h
+}
+
+func (h *health) middleware(conf *config) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
+	alive, ready := path.Join("/", conf.pathPrefix, "/health/alive"), path.Join("/", conf.pathPrefix, "/health/ready")
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		switch r.URL.Path {
+		case alive:
+			h.alive(w, r)
+		case ready:
+			h.ready(w, r)
+		default:
+			next(w, r)
+		}
+	}
+}
+
+func (h *health) alive(w http.ResponseWriter, r *http.Request) {
+	h.writer.Write(w, r, map[string]string{"status": "ok"})
+}
+
+func (h *health) ready(w http.ResponseWriter, r *http.Request) {
+	if h.draining != nil && h.draining() {
+		h.writer.WriteCode(w, r, http.StatusServiceUnavailable, map[string]string{"status": "draining"})
+		return
+	}
+	if h.client != nil {
+		if err := h.checkUpstream(r.Context()); err != nil {
+			h.writer.WriteCode(w, r, http.StatusServiceUnavailable, map[string]string{"status": "upstream unreachable"})
+			return
+		}
+	}
+	h.writer.Write(w, r, map[string]string{"status": "ok"})
+}
+
+// checkUpstream only checks that the upstream answers. Any status code counts, as the application may not serve its
+// root path.
+func (h *health) checkUpstream(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, h.upstream, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return res.Body.Close()
+}
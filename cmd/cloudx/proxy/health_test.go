@@ -0,0 +1,88 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/logrusx"
+)
+
+// serveHealth serves the request with the health middleware, followed by the admin middleware if a is set. It
+// reports whether the request was forwarded past both.
+func serveHealth(t *testing.T, conf *config, a *admin, r *http.Request) (*httptest.ResponseRecorder, bool) {
+	writer := herodot.NewJSONWriter(logrusx.New("test", "test"))
+
+	var forwarded bool
+	next := func(http.ResponseWriter, *http.Request) { forwarded = true }
+
+	h := newHealth(conf, writer)
+	if a != nil {
+		h.draining = a.isDraining
+		adminMiddleware, forward := a.middleware(conf, writer), next
+		next = func(w http.ResponseWriter, r *http.Request) { adminMiddleware(w, r, forward) }
+	}
+
+	rec := httptest.NewRecorder()
+	h.middleware(conf)(rec, r, next)
+	return rec, forwarded
+}
+
+func TestHealth(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(upstream.Close)
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	for _, tc := range []struct {
+		name           string
+		conf           *config
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "alive", conf: &config{pathPrefix: "/.ory"}, path: "/.ory/health/alive", expectedStatus: http.StatusOK, expectedBody: `{"status":"ok"}`},
+		{name: "ready", conf: &config{pathPrefix: "/.ory"}, path: "/.ory/health/ready", expectedStatus: http.StatusOK, expectedBody: `{"status":"ok"}`},
+		{name: "ready without mount path", conf: &config{}, path: "/health/ready", expectedStatus: http.StatusOK, expectedBody: `{"status":"ok"}`},
+		{name: "ready with reachable upstream", conf: &config{pathPrefix: "/.ory", upstream: upstream.URL, healthCheckUpstream: true}, path: "/.ory/health/ready", expectedStatus: http.StatusOK, expectedBody: `{"status":"ok"}`},
+		{name: "ready with unreachable upstream", conf: &config{pathPrefix: "/.ory", upstream: down.URL, healthCheckUpstream: true}, path: "/.ory/health/ready", expectedStatus: http.StatusServiceUnavailable, expectedBody: `{"status":"upstream unreachable"}`},
+		{name: "alive with unreachable upstream", conf: &config{pathPrefix: "/.ory", upstream: down.URL, healthCheckUpstream: true}, path: "/.ory/health/alive", expectedStatus: http.StatusOK, expectedBody: `{"status":"ok"}`},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			rec, forwarded := serveHealth(t, tc.conf, nil, httptest.NewRequest("GET", tc.path, nil))
+			assert.False(t, forwarded, "health checks must not reach the upstream handler")
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestHealthWhileDraining(t *testing.T) {
+	conf := &config{pathPrefix: "/.ory"}
+	a := &admin{token: "secret"}
+
+	drain := httptest.NewRequest("POST", "/.ory/proxy/admin/drain", nil)
+	drain.Header.Set("Authorization", "Bearer secret")
+	rec, _ := serveHealth(t, conf, a, drain)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	rec, _ = serveHealth(t, conf, a, httptest.NewRequest("GET", "/.ory/health/alive", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "liveness must pass while draining")
+
+	rec, _ = serveHealth(t, conf, a, httptest.NewRequest("GET", "/.ory/health/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"status":"draining"}`, rec.Body.String())
+
+	rec, forwarded := serveHealth(t, conf, a, httptest.NewRequest("GET", "/dashboard", nil))
+	assert.False(t, forwarded)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -0,0 +1,64 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/urlx"
+)
+
+var landingPage = template.Must(template.New("landing").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Ory Proxy</title>
+</head>
+<body>
+  <h1>Ory Proxy is running</h1>
+  <p>Your application at <code>{{ .Upstream }}</code> is not reachable yet. Start it and reload this page.</p>
+  <ul>
+    <li><a href="{{ .Login }}">Sign in</a></li>
+    <li><a href="{{ .Registration }}">Sign up</a></li>
+    {{ if .JWKS }}<li><a href="{{ .JWKS }}">JSON Web Key Set</a></li>{{ end }}
+  </ul>
+</body>
+</html>
+`))
+
+// upstreamErrorHandler serves the landing page instead of a bare 502 when the application can not be reached at `/`.
+func upstreamErrorHandler(conf *config, l *logrusx.Logger, upstream *url.URL) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		if !errors.Is(err, context.Canceled) {
+			l.WithError(err).Warn("Unable to proxy the request to the upstream.")
+		}
+
+		if !conf.landingPage || r.URL.Host != upstream.Host || r.URL.Path != "/" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		oryURL := urlx.AppendPaths(conf.publicURL, conf.pathPrefix)
+		data := struct {
+			Upstream, Login, Registration, JWKS string
+		}{
+			Upstream:     upstream.String(),
+			Login:        urlx.AppendPaths(oryURL, "/self-service/login/browser").String(),
+			Registration: urlx.AppendPaths(oryURL, "/self-service/registration/browser").String(),
+		}
+		if !conf.noJWT {
+			data.JWKS = urlx.AppendPaths(oryURL, "/jwks.json").String()
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusBadGateway)
+		_ = landingPage.Execute(w, data)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+)
+
+func TestUpstreamErrorHandler(t *testing.T) {
+	publicURL, err := url.Parse("http://localhost:4000")
+	require.NoError(t, err)
+	upstream, err := url.Parse("http://localhost:3000")
+	require.NoError(t, err)
+
+	l := logrusx.New("test", "test")
+	serve := func(conf *config, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		upstreamErrorHandler(conf, l, upstream)(rec, httptest.NewRequest("GET", target, nil), errors.New("connection refused"))
+		return rec
+	}
+
+	t.Run("case=landing page", func(t *testing.T) {
+		rec := serve(&config{landingPage: true, publicURL: publicURL, pathPrefix: "/.ory"}, "http://localhost:3000/")
+		assert.Equal(t, http.StatusBadGateway, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, rec.Body.String(), "http://localhost:4000/.ory/self-service/login/browser")
+		assert.Contains(t, rec.Body.String(), "http://localhost:4000/.ory/self-service/registration/browser")
+		assert.Contains(t, rec.Body.String(), "http://localhost:4000/.ory/jwks.json")
+	})
+
+	t.Run("case=no jwks without jwt", func(t *testing.T) {
+		rec := serve(&config{landingPage: true, noJWT: true, publicURL: publicURL, pathPrefix: "/.ory"}, "http://localhost:3000/")
+		assert.NotContains(t, rec.Body.String(), "jwks.json")
+	})
+
+	for name, tc := range map[string]struct {
+		conf   *config
+		target string
+	}{
+		"disabled":     {conf: &config{publicURL: publicURL}, target: "http://localhost:3000/"},
+		"other path":   {conf: &config{landingPage: true, publicURL: publicURL}, target: "http://localhost:3000/dashboard"},
+		"ory upstream": {conf: &config{landingPage: true, publicURL: publicURL}, target: "https://example.projects.oryapis.com/"},
+	} {
+		t.Run("case="+name, func(t *testing.T) {
+			rec := serve(tc.conf, tc.target)
+			assert.Equal(t, http.StatusBadGateway, rec.Code)
+			assert.Empty(t, rec.Body.String())
+		})
+	}
+
+	t.Run("case=logs with the proxy logger", func(t *testing.T) {
+		hook := test.NewGlobal()
+		l := logrusx.New("test", "test", logrusx.WithHook(hook))
+		handler := upstreamErrorHandler(&config{publicURL: publicURL}, l, upstream)
+
+		handler(httptest.NewRecorder(), httptest.NewRequest("GET", "http://localhost:3000/", nil), errors.New("connection refused"))
+		require.Len(t, hook.AllEntries(), 1)
+		assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+		assert.Equal(t, "connection refused", hook.LastEntry().Data[logrus.ErrorKey].(map[string]interface{})["message"])
+		assert.Equal(t, "Unable to proxy the request to the upstream.", hook.LastEntry().Message)
+
+		hook.Reset()
+		handler(httptest.NewRecorder(), httptest.NewRequest("GET", "http://localhost:3000/", nil), errors.WithStack(context.Canceled))
+		assert.Empty(t, hook.AllEntries(), "canceled requests are not logged")
+	})
+}
@@ -0,0 +1,42 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ory/x/logrusx"
+)
+
+var logFormats = []string{"text", "json"}
+
+// loggerOptions returns the logger options for --log-format and --log-level. Empty values fall back to the
+// LOG_FORMAT and LOG_LEVEL environment variables, and then to text and info.
+func loggerOptions(format, level string) ([]logrusx.Option, error) {
+	var opts []logrusx.Option
+
+	if len(format) > 0 {
+		var known bool
+		for _, f := range logFormats {
+			known = known || f == format
+		}
+		if !known {
+			return nil, errors.Errorf("the value of --%s must be one of %s but got: %s", LogFormatFlag, strings.Join(logFormats, ", "), format)
+		}
+		opts = append(opts, logrusx.ForceFormat(format))
+	}
+
+	if len(level) > 0 {
+		l, err := logrus.ParseLevel(level)
+		if err != nil {
+			return nil, errors.Errorf("the value of --%s must be one of trace, debug, info, warn, error, fatal, or panic but got: %s", LogLevelFlag, level)
+		}
+		opts = append(opts, logrusx.ForceLevel(l))
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,55 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+)
+
+func TestLoggerOptions(t *testing.T) {
+	t.Run("case=applies the format and level", func(t *testing.T) {
+		opts, err := loggerOptions("json", "debug")
+		require.NoError(t, err)
+
+		l := logrusx.New("ory/proxy", "test", opts...)
+		assert.IsType(t, &logrus.JSONFormatter{}, l.Logger.Formatter)
+		assert.Equal(t, logrus.DebugLevel, l.Logger.GetLevel())
+	})
+
+	t.Run("case=uses text", func(t *testing.T) {
+		opts, err := loggerOptions("text", "warn")
+		require.NoError(t, err)
+
+		l := logrusx.New("ory/proxy", "test", opts...)
+		assert.IsType(t, &logrus.TextFormatter{}, l.Logger.Formatter)
+		assert.Equal(t, logrus.WarnLevel, l.Logger.GetLevel())
+	})
+
+	t.Run("case=falls back to the environment", func(t *testing.T) {
+		t.Setenv("LOG_FORMAT", "json")
+		t.Setenv("LOG_LEVEL", "error")
+
+		opts, err := loggerOptions("", "")
+		require.NoError(t, err)
+		assert.Empty(t, opts)
+
+		l := logrusx.New("ory/proxy", "test", opts...)
+		assert.IsType(t, &logrus.JSONFormatter{}, l.Logger.Formatter)
+		assert.Equal(t, logrus.ErrorLevel, l.Logger.GetLevel())
+	})
+
+	t.Run("case=rejects unknown values", func(t *testing.T) {
+		_, err := loggerOptions("gelf", "")
+		assert.ErrorContains(t, err, "--log-format")
+
+		_, err = loggerOptions("", "loud")
+		assert.ErrorContains(t, err, "--log-level")
+	})
+}
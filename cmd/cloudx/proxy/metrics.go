@@ -0,0 +1,104 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/urfave/negroni"
+)
+
+// metrics collects the Prometheus metrics served with --metrics-port. All methods are safe to call on nil, which
+// means metrics are disabled.
+type metrics struct {
+	registry             *prometheus.Registry
+	requests             *prometheus.CounterVec
+	upstreamDuration     *prometheus.HistogramVec
+	sessionCheckFailures prometheus.Counter
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ory_proxy_requests_total",
+			Help: "The number of requests handled by the proxy.",
+		}, []string{"method", "code"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ory_proxy_upstream_duration_seconds",
+			Help:    "The response times of the application and Ory. The code is error if no response was received.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"upstream", "code"}),
+		sessionCheckFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ory_proxy_session_check_failures_total",
+			Help: "The number of session checks that failed because Ory could not be reached or answered with an error.",
+		}),
+	}
+	m.registry.MustRegister(m.requests, m.upstreamDuration, m.sessionCheckFailures)
+	return m
+}
+
+func newMetricsServer(host string, port int, m *metrics) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+
+	return &http.Server{
+		Addr:    net.JoinHostPort(host, strconv.Itoa(port)),
+		Handler: mux,
+	}
+}
+
+// middleware counts every request by method and status code.
+func (m *metrics) middleware(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if m == nil {
+		next(w, r)
+		return
+	}
+
+	nrw, ok := w.(negroni.ResponseWriter)
+	if !ok {
+		nrw = negroni.NewResponseWriter(w)
+	}
+	next(nrw, r)
+
+	status := nrw.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	m.requests.WithLabelValues(r.Method, strconv.Itoa(status)).Inc()
+}
+
+func (m *metrics) sessionCheckFailed() {
+	if m != nil {
+		m.sessionCheckFailures.Inc()
+	}
+}
+
+// metricsTransport observes the response time of every round trip to the application or to Ory.
+type metricsTransport struct {
+	http.RoundTripper
+	m       *metrics
+	oryHost string
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	upstream := "application"
+	if req.URL.Host == t.oryHost {
+		upstream = "ory"
+	}
+
+	start := time.Now()
+	res, err := t.RoundTripper.RoundTrip(req)
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(res.StatusCode)
+	}
+	t.m.upstreamDuration.WithLabelValues(upstream, code).Observe(time.Since(start).Seconds())
+	return res, err
+}
@@ -0,0 +1,97 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/logrusx"
+)
+
+func scrapeMetrics(t *testing.T, m *metrics) string {
+	rec := httptest.NewRecorder()
+	newMetricsServer("", 0, m).Handler.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	t.Run("case=disabled metrics are a no-op", func(t *testing.T) {
+		var m *metrics
+		called := false
+		m.middleware(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), func(http.ResponseWriter, *http.Request) { called = true })
+		assert.True(t, called)
+		m.sessionCheckFailed()
+	})
+
+	t.Run("case=counts requests", func(t *testing.T) {
+		m := newMetrics()
+		n := negroni.New()
+		n.UseFunc(m.middleware)
+		n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/missing" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		})
+
+		for _, p := range []string{"/", "/", "/missing"} {
+			n.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", p, nil))
+		}
+
+		body := scrapeMetrics(t, m)
+		assert.Contains(t, body, `ory_proxy_requests_total{code="200",method="GET"} 2`)
+		assert.Contains(t, body, `ory_proxy_requests_total{code="404",method="GET"} 1`)
+	})
+
+	t.Run("case=observes upstream response times", func(t *testing.T) {
+		m := newMetrics()
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		t.Cleanup(ts.Close)
+		u, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+
+		hc := &http.Client{Transport: &metricsTransport{RoundTripper: http.DefaultTransport, m: m, oryHost: u.Host}}
+		res, err := hc.Get(ts.URL)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+
+		hc.Transport.(*metricsTransport).oryHost = "ory.example.com"
+		ts.Close()
+		_, err = hc.Get(ts.URL)
+		require.Error(t, err)
+
+		body := scrapeMetrics(t, m)
+		assert.Contains(t, body, `ory_proxy_upstream_duration_seconds_count{code="502",upstream="ory"} 1`)
+		assert.Contains(t, body, `ory_proxy_upstream_duration_seconds_count{code="error",upstream="application"} 1`)
+	})
+
+	t.Run("case=counts failed session checks", func(t *testing.T) {
+		ws := newWhoamiServer(t, testSession)
+		ws.Close()
+
+		conf := &config{pathPrefix: "/.ory", metrics: newMetrics()}
+		l := logrusx.New("test", "test")
+		signer, keys, err := newSigner(l, conf)
+		require.NoError(t, err)
+
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Cookie", "ory_session_abc=secret")
+		checkOry(conf, l, herodot.NewJSONWriter(l), keys, signer, ws.url)(httptest.NewRecorder(), r, func(http.ResponseWriter, *http.Request) {})
+
+		assert.Contains(t, scrapeMetrics(t, conf.metrics), "ory_proxy_session_check_failures_total 1")
+	})
+}
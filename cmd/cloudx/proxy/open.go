@@ -0,0 +1,110 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/shlex"
+	"github.com/pkg/browser"
+	"github.com/pkg/errors"
+)
+
+// openURL opens the URL in the default browser using open on macOS, xdg-open and friends on Linux and BSD, and
+// rundll32 on Windows.
+func openURL(url string) error {
+	return browser.OpenURL(url)
+}
+
+// parseOpenCommand splits the command of --open-cmd into its arguments, honoring shell quotes.
+func parseOpenCommand(command string) ([]string, error) {
+	if len(strings.TrimSpace(command)) == 0 {
+		return nil, nil
+	}
+
+	args, err := shlex.Split(command)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse --%s", OpenCmdFlag)
+	}
+	return args, nil
+}
+
+// openWith returns a function which opens URLs by running the command with the URL as its last argument. Without a
+// command it opens the default browser.
+func openWith(args []string) func(string) error {
+	if len(args) == 0 {
+		return openURL
+	}
+
+	return func(url string) error {
+		// #nosec G204 - this is ok, the command was set by the user starting the proxy
+		cmd := exec.Command(args[0], append(args[1:len(args):len(args)], url)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return errors.Wrapf(err, "unable to run --%s: %s", OpenCmdFlag, bytes.TrimSpace(out))
+		}
+		return nil
+	}
+}
+
+// readyURL returns the URL of the JSON Web Key Set served by the proxy listening on addr. Clients can not connect to
+// the unspecified address on every platform, Windows for example, so the loopback address is used unless the proxy is
+// bound to a specific address.
+func readyURL(scheme string, addr net.Addr, pathPrefix string) string {
+	host, port := "127.0.0.1", 0
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		port = tcp.Port
+		if tcp.IP != nil && !tcp.IP.IsUnspecified() {
+			host = tcp.IP.String()
+		}
+	}
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(port)) + path.Join("/", pathPrefix, "jwks.json")
+}
+
+// openWhenReady opens the URL once the proxy answers the request to readyURL and the delay has passed, so that the
+// browser never shows a connection error while the proxy is still starting. Any response counts, because the proxy is
+// serving once it responds at all.
+func openWhenReady(ctx context.Context, readyURL string, delay time.Duration, url string, open func(string) error) error {
+	hc := &http.Client{
+		Timeout: time.Second,
+		// #nosec G402 - this only checks that the proxy itself is up, which may use a self-signed certificate
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	defer hc.CloseIdleConnections()
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, "GET", readyURL, nil)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		res, err := hc.Do(req)
+		if err == nil {
+			_ = res.Body.Close()
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(err, "the proxy did not answer at %s", readyURL)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	case <-time.After(delay):
+	}
+
+	return open(url)
+}
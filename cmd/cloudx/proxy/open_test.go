@@ -0,0 +1,184 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenWhenReady(t *testing.T) {
+	t.Run("case=opens only after the server accepts connections", func(t *testing.T) {
+		// Reserve a free port, then release it so nothing listens until the server starts below.
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+
+		var serving int32
+		opened := make(chan string, 1)
+		go func() {
+			_ = openWhenReady(context.Background(), "http://"+addr+"/.ory/jwks.json", 0, "http://"+addr, func(url string) error {
+				assert.EqualValues(t, 1, atomic.LoadInt32(&serving), "the browser must not open before the server listens")
+				res, err := http.Get(url)
+				if assert.NoError(t, err) {
+					_ = res.Body.Close()
+				}
+				opened <- url
+				return nil
+			})
+		}()
+
+		time.Sleep(200 * time.Millisecond)
+		select {
+		case <-opened:
+			t.Fatal("opened the browser before the server was started")
+		default:
+		}
+
+		ln, err = net.Listen("tcp", addr)
+		require.NoError(t, err)
+		atomic.StoreInt32(&serving, 1)
+		server := &http.Server{Handler: http.NotFoundHandler()}
+		go func() { _ = server.Serve(ln) }()
+		t.Cleanup(func() { _ = server.Close() })
+
+		select {
+		case url := <-opened:
+			assert.Equal(t, "http://"+addr, url)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the browser was not opened")
+		}
+	})
+
+	t.Run("case=waits for the delay", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		t.Cleanup(server.Close)
+
+		start := time.Now()
+		require.NoError(t, openWhenReady(context.Background(), server.URL, 100*time.Millisecond, "", func(string) error { return nil }))
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("case=waits until the server answers", func(t *testing.T) {
+		// The listener accepts connections before the server handles them, as it does while the proxy starts.
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		var serving int32
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			atomic.StoreInt32(&serving, 1)
+			_ = http.Serve(ln, http.NotFoundHandler())
+		}()
+		t.Cleanup(func() { _ = ln.Close() })
+
+		require.NoError(t, openWhenReady(context.Background(), "http://"+ln.Addr().String()+"/.ory/jwks.json", 0, "", func(string) error {
+			assert.EqualValues(t, 1, atomic.LoadInt32(&serving), "the browser must not open before the server answers")
+			return nil
+		}))
+	})
+
+	t.Run("case=accepts self-signed certificates", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.NotFoundHandler())
+		t.Cleanup(server.Close)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, openWhenReady(ctx, server.URL, 0, "", func(string) error { return nil }))
+	})
+
+	t.Run("case=gives up when the server never listens", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		err = openWhenReady(ctx, "http://"+addr, 0, "", func(string) error {
+			t.Fatal("must not open the browser")
+			return nil
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestReadyURL(t *testing.T) {
+	t.Run("case=uses the loopback address for the unspecified address", func(t *testing.T) {
+		ln, err := net.Listen("tcp", ":0")
+		require.NoError(t, err)
+		server := &http.Server{Handler: http.NotFoundHandler()}
+		go func() { _ = server.Serve(ln) }()
+		t.Cleanup(func() { _ = server.Close() })
+
+		port := ln.Addr().(*net.TCPAddr).Port
+		u := readyURL("http", ln.Addr(), "/.ory")
+		assert.Equal(t, fmt.Sprintf("http://127.0.0.1:%d/.ory/jwks.json", port), u)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, openWhenReady(ctx, u, 0, "", func(string) error { return nil }))
+	})
+
+	t.Run("case=uses the bound address", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = ln.Close() })
+
+		assert.Equal(t, "https://"+ln.Addr().String()+"/api/.ory/jwks.json", readyURL("https", ln.Addr(), "/api/.ory"))
+	})
+
+	t.Run("case=keeps IPv6 addresses in brackets", func(t *testing.T) {
+		assert.Equal(t, "http://[::1]:4000/.ory/jwks.json", readyURL("http", &net.TCPAddr{IP: net.IPv6loopback, Port: 4000}, "/.ory"))
+		assert.Equal(t, "http://127.0.0.1:4000/.ory/jwks.json", readyURL("http", &net.TCPAddr{IP: net.IPv6unspecified, Port: 4000}, "/.ory"))
+	})
+}
+
+func TestOpenCommand(t *testing.T) {
+	t.Run("case=parses quoted arguments", func(t *testing.T) {
+		args, err := parseOpenCommand(`firefox -P "dev profile"`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"firefox", "-P", "dev profile"}, args)
+
+		args, err = parseOpenCommand("  ")
+		require.NoError(t, err)
+		assert.Empty(t, args)
+
+		_, err = parseOpenCommand(`firefox "unterminated`)
+		assert.ErrorContains(t, err, "--open-cmd")
+	})
+
+	t.Run("case=appends the url", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("requires a POSIX shell")
+		}
+
+		out := filepath.Join(t.TempDir(), "opened")
+		require.NoError(t, openWith([]string{"sh", "-c", `echo "$1" > "$0"`, out})("http://localhost:4000"))
+
+		opened, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:4000\n", string(opened))
+	})
+
+	t.Run("case=reports failures", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("requires a POSIX shell")
+		}
+		assert.ErrorContains(t, openWith([]string{"sh", "-c", "echo nope >&2; exit 1"})("http://localhost:4000"), "nope")
+	})
+}
@@ -0,0 +1,40 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+
+	"github.com/ory/x/logrusx"
+)
+
+// prewarmWhoami calls whoami once without credentials, so that the first session check reuses an established
+// connection instead of paying for the TLS handshake. Failures only result in a warning.
+func prewarmWhoami(hc *retryablehttp.Client, target string, l *logrusx.Logger) {
+	l = l.WithField("url", target)
+
+	start := time.Now()
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		l.WithError(err).Warn("Unable to pre-warm the connection to Ory.")
+		return
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := hc.HTTPClient.Do(req)
+	if err != nil {
+		l.WithError(err).Warn("Unable to pre-warm the connection to Ory.")
+		return
+	}
+	defer res.Body.Close()
+
+	// The body must be read completely for the connection to be returned to the pool.
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	l.WithField("status", res.StatusCode).WithField("duration", time.Since(start)).Debug("Pre-warmed the connection to Ory.")
+}
@@ -0,0 +1,63 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+)
+
+func TestPrewarmWhoami(t *testing.T) {
+	t.Run("case=reachable", func(t *testing.T) {
+		var cookie string
+		ws := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cookie = r.Header.Get("Cookie")
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		t.Cleanup(ws.Close)
+
+		hook := test.NewGlobal()
+		l := logrusx.New("test", "test", logrusx.ForceLevel(logrus.DebugLevel), logrusx.WithHook(hook))
+		prewarmWhoami(newSessionClient(defaultSessionConfig()), ws.URL, l)
+
+		assert.Empty(t, cookie)
+		entry := hook.LastEntry()
+		require.NotNil(t, entry)
+		assert.Equal(t, logrus.DebugLevel, entry.Level)
+		assert.Equal(t, http.StatusUnauthorized, entry.Data["status"])
+	})
+
+	t.Run("case=unreachable", func(t *testing.T) {
+		ws := httptest.NewServer(http.NotFoundHandler())
+		ws.Close()
+
+		hook := test.NewGlobal()
+		l := logrusx.New("test", "test", logrusx.ForceLevel(logrus.DebugLevel), logrusx.WithHook(hook))
+		prewarmWhoami(newSessionClient(defaultSessionConfig()), ws.URL, l)
+
+		entry := hook.LastEntry()
+		require.NotNil(t, entry)
+		assert.Equal(t, logrus.WarnLevel, entry.Level)
+	})
+
+	t.Run("case=called when the session checker starts", func(t *testing.T) {
+		ws := newWhoamiServer(t, testSession)
+		conf := &config{pathPrefix: "/.ory", prewarmWhoami: true}
+		l := logrusx.New("test", "test")
+		signer, keys, err := newSigner(l, conf)
+		require.NoError(t, err)
+
+		checkOry(conf, l, nil, keys, signer, ws.url)
+		assert.Eventually(t, func() bool { return ws.Calls() == 1 }, time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/ory/x/stringslice"
+)
+
+// secretFlags are the flags whose values are secrets. New flags carrying secrets must be added here, so that
+// --print-config does not print them.
+var secretFlags = []string{AdminTokenFlag}
+
+// secretValueFlags are the flags in the form key=value whose values are secrets, but whose keys are not.
+var secretValueFlags = []string{UpstreamHeaderFlag}
+
+// printCommandLine prints the command line which reproduces this invocation with all flags set to their resolved
+// values. Empty values are omitted, and secrets as well as passwords in URLs are redacted.
+func printCommandLine(w io.Writer, cmd *cobra.Command, args []string) {
+	parts := []string{cmd.CommandPath()}
+	for _, arg := range args {
+		parts = append(parts, shellQuote(redactURL(arg)))
+	}
+
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if (f.Hidden && !f.Changed) || f.Name == "help" || f.Name == PrintConfigFlag || f.Name == ProxyConfigFlag {
+			return
+		}
+
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			for _, v := range sv.GetSlice() {
+				parts = append(parts, fmt.Sprintf("--%s=%s", f.Name, shellQuote(redactFlagValue(f.Name, v))))
+			}
+			return
+		}
+
+		if len(f.Value.String()) == 0 {
+			return
+		}
+		parts = append(parts, fmt.Sprintf("--%s=%s", f.Name, shellQuote(redactFlagValue(f.Name, f.Value.String()))))
+	})
+
+	_, _ = fmt.Fprintln(w, strings.Join(parts, " \\\n\t"))
+}
+
+func redactFlagValue(name, value string) string {
+	switch {
+	case stringslice.Has(secretFlags, name):
+		return redactedValue
+	case stringslice.Has(secretValueFlags, name):
+		if key, _, ok := strings.Cut(value, "="); ok {
+			return key + "=" + redactedValue
+		}
+		return redactedValue
+	}
+	return redactURL(value)
+}
+
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+	if _, ok := u.User.Password(); !ok {
+		return value
+	}
+	u.User = url.UserPassword(u.User.Username(), redactedValue)
+	return u.String()
+}
+
+func shellQuote(value string) string {
+	if len(value) > 0 && strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=,@", r))
+	}) == -1 {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
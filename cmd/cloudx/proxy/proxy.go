@@ -7,11 +7,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -22,56 +22,194 @@ import (
 	"github.com/gofrs/uuid/v3"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/pkg/errors"
-	"github.com/rs/cors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/square/go-jose/v3"
 	"github.com/square/go-jose/v3/jwt"
 	"github.com/tidwall/gjson"
 	"github.com/urfave/negroni"
+	"golang.org/x/net/http/httpguts"
 
 	"github.com/ory/graceful"
 	"github.com/ory/herodot"
-	"github.com/ory/x/corsx"
+	"github.com/ory/x/flagx"
 	"github.com/ory/x/httpx"
-	"github.com/ory/x/jwksx"
 	"github.com/ory/x/logrusx"
 	"github.com/ory/x/proxy"
+	"github.com/ory/x/stringslice"
 	"github.com/ory/x/urlx"
 )
 
 const (
-	PortFlag               = "port"
-	OpenFlag               = "open"
-	DevFlag                = "dev"
-	DebugFlag              = "debug"
-	WithoutJWTFlag         = "no-jwt"
-	CookieDomainFlag       = "cookie-domain"
-	DefaultRedirectURLFlag = "default-redirect-url"
-	ProjectFlag            = "project"
-	CORSFlag               = "allowed-cors-origins"
-	RewriteHostFlag        = "rewrite-host"
+	PortFlag                = "port"
+	HostFlag                = "host"
+	MountPathFlag           = "mount-path"
+	TLSCertFlag             = "tls-cert"
+	TLSKeyFlag              = "tls-key"
+	NoTLSFlag               = "no-tls"
+	SessionRetryMaxFlag     = "session-retry-max"
+	SessionRetryWaitFlag    = "session-retry-wait"
+	SessionConnTimeoutFlag  = "session-connect-timeout"
+	SessionCacheTTLFlag     = "session-cache-ttl"
+	StrictSessionFlag       = "strict-session"
+	OpenFlag                = "open"
+	DevFlag                 = "dev"
+	DebugFlag               = "debug"
+	WithoutJWTFlag          = "no-jwt"
+	CookieDomainFlag        = "cookie-domain"
+	CookieSameSiteFlag      = "cookie-samesite"
+	UpstreamHeaderFlag      = "upstream-header"
+	UpstreamHTTP2Flag       = "upstream-http2"
+	MaxBodySizeFlag         = "max-body-size"
+	KeepHSTSFlag            = "keep-hsts"
+	DefaultRedirectURLFlag  = "default-redirect-url"
+	ProjectFlag             = "project"
+	CORSFlag                = "allowed-cors-origins"
+	RewriteHostFlag         = "rewrite-host"
+	CookieDomainMatchFlag   = "cookie-domain-match"
+	CredentialFlag          = "inject-credential"
+	BypassPathFlag          = "bypass-path"
+	UpstreamCookiesFlag     = "upstream-cookie-allowlist"
+	LandingPageFlag         = "landing-page"
+	LogUpstreamTimingFlag   = "log-upstream-timing"
+	LogRequestsFlag         = "log-requests"
+	MetricsPortFlag         = "metrics-port"
+	LogOryTimingFlag        = "log-ory-timing"
+	NoForwardedPortFlag     = "no-forwarded-port"
+	PprofPortFlag           = "pprof-port"
+	PrintConfigFlag         = "print-config"
+	RequestIDHeaderFlag     = "request-id-header"
+	MaxHeaderBytesFlag      = "max-header-bytes"
+	TraceWhoamiFlag         = "trace-whoami"
+	ResolveFlag             = "resolve"
+	UpstreamMaxConnsFlag    = "upstream-max-conns"
+	AdminEndpointsFlag      = "admin-endpoints"
+	AdminTokenFlag          = "admin-token"
+	RedirectExpiredFlag     = "redirect-expired-sessions"
+	SessionTokenHeaderFlag  = "session-token-header"
+	PrewarmWhoamiFlag       = "prewarm-whoami"
+	JWKSCacheMaxAgeFlag     = "jwks-cache-max-age"
+	RouteFlag               = "route"
+	RoutesFileFlag          = "routes-file"
+	OpenDelayFlag           = "open-delay"
+	OpenCmdFlag             = "open-cmd"
+	JWTTTLFlag              = "jwt-ttl"
+	JWTAlgorithmFlag        = "jwt-algorithm"
+	JWTAudienceFlag         = "jwt-audience"
+	JWTClaimFlag            = "jwt-claim"
+	JWTKeyFileFlag          = "jwt-key-file"
+	JWTKeyIDFlag            = "jwt-kid"
+	ProxyConfigFlag         = "proxy-config"
+	CORSAllowedOriginsFlag  = "cors-allowed-origins"
+	CORSAllowedMethodsFlag  = "cors-allowed-methods"
+	CORSCredentialsFlag     = "cors-allow-credentials"
+	ShutdownTimeoutFlag     = "shutdown-timeout"
+	HealthCheckUpstreamFlag = "health-check-upstream"
+	PortAutoFlag            = "port-auto"
+	TrustForwardedForFlag   = "trust-forwarded-for"
+	LogFormatFlag           = "log-format"
+	LogLevelFlag            = "log-level"
 )
 
 type config struct {
-	port              int
-	noOpen            bool
-	noJWT             bool
-	upstream          string
-	cookieDomain      string
-	publicURL         *url.URL
-	oryURL            *url.URL
-	pathPrefix        string
-	defaultRedirectTo *url.URL
-	isTunnel          bool
-	isDebug           bool
-	isDev             bool
-	corsOrigins       []string
+	host                 string
+	tlsCertFile          string
+	tlsKeyFile           string
+	port                 int
+	portAuto             bool
+	portURLs             []*url.URL
+	noOpen               bool
+	openCmd              []string
+	noJWT                bool
+	upstream             string
+	cookieDomain         string
+	cookieDomainMatch    string
+	cookieSameSite       http.SameSite
+	upstreamHeaders      http.Header
+	upstreamHTTP2        bool
+	publicURL            *url.URL
+	oryURL               *url.URL
+	pathPrefix           string
+	defaultRedirectTo    *url.URL
+	isTunnel             bool
+	isDebug              bool
+	isDev                bool
+	corsOrigins          []string
+	corsMethods          []string
+	corsAllowCredentials bool
+	credentials          []credentialRule
+	bypassPaths          []string
+	upstreamCookies      []string
+	landingPage          bool
+	logUpstreamTiming    bool
+	logRequests          bool
+	metricsPort          int
+	metrics              *metrics
+	logOryTiming         bool
+	noForwardedPort      bool
+	trustForwardedFor    bool
+	pprofPort            int
+	requestIDHeader      string
+	maxHeaderBytes       int
+	maxBodySize          int
+	keepHSTS             bool
+	traceWhoami          bool
+	resolve              map[string]string
+	upstreamMaxConns     int
+	adminEndpoints       bool
+	adminToken           string
+	redirectExpired      bool
+	sessionTokenHeader   string
+	prewarmWhoami        bool
+	jwksCacheMaxAge      time.Duration
+	routes               []route
+	openDelay            time.Duration
+	jwtTTL               time.Duration
+	jwtAlgorithm         string
+	jwtAudience          []string
+	jwtClaims            map[string]string
+	jwtKeyFile           string
+	jwtKeyID             string
+	shutdownTimeout      time.Duration
+	healthCheckUpstream  bool
+	logOptions           []logrusx.Option
+
+	sessionRetryMax       int
+	sessionRetryWait      time.Duration
+	sessionConnectTimeout time.Duration
+	sessionCacheTTL       time.Duration
+	strictSession         bool
 
 	// rewriteHost means the host header will be rewritten to the upstream host.
 	// This is useful in cases where upstream resolves requests based on Host.
 	rewriteHost bool
 }
 
+const defaultMountPath = "/.ory"
+
+// parseMountPath normalizes the path prefix Ory is served at. Mounting Ory at the root would leave no path for the
+// application.
+func parseMountPath(mountPath string) (string, error) {
+	if !strings.HasPrefix(mountPath, "/") {
+		return "", errors.Errorf("the value of --%s must start with a slash but got: %s", MountPathFlag, mountPath)
+	}
+
+	mountPath = path.Clean(mountPath)
+	if mountPath == "/" {
+		return "", errors.Errorf("the value of --%s must not be the root path", MountPathFlag)
+	}
+	return mountPath, nil
+}
+
+// defaultPublicURL is the URL the proxy is reachable at when no public URL is given. Listening on all interfaces
+// includes localhost.
+func defaultPublicURL(scheme, host string, port int) string {
+	if ip := net.ParseIP(host); len(host) == 0 || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(port))
+}
+
 func portFromEnv() int {
 	var port int64 = 4000
 	if p, _ := strconv.ParseInt(os.Getenv("PORT"), 10, 64); p != 0 {
@@ -80,6 +218,93 @@ func portFromEnv() int {
 	return int(port)
 }
 
+// listen binds to the address. If the port is in use, the error names it and where its value came from, or, with
+// auto, a free port is picked instead.
+func listen(addr string, port int, fromEnv, auto bool) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if isAddrInUse(err) {
+		if auto {
+			host, _, _ := net.SplitHostPort(addr)
+			ln, err = net.Listen("tcp", net.JoinHostPort(host, "0"))
+			return ln, errors.Wrapf(err, "unable to listen on a free port of %s", host)
+		}
+		if fromEnv {
+			return nil, errors.Errorf("port %d, set by the PORT environment variable, is already in use, please stop the process using it, change PORT, choose a different port with --%s, or pass --%s", port, PortFlag, PortAutoFlag)
+		}
+		return nil, errors.Errorf("port %d is already in use, please stop the process using it, choose a different port with --%s, or pass --%s", port, PortFlag, PortAutoFlag)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "unable to listen on %s", addr)
+	}
+	return ln, nil
+}
+
+// usePort switches the configuration to the port, including the URLs the proxy derived from the configured port. URLs
+// passed by the user are left as they are, even if they use the same port.
+func (c *config) usePort(port int) {
+	for _, u := range c.portURLs {
+		u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(port))
+	}
+	c.port = port
+}
+
+// bypasses reports whether the request is passed to the upstream as-is, without checking the session. Ory's own paths
+// are never bypassed.
+func (c *config) bypasses(path string) bool {
+	if c.isTunnel || (len(c.pathPrefix) > 0 && strings.HasPrefix(path, c.pathPrefix)) {
+		return false
+	}
+
+	for _, prefix := range c.bypassPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// setForwardedHeaders tells the application under which host and port the proxy is reachable. The port is the one of
+// the public URL, not the port the proxy listens on, as those differ when the proxy runs behind a load balancer. An
+// inbound X-Forwarded-Port is only kept if forwarded headers are trusted, because any client can send one.
+func setForwardedHeaders(r *http.Request, conf *config) {
+	if conf.rewriteHost {
+		r.Header.Set("X-Forwarded-Host", r.Host)
+	}
+
+	if conf.noForwardedPort || (conf.trustForwardedFor && len(r.Header.Get("X-Forwarded-Port")) > 0) {
+		return
+	}
+
+	port := conf.publicURL.Port()
+	if len(port) == 0 {
+		port = "80"
+		if conf.publicURL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	r.Header.Set("X-Forwarded-Port", port)
+}
+
+// setForwardedFor sets X-Real-IP to the IP of the client and prepares X-Forwarded-For, to which the reverse proxy
+// appends the address of the client. An inbound X-Forwarded-For is only kept if it is trusted, because any client can
+// send one to spoof its IP. Only the proxy in front of this one is trusted, so the client is the rightmost entry,
+// which that proxy appended. Entries further left were sent by the client and may be spoofed.
+func setForwardedFor(r *http.Request, trust bool) {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return
+	}
+
+	prior := r.Header.Values("X-Forwarded-For")
+	if !trust || len(prior) == 0 {
+		r.Header.Del("X-Forwarded-For")
+		r.Header.Set("X-Real-IP", ip)
+		return
+	}
+
+	entries := strings.Split(prior[len(prior)-1], ",")
+	r.Header.Set("X-Real-IP", strings.TrimSpace(entries[len(entries)-1]))
+}
+
 var errNoApiKeyAvailable = errors.New("no api key available")
 
 func noop() {}
@@ -149,14 +374,57 @@ func run(cmd *cobra.Command, conf *config, version string, name string) error {
 		return err
 	}
 
-	upstream, err := url.ParseRequestURI(conf.upstream)
+	// Bind before anything else, so that a port in use fails early, the rest of the setup sees the port actually in
+	// use, and the first page load in the browser does not race the listener.
+	portFromEnvVar := !cmd.Flags().Changed(PortFlag) && len(os.Getenv("PORT")) > 0
+	ln, err := listen(net.JoinHostPort(conf.host, strconv.Itoa(conf.port)), conf.port, portFromEnvVar, conf.portAuto)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = ln.Close() }()
+	if port := ln.Addr().(*net.TCPAddr).Port; port != conf.port {
+		if conf.port != 0 {
+			_, _ = fmt.Fprintf(os.Stderr, "Port %d is already in use, listening on port %d instead.\n", conf.port, port)
+		}
+		conf.usePort(port)
+	}
+
+	upstream, err := parseUpstream(conf.upstream)
 	if err != nil {
-		return errors.Wrap(err, "unable to parse upstream URL")
+		return err
+	}
+	if err := conf.checkUpstreamLoop(upstream); err != nil {
+		return err
+	}
+	for _, rt := range conf.routes {
+		if err := conf.checkUpstreamLoop(rt.upstream); err != nil {
+			return errors.WithMessagef(err, "--%s %s", RouteFlag, rt.prefix)
+		}
+	}
+
+	if err := validateCookieDomain(conf.cookieDomain); err != nil {
+		return err
+	}
+	if err := validateCookieDomainMatch(conf.cookieDomainMatch); err != nil {
+		return err
 	}
 
-	l := logrusx.New("ory/"+strings.ToLower(name), version)
+	// The JSON writer logs errors with the same logger, so that they use the same format and level.
+	l := logrusx.New("ory/"+strings.ToLower(name), version, conf.logOptions...)
 	writer := herodot.NewJSONWriter(l)
+	warnCORS(conf, l)
+	for _, overlap := range routeOverlaps(conf.routes) {
+		l.Info(overlap)
+	}
+	if conf.metricsPort > 0 {
+		conf.metrics = newMetrics()
+	}
+
 	mw := negroni.New()
+	mw.UseFunc(conf.metrics.middleware)
+	if conf.logRequests {
+		mw.Use(newRequestLogger(conf, l))
+	}
 
 	signer, key, err := newSigner(l, conf)
 	if err != nil {
@@ -171,15 +439,194 @@ func run(cmd *cobra.Command, conf *config, version string, name string) error {
 	}
 	defer removeAPIKey()
 
-	mw.UseFunc(func(w http.ResponseWriter, r *http.Request, n http.HandlerFunc) {
-		// Disable HSTS because it is very annoying to use in localhost.
-		w.Header().Set("Strict-Transport-Security", "max-age=0;")
-		n(w, r)
-	})
+	if !conf.keepHSTS {
+		mw.UseFunc(disableHSTS)
+	}
 
+	// The health endpoints come before draining, which rejects all other requests, so that liveness probes keep
+	// passing and readiness reports the drain.
+	health := newHealth(conf, writer)
+	mw.UseFunc(health.middleware(conf))
+	if conf.adminEndpoints {
+		token := conf.adminToken
+		if len(token) == 0 {
+			token = uuid.Must(uuid.NewV4()).String()
+			_, _ = fmt.Fprintf(os.Stderr, "Use this token to call the admin endpoints: %s\n", token)
+		}
+		a := &admin{token: token}
+		health.draining = a.isDraining
+		mw.UseFunc(a.middleware(conf, writer))
+	}
+
+	mw.UseFunc(withRequestID(conf))
+	mw.UseFunc(limitBody(conf, writer))
 	mw.UseFunc(checkOry(conf, l, writer, key, signer, conf.oryURL)) // This must be the last method before the handler
 
-	mw.UseHandler(proxy.New(
+	mw.UseHandler(newProxyHandler(conf, l, upstream, apiKey))
+
+	var cleanups []func(context.Context) error
+	cleanup := func(ctx context.Context) error {
+		for _, c := range cleanups {
+			if err := c(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if conf.pprofPort > 0 {
+		pprofServer := newPprofServer(conf.pprofPort)
+		go func() {
+			if err := pprofServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				l.WithError(err).Error("Unable to start the profiling server.")
+			}
+		}()
+		l.Infof("Serving profiles at http://%s/debug/pprof/", pprofServer.Addr)
+		cleanups = append(cleanups, pprofServer.Shutdown)
+	}
+
+	if conf.metrics != nil {
+		metricsServer := newMetricsServer(conf.host, conf.metricsPort, conf.metrics)
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				l.WithError(err).Error("Unable to start the metrics server.")
+			}
+		}()
+		l.Infof("Serving metrics at http://%s/metrics", metricsServer.Addr)
+		cleanups = append(cleanups, metricsServer.Shutdown)
+	}
+
+	tlsConfig, err := loadTLSConfig(conf.tlsCertFile, conf.tlsKeyFile)
+	if err != nil {
+		return err
+	}
+
+	proto := "http"
+	if tlsConfig != nil {
+		proto = "https"
+		// Lets users check that the certificate their browser warns about is the one they passed.
+		l.WithField("sha256_fingerprint", certFingerprint(tlsConfig)).Info("Serving HTTPS with the TLS certificate.")
+	}
+	addr := net.JoinHostPort(conf.host, strconv.Itoa(conf.port))
+	server := newServer(conf, addr, newCORS(conf).Handler(mw))
+	server.TLSConfig = tlsConfig
+
+	if conf.isTunnel {
+		_, _ = fmt.Fprintf(os.Stderr, `To access Ory's APIs, use URL
+
+	%s
+
+and configure your SDKs to point to it, for example in JavaScript:
+
+	import { V0alpha2Api, Configuration } from '@ory/client'
+	const ory = new V0alpha2Api(new Configuration({
+	  basePath: 'http://localhost:4000',
+	  baseOptions: {
+		withCredentials: true
+	  }
+	}))
+
+`, conf.publicURL.String())
+	} else {
+		_, _ = fmt.Fprintf(os.Stderr, `To access your application via the Ory Proxy, open:
+
+	%s
+`, conf.publicURL.String())
+	}
+
+	if !conf.noOpen {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), conf.openDelay+10*time.Second)
+			defer cancel()
+			// The JSON Web Key Set is served by the proxy itself, so a response means that the proxy is up.
+			if err := openWhenReady(ctx, readyURL(proto, ln.Addr(), conf.pathPrefix), conf.openDelay, conf.publicURL.String(), openWith(conf.openCmd)); err != nil {
+				l.WithError(err).Warn("Unable to open the browser.")
+				_, _ = fmt.Fprintf(os.Stderr, "Unable to automatically open the proxy URL in your browser. Please open it manually:\n\n\t%s\n", conf.publicURL)
+			}
+		}()
+	}
+
+	if err := graceful.Graceful(func() error {
+		if tlsConfig != nil {
+			return server.ServeTLS(ln, "", "")
+		}
+		return server.Serve(ln)
+	}, func(context.Context) error {
+		if err := shutdownServer(server, conf.shutdownTimeout, cleanup); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "http server was shutdown gracefully\n")
+		return nil
+	}); err != nil {
+		// Returning instead of exiting lets the deferred cleanups, such as removing the API key, run.
+		return errors.Wrapf(err, "failed to gracefully shutdown the %s server", proto)
+	}
+
+	return nil
+}
+
+// shutdownServer waits up to timeout for the requests in flight to finish and closes the connections that are still
+// open afterwards. The cleanup runs in any case, with a deadline of its own, so that nothing is left behind when
+// long-lived connections exceed the timeout.
+func shutdownServer(server *http.Server, timeout time.Duration, cleanup func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := server.Shutdown(ctx)
+	if err != nil {
+		_ = server.Close()
+		err = errors.Wrapf(err, "the requests in flight did not finish within %s", timeout)
+	}
+
+	cleanupCtx, cancelCleanup := context.WithTimeout(context.Background(), timeout)
+	defer cancelCleanup()
+	if cleanupErr := cleanup(cleanupCtx); err == nil && cleanupErr != nil {
+		err = errors.WithStack(cleanupErr)
+	}
+	return err
+}
+
+func newServer(conf *config, addr string, handler http.Handler) *http.Server {
+	return graceful.WithDefaults(&http.Server{
+		Addr:           addr,
+		Handler:        handler,
+		MaxHeaderBytes: conf.maxHeaderBytes,
+	})
+}
+
+// newUpstreamTransport returns the transport used to connect to Ory and the application. It is the default transport
+// unless connections need to be tuned.
+func newUpstreamTransport(conf *config) http.RoundTripper {
+	var transport http.RoundTripper = http.DefaultTransport
+	if len(conf.resolve) > 0 || conf.upstreamMaxConns > 0 {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		if len(conf.resolve) > 0 {
+			t.DialContext = resolvingDialContext(conf.resolve)
+		}
+		if conf.upstreamMaxConns > 0 {
+			t.MaxConnsPerHost = conf.upstreamMaxConns
+			t.MaxIdleConnsPerHost = conf.upstreamMaxConns
+		}
+		transport = t
+	}
+
+	if conf.upstreamHTTP2 {
+		return newH2CTransport(conf, transport)
+	}
+	return transport
+}
+
+// newProxyHandler returns the handler forwarding requests to Ory or the application.
+func newProxyHandler(conf *config, l *logrusx.Logger, upstream *url.URL, apiKey string) http.Handler {
+	var transport http.RoundTripper = &cookieDomainTransport{RoundTripper: newUpstreamTransport(conf), match: conf.cookieDomainMatch}
+	if conf.metrics != nil {
+		transport = &metricsTransport{RoundTripper: transport, m: conf.metrics, oryHost: conf.oryURL.Host}
+	}
+	if conf.logUpstreamTiming {
+		transport = &timingTransport{RoundTripper: transport, l: l, oryHost: conf.oryURL.Host, includeOry: conf.logOryTiming, requestIDHeader: conf.requestIDHeaderName()}
+	}
+
+	return proxy.New(
 		func(_ context.Context, r *http.Request) (*proxy.HostConfig, error) {
 			if conf.isTunnel || strings.HasPrefix(r.URL.Path, conf.pathPrefix) {
 				return &proxy.HostConfig{
@@ -191,21 +638,52 @@ func run(cmd *cobra.Command, conf *config, version string, name string) error {
 				}, nil
 			}
 
+			target := upstream
+			if rt := conf.routeFor(r.URL.Path); rt != nil {
+				target = rt.upstream
+			}
+
 			return &proxy.HostConfig{
 				CookieDomain:   conf.cookieDomain,
-				UpstreamHost:   upstream.Host,
-				UpstreamScheme: upstream.Scheme,
-				TargetHost:     upstream.Host,
+				UpstreamHost:   target.Host,
+				UpstreamScheme: target.Scheme,
+				TargetHost:     target.Host,
 				PathPrefix:     "",
 			}, nil
 		},
 		proxy.WithReqMiddleware(func(r *http.Request, c *proxy.HostConfig, body []byte) ([]byte, error) {
+			// The session was already checked by checkOry, so it is safe to strip cookies here.
+			if r.URL.Host != conf.oryURL.Host && len(conf.upstreamCookies) > 0 {
+				filterCookies(r, conf.upstreamCookies)
+			}
+
+			bypassed := conf.bypasses(r.URL.Path)
+			if r.URL.Host != conf.oryURL.Host {
+				if rt := conf.routeFor(r.URL.Path); rt != nil {
+					rt.strip(r.URL)
+				}
+			}
+
+			if bypassed {
+				if conf.rewriteHost {
+					r.Host = c.UpstreamHost
+				}
+				conf.setUpstreamHeaders(r)
+				return body, nil
+			}
+
 			if r.URL.Host == conf.oryURL.Host {
 				r.URL.Path = strings.TrimPrefix(r.URL.Path, conf.pathPrefix)
+				// Keep percent-encoded segments such as %2F intact, they are lost if only the decoded path is trimmed.
+				r.URL.RawPath = strings.TrimPrefix(r.URL.RawPath, conf.pathPrefix)
+				// Ory routes requests by their host, so it is always rewritten, regardless of --rewrite-host.
 				r.Host = conf.oryURL.Host
-			} else if conf.rewriteHost {
-				r.Header.Set("X-Forwarded-Host", r.Host)
-				r.Host = c.UpstreamHost
+			} else {
+				setForwardedHeaders(r, conf)
+				if conf.rewriteHost {
+					r.Host = c.UpstreamHost
+				}
+				conf.setUpstreamHeaders(r)
 			}
 
 			publicURL := conf.publicURL
@@ -221,171 +699,424 @@ func run(cmd *cobra.Command, conf *config, version string, name string) error {
 
 			return body, nil
 		}),
+		proxy.WithErrorHandler(upstreamErrorHandler(conf, l, upstream)),
+		proxy.WithTransport(transport),
+		proxy.WithRespMiddleware(restorePreservedCookies),
+		proxy.WithRespMiddleware(rewriteSameSite(conf.cookieSameSite)),
 		proxy.WithRespMiddleware(func(resp *http.Response, config *proxy.HostConfig, body []byte) ([]byte, error) {
 			l, err := resp.Location()
 			if err == nil {
 				// Redirect to main page if path is the default ui welcome page.
-				if l.Path == filepath.Join(conf.pathPrefix, "/ui/welcome") {
+				if l.Path == path.Join(conf.pathPrefix, "/ui/welcome") {
 					resp.Header.Set("Location", conf.defaultRedirectTo.String())
 				}
 			}
 
 			return body, nil
 		}),
-	))
+	)
+}
 
-	cleanup := func() error {
-		return nil
+func newSigner(l *logrusx.Logger, conf *config) (jose.Signer, *jose.JSONWebKeySet, error) {
+	if conf.noJWT {
+		return nil, &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}, nil
 	}
 
-	var originFunc func(r *http.Request, origin string) bool
-	if conf.isDev {
-		originFunc = func(r *http.Request, origin string) bool {
-			return true
+	alg := conf.signingAlgorithm()
+	if err := validateJWTAlgorithm(alg); err != nil {
+		return nil, nil, err
+	}
+
+	var key *jose.JSONWebKeySet
+	var err error
+	if len(conf.jwtKeyFile) > 0 {
+		key, err = loadOrGenerateSigningKey(l, conf.jwtKeyFile, alg, conf.jwtKeyID)
+	} else {
+		key, err = generateSigningKey(l, alg, conf.jwtKeyID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Signing with the JSON Web Key instead of the raw key puts its key ID into the token header.
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.SignatureAlgorithm(alg), Key: key.Keys[0]}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to create signer")
+	}
+	return sig, key, nil
+}
+
+const defaultJWTAlgorithm = string(jose.ES256)
+
+// jwtAlgorithms are the algorithms the proxy can sign JSON Web Tokens with.
+var jwtAlgorithms = []string{string(jose.ES256), string(jose.ES384), string(jose.ES512), string(jose.RS256)}
+
+func (c *config) signingAlgorithm() string {
+	if len(c.jwtAlgorithm) == 0 {
+		return defaultJWTAlgorithm
+	}
+	return c.jwtAlgorithm
+}
+
+func validateJWTAlgorithm(alg string) error {
+	for _, a := range jwtAlgorithms {
+		if a == alg {
+			return nil
 		}
 	}
+	return errors.Errorf("the value of --%s must be one of %s but got: %s", JWTAlgorithmFlag, strings.Join(jwtAlgorithms, ", "), alg)
+}
 
-	proto := "http"
-	addr := fmt.Sprintf(":%d", conf.port)
-	ch := cors.New(cors.Options{
-		AllowedOrigins:         conf.corsOrigins,
-		AllowOriginRequestFunc: originFunc,
-		AllowedMethods:         corsx.CORSDefaultAllowedMethods,
-		AllowedHeaders:         append(corsx.CORSRequestHeadersSafelist, corsx.CORSRequestHeadersExtended...),
-		ExposedHeaders:         corsx.CORSResponseHeadersSafelist,
-		MaxAge:                 0,
-		AllowCredentials:       true,
-		OptionsPassthrough:     false,
-		Debug:                  conf.isDebug,
-	})
+// maxRetryAfter caps how long the session check waits when Ory asks it to back off.
+const maxRetryAfter = 5 * time.Second
 
-	server := graceful.WithDefaults(&http.Server{
-		Addr:    addr,
-		Handler: ch.Handler(mw),
-	})
+// retryAfterKey stores a *bool in the context of a session check, which is set once a Retry-After header was honored.
+// Each session check honors at most one Retry-After, so that a rate limited request never stalls for longer than
+// maxRetryAfter.
+type retryAfterKey struct{}
 
-	if conf.isTunnel {
-		_, _ = fmt.Fprintf(os.Stderr, `To access Ory's APIs, use URL
+func retryAfterHonored(r *http.Request) *bool {
+	if r == nil {
+		return nil
+	}
+	honored, _ := r.Context().Value(retryAfterKey{}).(*bool)
+	return honored
+}
 
-	%s
+// errSessionRateLimited is returned by checkSession when Ory keeps rate limiting the session check.
+type errSessionRateLimited struct {
+	retryAfter string
+}
 
-and configure your SDKs to point to it, for example in JavaScript:
+func (e *errSessionRateLimited) Error() string {
+	if len(e.retryAfter) == 0 {
+		return "Ory rate limited the session check"
+	}
+	return fmt.Sprintf("Ory rate limited the session check, retry after %s", e.retryAfter)
+}
 
-	import { V0alpha2Api, Configuration } from '@ory/client'
-	const ory = new V0alpha2Api(new Configuration({
-	  basePath: 'http://localhost:4000',
-	  baseOptions: {
-		withCredentials: true
-	  }
-	}))
+const (
+	defaultSessionRetryMax       = 5
+	defaultSessionRetryWait      = 5 * time.Millisecond
+	defaultSessionConnectTimeout = 2 * time.Second
+)
 
-`, conf.publicURL.String())
-	} else {
-		_, _ = fmt.Fprintf(os.Stderr, `To access your application via the Ory Proxy, open:
+var errSessionCheckFailed = herodot.DefaultError{
+	StatusField: http.StatusText(http.StatusBadGateway),
+	ErrorField:  "The session could not be checked because Ory is unreachable",
+	CodeField:   http.StatusBadGateway,
+}
 
-	%s
-`, conf.publicURL.String())
+func newSessionClient(conf *config) *retryablehttp.Client {
+	hc := httpx.NewResilientClient(
+		httpx.ResilientClientWithMaxRetry(conf.sessionRetryMax),
+		httpx.ResilientClientWithMaxRetryWait(conf.sessionRetryWait),
+		httpx.ResilientClientWithConnectionTimeout(conf.sessionConnectTimeout),
+	)
+	hc.Backoff = retryAfterBackoff
+	hc.CheckRetry = retryAfterPolicy
+	hc.ErrorHandler = retryablehttp.PassthroughErrorHandler
+	return hc
+}
+
+func registerSessionClientFlags(flags *pflag.FlagSet) {
+	flags.Int(SessionRetryMaxFlag, defaultSessionRetryMax, "How often a failed session check is retried.")
+	flags.Duration(SessionRetryWaitFlag, defaultSessionRetryWait, "The maximum time to wait between retries of a failed session check.")
+	flags.Duration(SessionConnTimeoutFlag, defaultSessionConnectTimeout, "The time to wait for the connection to Ory when checking the session.")
+}
+
+// sessionClientFromFlags sets the retry and timeout settings of the session checks from the flags.
+func sessionClientFromFlags(cmd *cobra.Command, conf *config) error {
+	conf.sessionRetryMax = flagx.MustGetInt(cmd, SessionRetryMaxFlag)
+	conf.sessionRetryWait = flagx.MustGetDuration(cmd, SessionRetryWaitFlag)
+	conf.sessionConnectTimeout = flagx.MustGetDuration(cmd, SessionConnTimeoutFlag)
+
+	switch {
+	case conf.sessionRetryMax < 0:
+		return errors.Errorf("the value of --%s must not be negative but got: %d", SessionRetryMaxFlag, conf.sessionRetryMax)
+	case conf.sessionRetryWait < 0:
+		return errors.Errorf("the value of --%s must not be negative but got: %s", SessionRetryWaitFlag, conf.sessionRetryWait)
+	case conf.sessionConnectTimeout <= 0:
+		return errors.Errorf("the value of --%s must be positive but got: %s", SessionConnTimeoutFlag, conf.sessionConnectTimeout)
 	}
+	return nil
+}
 
-	if !conf.noOpen {
-		// #nosec G204 - this is ok
-		if err := exec.Command("open", conf.publicURL.String()).Run(); err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "Unable to automatically open the proxy URL in your browser. Please open it manually!")
+// retryAfter returns how long a rate limited response asks the client to wait.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+	return parseRetryAfter(resp.Header.Get("Retry-After"))
+}
+
+// retryAfterBackoff honors the Retry-After header of rate limited responses, but never waits longer than
+// maxRetryAfter. Without such a header it falls back to the default exponential backoff.
+func retryAfterBackoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	if wait, ok := retryAfter(resp); ok {
+		if honored := retryAfterHonored(resp.Request); honored != nil {
+			*honored = true
+		}
+		if wait > maxRetryAfter {
+			return maxRetryAfter
 		}
+		return wait
 	}
+	return retryablehttp.DefaultBackoff(min, max, attemptNum, nil)
+}
 
-	if err := graceful.Graceful(func() error {
-		return server.ListenAndServe()
-	}, func(ctx context.Context) error {
-		_, _ = fmt.Fprintf(os.Stderr, "http server was shutdown gracefully\n")
-		if err := server.Shutdown(ctx); err != nil {
-			return err
+// retryAfterPolicy stops retrying once Ory asks the session check to back off a second time.
+func retryAfterPolicy(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if _, ok := retryAfter(resp); ok {
+		if honored := retryAfterHonored(resp.Request); honored != nil && *honored {
+			return false, nil
 		}
+	}
+	return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+}
 
-		return cleanup()
-	}); err != nil {
-		l.Fatalf("Failed to gracefully shutdown %s server because: %s\n", proto, err)
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
 	}
 
-	return nil
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		wait := time.Until(at)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
 }
 
-func newSigner(l *logrusx.Logger, conf *config) (jose.Signer, *jose.JSONWebKeySet, error) {
-	if conf.noJWT {
-		return nil, &jose.JSONWebKeySet{}, nil
+// disableHSTS overrides the Strict-Transport-Security header, because HSTS is very annoying to use in localhost.
+func disableHSTS(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Set("Strict-Transport-Security", "max-age=0;")
+	next(w, r)
+}
+
+// isUpgrade reports whether the request asks to switch protocols, for example to a WebSocket.
+func isUpgrade(r *http.Request) bool {
+	return len(r.Header.Get("Upgrade")) > 0 && httpguts.HeaderValuesContainsToken(r.Header["Connection"], "upgrade")
+}
+
+// setJWKSCacheControl allows caching the JSON Web Key Set. The keys change whenever the proxy restarts, so the
+// max age should be short enough for clients to pick up the new keys.
+func setJWKSCacheControl(w http.ResponseWriter, conf *config) {
+	if conf.jwksCacheMaxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int64(conf.jwksCacheMaxAge/time.Second)))
 	}
+}
 
-	l.WithField("started_at", time.Now()).Info("")
-	key, err := jwksx.GenerateSigningKeys(
-		uuid.Must(uuid.NewV4()).String(),
-		"ES256",
-		0,
-	)
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "unable to generate JSON Web Key")
+func checkOry(conf *config, l *logrusx.Logger, writer herodot.Writer, keys *jose.JSONWebKeySet, sig jose.Signer, endpoint *url.URL) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
+	hc := newSessionClient(conf)
+	if conf.traceWhoami {
+		transport := hc.HTTPClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		hc.HTTPClient.Transport = &whoamiTraceTransport{RoundTripper: transport, l: l}
 	}
-	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key.Keys[0].Key}, (&jose.SignerOptions{}).WithType("JWT"))
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "unable to create signer")
+
+	if conf.prewarmWhoami {
+		go prewarmWhoami(hc, whoamiURL(endpoint).String(), l)
 	}
-	l.WithField("completed_at", time.Now()).Info("ES256 JSON Web Key generation completed.")
-	return sig, key, nil
-}
 
-func checkOry(conf *config, _ *logrusx.Logger, writer herodot.Writer, keys *jose.JSONWebKeySet, sig jose.Signer, endpoint *url.URL) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
-	hc := httpx.NewResilientClient(httpx.ResilientClientWithMaxRetry(5), httpx.ResilientClientWithMaxRetryWait(time.Millisecond*5), httpx.ResilientClientWithConnectionTimeout(time.Second*2))
+	publicKeys := publicKeySet(keys)
 
-	var publicKeys jose.JSONWebKeySet
-	for _, key := range keys.Keys {
-		publicKeys.Keys = append(publicKeys.Keys, key.Public())
+	var cache *sessionCache
+	if conf.sessionCacheTTL > 0 {
+		cache = newSessionCache(conf.sessionCacheTTL)
 	}
 
 	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-		if !conf.noJWT && r.URL.Path == filepath.Join(conf.pathPrefix, "/proxy/jwks.json") {
+		setForwardedFor(r, conf.trustForwardedFor)
+
+		if !conf.noJWT && r.URL.Path == path.Join(conf.pathPrefix, "/proxy/jwks.json") {
+			setJWKSCacheControl(w, conf)
 			writer.Write(w, r, publicKeys)
 			return
 		}
 
 		switch r.URL.Path {
-		case filepath.Join(conf.pathPrefix, "/jwks.json"):
+		case path.Join(conf.pathPrefix, "/jwks.json"):
+			setJWKSCacheControl(w, conf)
 			writer.Write(w, r, publicKeys)
 			return
 		}
 
-		session, err := checkSession(hc, r, endpoint)
-		if err != nil || !gjson.GetBytes(session, "active").Bool() {
+		// Preflight requests never carry credentials, so there is no session to check. Preflights with CORS
+		// headers are already answered by the CORS handler, this covers the remaining OPTIONS requests. Protocol
+		// upgrades such as WebSockets are passed on untouched, so the handshake is not broken.
+		if r.Method == http.MethodOptions || isUpgrade(r) || conf.bypasses(r.URL.Path) {
 			next(w, r)
 			return
 		}
 
-		if conf.noJWT || (len(conf.pathPrefix) > 0 && strings.HasPrefix(r.URL.Path, conf.pathPrefix)) {
+		credential := conf.credentialFor(r.URL.Path)
+		if credential == credentialNone {
 			next(w, r)
 			return
 		}
 
-		now := time.Now().UTC()
-		raw, err := jwt.Signed(sig).Claims(&jwt.Claims{
-			Issuer:    endpoint.String(),
-			Subject:   gjson.GetBytes(session, "identity.id").String(),
-			Expiry:    jwt.NewNumericDate(now.Add(time.Minute)),
-			NotBefore: jwt.NewNumericDate(now),
-			IssuedAt:  jwt.NewNumericDate(now),
-			ID:        uuid.Must(uuid.NewV4()).String(),
-		}).Claims(map[string]interface{}{"session": session}).CompactSerialize()
+		var (
+			cacheKey string
+			session  json.RawMessage
+			cached   bool
+		)
+		if cache != nil {
+			cacheKey = sessionCacheKey(r, conf.sessionTokenHeader)
+			session, cached = cache.get(cacheKey)
+		}
+
+		if !cached {
+			var err error
+			session, err = checkSession(hc, r, endpoint, conf.requestIDHeaderName(), conf.sessionTokenHeader)
+			var rateLimited *errSessionRateLimited
+			if errors.As(err, &rateLimited) {
+				l.WithError(err).WithField("path", r.URL.Path).Warn("Forwarding the request without credentials because the session could not be checked.")
+			}
+			if err != nil {
+				conf.metrics.sessionCheckFailed()
+				if conf.strictSession {
+					l.WithError(err).WithField("path", r.URL.Path).Warn("Rejecting the request because the session could not be checked.")
+					writer.WriteError(w, r, errSessionCheckFailed)
+					return
+				}
+				next(w, r)
+				return
+			}
+
+			if cache != nil {
+				cache.set(cacheKey, session)
+			}
+		}
+
+		switch classifySession(r, session, conf.sessionTokenHeader) {
+		case sessionExpired:
+			if conf.redirectExpired && acceptsHTML(r) {
+				http.Redirect(w, r, loginURL(conf, r), http.StatusSeeOther)
+				return
+			}
+			fallthrough
+		case sessionMissing:
+			next(w, r)
+			return
+		}
+
+		if credential == credentialSessionToken {
+			if token := sessionTokenFromRequest(r, conf.sessionTokenHeader); len(token) > 0 {
+				r.Header.Set("X-Session-Token", token)
+			}
+			next(w, r)
+			return
+		}
+
+		raw, err := mintToken(sig, endpoint, session, conf)
 		if err != nil {
 			writer.WriteError(w, r, err)
 			return
 		}
 
+		// Only the sizes are logged to help with staying below header size limits, never the contents.
+		l.WithField("path", r.URL.Path).
+			WithField("jwt_bytes", len(raw)).
+			WithField("session_bytes", len(session)).
+			Debug("Injecting JSON Web Token.")
+
 		r.Header.Set("Authorization", "Bearer "+raw)
 		next(w, r)
 	}
 }
 
-func checkSession(c *retryablehttp.Client, r *http.Request, target *url.URL) (json.RawMessage, error) {
+const defaultJWTTTL = time.Minute
+
+func (c *config) tokenTTL() time.Duration {
+	if c.jwtTTL <= 0 {
+		return defaultJWTTTL
+	}
+	return c.jwtTTL
+}
+
+// validateJWTTTL rejects token lifetimes that would make every minted token expire immediately.
+func validateJWTTTL(ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.Errorf("the value of --%s must be a positive duration such as 5m or 30s but got: %s", JWTTTLFlag, ttl)
+	}
+	return nil
+}
+
+// publicKeySet returns the public parts of the keys. The key list is never nil, so that an empty set is served as
+// {"keys":[]}.
+func publicKeySet(keys *jose.JSONWebKeySet) jose.JSONWebKeySet {
+	publicKeys := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	for _, key := range keys.Keys {
+		publicKeys.Keys = append(publicKeys.Keys, key.Public())
+	}
+	return publicKeys
+}
+
+// mintToken returns the JSON Web Token injected into requests for the given session.
+func mintToken(sig jose.Signer, issuer *url.URL, session json.RawMessage, conf *config) (string, error) {
+	now := time.Now().UTC()
+	claims := map[string]interface{}{"session": session}
+	for k, v := range conf.jwtClaims {
+		claims[k] = v
+	}
+
+	return jwt.Signed(sig).Claims(&jwt.Claims{
+		Issuer:    issuer.String(),
+		Subject:   gjson.GetBytes(session, "identity.id").String(),
+		Audience:  conf.jwtAudience,
+		Expiry:    jwt.NewNumericDate(now.Add(conf.tokenTTL())),
+		NotBefore: jwt.NewNumericDate(now),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        uuid.Must(uuid.NewV4()).String(),
+	}).Claims(claims).CompactSerialize()
+}
+
+// reservedJWTClaims are set by the proxy and can not be overridden with --jwt-claim.
+var reservedJWTClaims = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti", "session"}
+
+// parseJWTClaims parses the key=value pairs of --jwt-claim into claims with string values.
+func parseJWTClaims(values []string) (map[string]string, error) {
+	claims := make(map[string]string, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || len(key) == 0 {
+			return nil, errors.Errorf("--%s must be in format of `key=value` but got: %s", JWTClaimFlag, v)
+		}
+		if stringslice.Has(reservedJWTClaims, key) {
+			return nil, errors.Errorf("the claim %s is set by the proxy and can not be set with --%s", key, JWTClaimFlag)
+		}
+		if _, ok := claims[key]; ok {
+			return nil, errors.Errorf("the claim %s is set more than once with --%s", key, JWTClaimFlag)
+		}
+		claims[key] = value
+	}
+	return claims, nil
+}
+
+// whoamiURL returns the session check endpoint below target. URL paths always use forward slashes, which is why
+// path.Join is used instead of filepath.Join.
+func whoamiURL(target *url.URL) *url.URL {
 	target = urlx.Copy(target)
-	target.Path = filepath.Join(target.Path, "api", "kratos", "public", "sessions", "whoami")
-	req, err := retryablehttp.NewRequest("GET", target.String(), nil)
+	target.Path = path.Join("/", target.Path, "api", "kratos", "public", "sessions", "whoami")
+	return target
+}
+
+func checkSession(c *retryablehttp.Client, r *http.Request, target *url.URL, requestIDHeader, sessionTokenHeader string) (json.RawMessage, error) {
+	ctx := context.WithValue(context.Background(), retryAfterKey{}, new(bool))
+	req, err := retryablehttp.NewRequestWithContext(ctx, "GET", whoamiURL(target).String(), nil)
 	if err != nil {
 		return nil, errors.WithStack(herodot.ErrInternalServerError)
 	}
@@ -393,7 +1124,10 @@ func checkSession(c *retryablehttp.Client, r *http.Request, target *url.URL) (js
 	req.Header.Set("Cookie", r.Header.Get("Cookie"))
 	req.Header.Set("Authorization", r.Header.Get("Authorization"))
 	req.Header.Set("X-Session-Token", r.Header.Get("X-Session-Token"))
-	req.Header.Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+	if token := r.Header.Get(sessionTokenHeader); len(sessionTokenHeader) > 0 && len(token) > 0 && len(req.Header.Get("X-Session-Token")) == 0 {
+		req.Header.Set("X-Session-Token", token)
+	}
+	req.Header.Set("X-Request-Id", r.Header.Get(requestIDHeader))
 	req.Header.Set("Accept", "application/json")
 
 	res, err := c.Do(req)
@@ -402,6 +1136,12 @@ func checkSession(c *retryablehttp.Client, r *http.Request, target *url.URL) (js
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == http.StatusTooManyRequests {
+		return nil, errors.WithStack(&errSessionRateLimited{retryAfter: res.Header.Get("Retry-After")})
+	} else if res.StatusCode >= http.StatusInternalServerError {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("The session checker responded with status %d", res.StatusCode))
+	}
+
 	var body json.RawMessage
 	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
 		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Unable to decode session to JSON: %s", err).WithWrap(err))
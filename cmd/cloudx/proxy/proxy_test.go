@@ -0,0 +1,1086 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/square/go-jose/v3"
+	"github.com/square/go-jose/v3/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/flagx"
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/urlx"
+)
+
+const testSession = `{"active":true,"identity":{"id":"a2a9a3b5-6c9b-4d5a-9a5e-1c3b21c6a2b1"}}`
+
+type whoamiServer struct {
+	*httptest.Server
+	url   *url.URL
+	calls int32
+}
+
+func newWhoamiServer(t *testing.T, session string) *whoamiServer {
+	ws := &whoamiServer{}
+	ws.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&ws.calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(session))
+	}))
+	t.Cleanup(ws.Close)
+
+	var err error
+	ws.url, err = url.Parse(ws.URL)
+	require.NoError(t, err)
+	return ws
+}
+
+func (ws *whoamiServer) Calls() int {
+	return int(atomic.LoadInt32(&ws.calls))
+}
+
+// serveCheckOry runs the request through checkOry and returns the request as seen by the next handler, or nil if the
+// next handler was not called.
+func serveCheckOry(t *testing.T, conf *config, endpoint *url.URL, r *http.Request) (*httptest.ResponseRecorder, *http.Request) {
+	l := logrusx.New("test", "test")
+	signer, keys, err := newSigner(l, conf)
+	require.NoError(t, err)
+
+	var forwarded *http.Request
+	rec := httptest.NewRecorder()
+	checkOry(conf, l, herodot.NewJSONWriter(l), keys, signer, endpoint)(rec, r, func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r
+	})
+	return rec, forwarded
+}
+
+func TestStrictSession(t *testing.T) {
+	unreachable := newWhoamiServer(t, testSession)
+	unreachable.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error":{"code":500}}`))
+	}))
+	t.Cleanup(failing.Close)
+
+	for _, tc := range []struct {
+		name     string
+		endpoint *url.URL
+	}{
+		{name: "unreachable", endpoint: unreachable.url},
+		{name: "server error", endpoint: urlx.ParseOrPanic(failing.URL)},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			t.Run("mode=lenient", func(t *testing.T) {
+				rec, forwarded := serveCheckOry(t, &config{pathPrefix: "/.ory"}, tc.endpoint, httptest.NewRequest("GET", "/", nil))
+				require.NotNil(t, forwarded)
+				assert.Empty(t, forwarded.Header.Get("Authorization"))
+				assert.Equal(t, http.StatusOK, rec.Code)
+			})
+
+			t.Run("mode=strict", func(t *testing.T) {
+				rec, forwarded := serveCheckOry(t, &config{pathPrefix: "/.ory", strictSession: true}, tc.endpoint, httptest.NewRequest("GET", "/", nil))
+				assert.Nil(t, forwarded)
+				assert.Equal(t, http.StatusBadGateway, rec.Code)
+				assert.Contains(t, rec.Header().Get("Content-Type"), "application/json")
+				assert.Contains(t, rec.Body.String(), "Ory is unreachable")
+			})
+		})
+	}
+
+	t.Run("case=inactive sessions are forwarded in strict mode", func(t *testing.T) {
+		rec, forwarded := serveCheckOry(t, &config{pathPrefix: "/.ory", strictSession: true}, newWhoamiServer(t, `{"active":false}`).url, httptest.NewRequest("GET", "/", nil))
+		require.NotNil(t, forwarded)
+		assert.Empty(t, forwarded.Header.Get("Authorization"))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestCheckOryCredentials(t *testing.T) {
+	ws := newWhoamiServer(t, testSession)
+	conf := &config{
+		pathPrefix: "/.ory",
+		credentials: []credentialRule{
+			{prefix: "/api", credential: credentialSessionToken},
+			{prefix: "/api/public", credential: credentialNone},
+		},
+	}
+
+	for _, tc := range []struct {
+		path, expected string
+	}{
+		{path: "/", expected: credentialJWT},
+		{path: "/api/private", expected: credentialSessionToken},
+		{path: "/api/public/assets", expected: credentialNone},
+		{path: "/.ory/sessions/whoami", expected: credentialNone},
+	} {
+		t.Run("path="+tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.expected, conf.credentialFor(tc.path))
+
+			before := ws.Calls()
+			r := httptest.NewRequest("GET", tc.path, nil)
+			r.AddCookie(&http.Cookie{Name: "ory_session_test", Value: "the-session-token"})
+			_, forwarded := serveCheckOry(t, conf, ws.url, r)
+			require.NotNil(t, forwarded)
+
+			switch tc.expected {
+			case credentialJWT:
+				assert.Regexp(t, "^Bearer ey", forwarded.Header.Get("Authorization"))
+				assert.Empty(t, forwarded.Header.Get("X-Session-Token"))
+				assert.Equal(t, before+1, ws.Calls())
+			case credentialSessionToken:
+				assert.Empty(t, forwarded.Header.Get("Authorization"))
+				assert.Empty(t, forwarded.Header.Get("X-Session-Token"), "session cookies must not become session tokens")
+				assert.Equal(t, before+1, ws.Calls())
+			case credentialNone:
+				assert.Empty(t, forwarded.Header.Get("Authorization"))
+				assert.Empty(t, forwarded.Header.Get("X-Session-Token"))
+				assert.Equal(t, before, ws.Calls(), "whoami must not be called")
+			}
+		})
+	}
+
+	t.Run("case=forwards the session token sent by the client", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/api/private", nil)
+		r.Header.Set("Authorization", "Bearer the-session-token")
+		_, forwarded := serveCheckOry(t, conf, ws.url, r)
+		require.NotNil(t, forwarded)
+		assert.Equal(t, "the-session-token", forwarded.Header.Get("X-Session-Token"))
+	})
+
+	t.Run("case=no-jwt defaults to no credential", func(t *testing.T) {
+		conf := &config{noJWT: true, credentials: []credentialRule{{prefix: "/api", credential: credentialSessionToken}}}
+		assert.Equal(t, credentialNone, conf.credentialFor("/"))
+		assert.Equal(t, credentialSessionToken, conf.credentialFor("/api"))
+	})
+}
+
+func TestSessionTokenHeader(t *testing.T) {
+	var received string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("X-Session-Token")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(testSession))
+	}))
+	t.Cleanup(ts.Close)
+
+	conf := &config{
+		pathPrefix:         "/.ory",
+		sessionTokenHeader: "X-App-Session",
+		credentials:        []credentialRule{{prefix: "/api", credential: credentialSessionToken}},
+	}
+
+	r := httptest.NewRequest("GET", "/api/items", nil)
+	r.Header.Set("X-App-Session", "the-session-token")
+	_, forwarded := serveCheckOry(t, conf, urlx.ParseOrPanic(ts.URL), r)
+	require.NotNil(t, forwarded)
+	assert.Equal(t, "the-session-token", received)
+	assert.Equal(t, "the-session-token", forwarded.Header.Get("X-Session-Token"))
+
+	t.Run("case=x-session-token takes precedence", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/api/items", nil)
+		r.Header.Set("X-App-Session", "the-custom-token")
+		r.Header.Set("X-Session-Token", "the-session-token")
+		_, forwarded := serveCheckOry(t, conf, urlx.ParseOrPanic(ts.URL), r)
+		require.NotNil(t, forwarded)
+		assert.Equal(t, "the-session-token", received)
+	})
+}
+
+func TestJWTTTL(t *testing.T) {
+	ws := newWhoamiServer(t, testSession)
+
+	for _, tc := range []struct {
+		ttl, expected time.Duration
+	}{
+		{ttl: 0, expected: time.Minute},
+		{ttl: 5 * time.Minute, expected: 5 * time.Minute},
+	} {
+		t.Run(fmt.Sprintf("ttl=%s", tc.ttl), func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Cookie", "ory_session_abc=session")
+			_, forwarded := serveCheckOry(t, &config{pathPrefix: "/.ory", jwtTTL: tc.ttl}, ws.url, r)
+			require.NotNil(t, forwarded)
+
+			token, err := jwt.ParseSigned(strings.TrimPrefix(forwarded.Header.Get("Authorization"), "Bearer "))
+			require.NoError(t, err)
+			var claims jwt.Claims
+			require.NoError(t, token.UnsafeClaimsWithoutVerification(&claims))
+			assert.Equal(t, tc.expected, claims.Expiry.Time().Sub(claims.IssuedAt.Time()))
+		})
+	}
+
+	assert.Error(t, validateJWTTTL(0))
+	assert.Error(t, validateJWTTTL(-time.Second))
+	assert.NoError(t, validateJWTTTL(30*time.Second))
+}
+
+func TestJWTClaims(t *testing.T) {
+	t.Run("case=parses claims", func(t *testing.T) {
+		claims, err := parseJWTClaims([]string{"tenant=acme", "query=a=b", "empty="})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"tenant": "acme", "query": "a=b", "empty": ""}, claims)
+	})
+
+	for _, tc := range []struct {
+		values   []string
+		expected string
+	}{
+		{values: []string{"tenant"}, expected: "must be in format of"},
+		{values: []string{"=acme"}, expected: "must be in format of"},
+		{values: []string{"sub=someone-else"}, expected: "the claim sub is set by the proxy"},
+		{values: []string{"session=forged"}, expected: "the claim session is set by the proxy"},
+		{values: []string{"tenant=a", "tenant=b"}, expected: "set more than once"},
+	} {
+		t.Run("case=rejects "+strings.Join(tc.values, " "), func(t *testing.T) {
+			_, err := parseJWTClaims(tc.values)
+			assert.ErrorContains(t, err, tc.expected)
+		})
+	}
+
+	t.Run("case=minted tokens carry the audience and claims", func(t *testing.T) {
+		conf := &config{pathPrefix: "/.ory", jwtAudience: []string{"api-a", "api-b"}, jwtClaims: map[string]string{"tenant": "acme"}}
+		_, forwarded := serveCheckOry(t, conf, newWhoamiServer(t, testSession).url, httptest.NewRequest("GET", "/", nil))
+		require.NotNil(t, forwarded)
+
+		token, err := jwt.ParseSigned(strings.TrimPrefix(forwarded.Header.Get("Authorization"), "Bearer "))
+		require.NoError(t, err)
+		var claims jwt.Claims
+		var custom struct {
+			Tenant  string          `json:"tenant"`
+			Session json.RawMessage `json:"session"`
+		}
+		require.NoError(t, token.UnsafeClaimsWithoutVerification(&claims, &custom))
+		assert.Equal(t, jwt.Audience{"api-a", "api-b"}, claims.Audience)
+		assert.Equal(t, "a2a9a3b5-6c9b-4d5a-9a5e-1c3b21c6a2b1", claims.Subject)
+		assert.Equal(t, "acme", custom.Tenant)
+		assert.JSONEq(t, testSession, string(custom.Session))
+	})
+}
+
+func TestJWTAlgorithm(t *testing.T) {
+	for _, alg := range jwtAlgorithms {
+		t.Run("alg="+alg, func(t *testing.T) {
+			conf := &config{jwtAlgorithm: alg}
+			signer, keys, err := newSigner(logrusx.New("test", "test"), conf)
+			require.NoError(t, err)
+
+			raw, err := mintToken(signer, urlx.ParseOrPanic("https://example.com"), json.RawMessage(testSession), conf)
+			require.NoError(t, err)
+
+			token, err := jwt.ParseSigned(raw)
+			require.NoError(t, err)
+			require.Len(t, token.Headers, 1)
+			assert.Equal(t, alg, token.Headers[0].Algorithm)
+
+			published := publicKeySet(keys)
+			var claims jwt.Claims
+			require.NoError(t, token.Claims(published.Keys[0].Key, &claims))
+			assert.Equal(t, "a2a9a3b5-6c9b-4d5a-9a5e-1c3b21c6a2b1", claims.Subject)
+
+			rec, _ := serveCheckOry(t, &config{pathPrefix: "/.ory", jwtAlgorithm: alg}, newWhoamiServer(t, testSession).url, httptest.NewRequest("GET", "/.ory/jwks.json", nil))
+			require.Equal(t, http.StatusOK, rec.Code)
+			var served jose.JSONWebKeySet
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+			require.Len(t, served.Keys, 1)
+			assert.Equal(t, alg, served.Keys[0].Algorithm)
+			assert.True(t, served.Keys[0].IsPublic())
+		})
+	}
+
+	t.Run("case=unsupported", func(t *testing.T) {
+		_, _, err := newSigner(logrusx.New("test", "test"), &config{jwtAlgorithm: "HS256"})
+		assert.ErrorContains(t, err, "--jwt-algorithm")
+	})
+}
+
+func TestNoJWT(t *testing.T) {
+	conf := &config{noJWT: true, pathPrefix: "/.ory"}
+
+	signer, keys, err := newSigner(logrusx.New("test", "test"), conf)
+	require.NoError(t, err)
+	assert.Nil(t, signer)
+	assert.Empty(t, keys.Keys)
+
+	ws := newWhoamiServer(t, testSession)
+	t.Run("case=serves an empty key set", func(t *testing.T) {
+		rec, forwarded := serveCheckOry(t, conf, ws.url, httptest.NewRequest("GET", "/.ory/jwks.json", nil))
+		require.Nil(t, forwarded)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"keys":[]}`, rec.Body.String())
+	})
+
+	t.Run("case=requests are forwarded without a token", func(t *testing.T) {
+		_, forwarded := serveCheckOry(t, conf, ws.url, httptest.NewRequest("GET", "/", nil))
+		require.NotNil(t, forwarded)
+		assert.Empty(t, forwarded.Header.Get("Authorization"))
+		assert.Zero(t, ws.Calls())
+	})
+}
+
+func TestJWKSCacheControl(t *testing.T) {
+	ws := newWhoamiServer(t, testSession)
+
+	for _, p := range []string{"/.ory/jwks.json", "/.ory/proxy/jwks.json"} {
+		t.Run("path="+p, func(t *testing.T) {
+			rec, _ := serveCheckOry(t, &config{pathPrefix: "/.ory", jwksCacheMaxAge: 5 * time.Minute}, ws.url, httptest.NewRequest("GET", p, nil))
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "public, max-age=300", rec.Header().Get("Cache-Control"))
+		})
+	}
+
+	t.Run("case=not set by default", func(t *testing.T) {
+		rec, _ := serveCheckOry(t, &config{pathPrefix: "/.ory"}, ws.url, httptest.NewRequest("GET", "/.ory/jwks.json", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Cache-Control"))
+	})
+}
+
+func TestBypassPaths(t *testing.T) {
+	ws := newWhoamiServer(t, testSession)
+	conf := &config{pathPrefix: "/.ory", bypassPaths: []string{"/__vite_ping", "/.ory/jwks.json"}}
+
+	assert.True(t, conf.bypasses("/__vite_ping"))
+	assert.False(t, conf.bypasses("/"))
+	assert.False(t, conf.bypasses("/.ory/jwks.json"), "Ory paths take precedence")
+
+	_, forwarded := serveCheckOry(t, conf, ws.url, httptest.NewRequest("GET", "/__vite_ping", nil))
+	require.NotNil(t, forwarded)
+	assert.Empty(t, forwarded.Header.Get("Authorization"))
+	assert.Zero(t, ws.Calls())
+
+	rec, forwarded := serveCheckOry(t, conf, ws.url, httptest.NewRequest("GET", "/.ory/jwks.json", nil))
+	assert.Nil(t, forwarded)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	_, forwarded = serveCheckOry(t, conf, ws.url, httptest.NewRequest("GET", "/dashboard", nil))
+	require.NotNil(t, forwarded)
+	assert.NotEmpty(t, forwarded.Header.Get("Authorization"))
+	assert.Equal(t, 1, ws.Calls())
+}
+
+func TestRetryAfterBackoff(t *testing.T) {
+	rateLimited := func(retryAfter string) *http.Response {
+		res := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+		if len(retryAfter) > 0 {
+			res.Header.Set("Retry-After", retryAfter)
+		}
+		return res
+	}
+
+	assert.Equal(t, 2*time.Second, retryAfterBackoff(time.Millisecond, 5*time.Millisecond, 0, rateLimited("2")))
+	assert.Equal(t, maxRetryAfter, retryAfterBackoff(time.Millisecond, 5*time.Millisecond, 0, rateLimited("3600")))
+	assert.Equal(t, maxRetryAfter, retryAfterBackoff(time.Millisecond, 5*time.Millisecond, 0, rateLimited(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))))
+	assert.Equal(t, 2*time.Millisecond, retryAfterBackoff(time.Millisecond, 5*time.Millisecond, 1, rateLimited("")))
+	assert.Equal(t, 2*time.Millisecond, retryAfterBackoff(time.Millisecond, 5*time.Millisecond, 1, rateLimited("soon")))
+}
+
+func TestCheckSessionRateLimited(t *testing.T) {
+	newServer := func(t *testing.T, h http.HandlerFunc) *url.URL {
+		ts := httptest.NewServer(h)
+		t.Cleanup(ts.Close)
+		u, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+		return u
+	}
+
+	t.Run("case=without retry-after", func(t *testing.T) {
+		var calls int32
+		u := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+		_, err := checkSession(newSessionClient(defaultSessionConfig()), httptest.NewRequest("GET", "/", nil), u, defaultRequestIDHeader, "")
+		var rateLimited *errSessionRateLimited
+		require.ErrorAs(t, err, &rateLimited)
+		assert.Equal(t, "Ory rate limited the session check", err.Error())
+		assert.EqualValues(t, 6, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("case=with retry-after", func(t *testing.T) {
+		var calls int32
+		u := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			_, _ = w.Write([]byte(testSession))
+		})
+
+		start := time.Now()
+		session, err := checkSession(newSessionClient(defaultSessionConfig()), httptest.NewRequest("GET", "/", nil), u, defaultRequestIDHeader, "")
+		require.NoError(t, err)
+		assert.JSONEq(t, testSession, string(session))
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("case=honors retry-after only once", func(t *testing.T) {
+		var calls int32
+		u := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+		start := time.Now()
+		_, err := checkSession(newSessionClient(defaultSessionConfig()), httptest.NewRequest("GET", "/", nil), u, defaultRequestIDHeader, "")
+		var rateLimited *errSessionRateLimited
+		require.ErrorAs(t, err, &rateLimited)
+		assert.Equal(t, "Ory rate limited the session check, retry after 1", err.Error())
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	})
+}
+
+func defaultSessionConfig() *config {
+	return &config{
+		sessionRetryMax:       defaultSessionRetryMax,
+		sessionRetryWait:      defaultSessionRetryWait,
+		sessionConnectTimeout: defaultSessionConnectTimeout,
+	}
+}
+
+func TestSessionClientFlags(t *testing.T) {
+	t.Run("case=defaults", func(t *testing.T) {
+		cmd := NewProxyCommand("ory", "test")
+		conf := new(config)
+		require.NoError(t, sessionClientFromFlags(cmd, conf))
+		assert.Equal(t, defaultSessionConfig(), conf)
+	})
+
+	t.Run("case=retries as often as configured", func(t *testing.T) {
+		var calls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		t.Cleanup(ts.Close)
+		u, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+
+		cmd := NewTunnelCommand("ory", "test")
+		require.NoError(t, cmd.ParseFlags([]string{"--session-retry-max", "1", "--session-retry-wait", "1ms"}))
+		conf := new(config)
+		require.NoError(t, sessionClientFromFlags(cmd, conf))
+
+		_, _ = checkSession(newSessionClient(conf), httptest.NewRequest("GET", "/", nil), u, defaultRequestIDHeader, "")
+		assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	})
+
+	for _, args := range [][]string{
+		{"--session-retry-max", "-1"},
+		{"--session-retry-wait", "-1s"},
+		{"--session-connect-timeout", "0s"},
+	} {
+		t.Run("case=rejects "+strings.Join(args, " "), func(t *testing.T) {
+			cmd := NewProxyCommand("ory", "test")
+			require.NoError(t, cmd.ParseFlags(args))
+			assert.ErrorContains(t, sessionClientFromFlags(cmd, new(config)), args[0])
+		})
+	}
+}
+
+func TestSetForwardedHeaders(t *testing.T) {
+	for _, tc := range []struct {
+		name, publicURL, existing, expected string
+		rewriteHost, trusted, disabled      bool
+	}{
+		{name: "port of the public url", publicURL: "http://localhost:4000", expected: "4000"},
+		{name: "default https port", publicURL: "https://app.example.com", expected: "443"},
+		{name: "default http port", publicURL: "http://app.example.com", expected: "80"},
+		{name: "untrusted port is replaced", publicURL: "http://localhost:4000", existing: "8443", expected: "4000"},
+		{name: "trusted port is kept", publicURL: "http://localhost:4000", existing: "8443", trusted: true, expected: "8443"},
+		{name: "trusted without port", publicURL: "http://localhost:4000", trusted: true, expected: "4000"},
+		{name: "with rewrite host", publicURL: "https://app.example.com:8443", rewriteHost: true, expected: "8443"},
+		{name: "disabled", publicURL: "http://localhost:4000", disabled: true},
+		{name: "disabled keeps the forwarded host", publicURL: "http://localhost:4000", rewriteHost: true, disabled: true},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			publicURL, err := url.Parse(tc.publicURL)
+			require.NoError(t, err)
+
+			r := httptest.NewRequest("GET", "http://localhost:3000/", nil)
+			r.Host = "app.example.com"
+			if len(tc.existing) > 0 {
+				r.Header.Set("X-Forwarded-Port", tc.existing)
+			}
+
+			setForwardedHeaders(r, &config{publicURL: publicURL, rewriteHost: tc.rewriteHost, trustForwardedFor: tc.trusted, noForwardedPort: tc.disabled})
+			assert.Equal(t, tc.expected, r.Header.Get("X-Forwarded-Port"))
+			if tc.rewriteHost {
+				assert.Equal(t, "app.example.com", r.Header.Get("X-Forwarded-Host"))
+			} else {
+				assert.Empty(t, r.Header.Get("X-Forwarded-Host"))
+			}
+		})
+	}
+}
+
+func TestProxyForwardedHeaders(t *testing.T) {
+	ory, upstream := newEchoServer(t), newHeaderEchoServer(t, "X-Forwarded-Port", "X-Forwarded-Host")
+	spoofed := http.Header{"X-Forwarded-Port": {"1337"}}
+
+	for _, tc := range []struct {
+		name     string
+		conf     *config
+		header   http.Header
+		expected string
+	}{
+		{name: "public port", conf: &config{publicURL: urlx.ParseOrPanic("https://app.example.com:8443")}, expected: "X-Forwarded-Port=8443\nX-Forwarded-Host=\n"},
+		{name: "spoofed port is replaced", conf: &config{}, header: spoofed, expected: "X-Forwarded-Port=4000\nX-Forwarded-Host=\n"},
+		{name: "trusted port is kept", conf: &config{trustForwardedFor: true}, header: spoofed, expected: "X-Forwarded-Port=1337\nX-Forwarded-Host=\n"},
+		{name: "rewrite host", conf: &config{rewriteHost: true, noForwardedPort: true}, expected: "X-Forwarded-Port=\nX-Forwarded-Host=app.example.com\n"},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			tc.conf.pathPrefix, tc.conf.oryURL = "/.ory", ory
+			header := http.Header{"Host": {"app.example.com"}}
+			for k, v := range tc.header {
+				header[k] = v
+			}
+			assert.Equal(t, tc.expected, serveProxyWithHeader(t, tc.conf, upstream, "/", header))
+		})
+	}
+}
+
+func TestSetForwardedFor(t *testing.T) {
+	var received http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	t.Cleanup(upstream.Close)
+	target := urlx.ParseOrPanic(upstream.URL)
+
+	for _, tc := range []struct {
+		name, inbound, expectedFor, expectedRealIP string
+		trust                                      bool
+	}{
+		{name: "no inbound header", expectedFor: "192.0.2.1", expectedRealIP: "192.0.2.1"},
+		{name: "replaces the inbound header", inbound: "203.0.113.7", expectedFor: "192.0.2.1", expectedRealIP: "192.0.2.1"},
+		{name: "keeps a trusted inbound header", inbound: "203.0.113.7, 198.51.100.2", trust: true, expectedFor: "203.0.113.7, 198.51.100.2, 192.0.2.1", expectedRealIP: "198.51.100.2"},
+		{name: "ignores entries spoofed by the client", inbound: "10.0.0.1, 203.0.113.7", trust: true, expectedFor: "10.0.0.1, 203.0.113.7, 192.0.2.1", expectedRealIP: "203.0.113.7"},
+		{name: "trusts a missing inbound header", trust: true, expectedFor: "192.0.2.1", expectedRealIP: "192.0.2.1"},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = "192.0.2.1:51234"
+			if len(tc.inbound) > 0 {
+				r.Header.Set("X-Forwarded-For", tc.inbound)
+			}
+
+			setForwardedFor(r, tc.trust)
+			httputil.NewSingleHostReverseProxy(target).ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Equal(t, tc.expectedFor, received.Get("X-Forwarded-For"))
+			assert.Equal(t, tc.expectedRealIP, received.Get("X-Real-IP"))
+		})
+	}
+}
+
+func TestCheckOryOptions(t *testing.T) {
+	ws := newWhoamiServer(t, testSession)
+	conf := &config{pathPrefix: "/.ory"}
+
+	r := httptest.NewRequest("OPTIONS", "/api/items", nil)
+	r.Header.Set("Cookie", "ory_session_abc=session")
+	_, forwarded := serveCheckOry(t, conf, ws.url, r)
+	require.NotNil(t, forwarded)
+	assert.Empty(t, forwarded.Header.Get("Authorization"))
+	assert.Zero(t, ws.Calls())
+}
+
+func TestRedirectExpiredSessions(t *testing.T) {
+	const (
+		unauthorized = `{"error":{"code":401,"status":"Unauthorized"}}`
+		expired      = `{"active":false,"expires_at":"2020-01-01T00:00:00Z"}`
+	)
+	publicURL := urlx.ParseOrPanic("http://localhost:4000")
+
+	for _, tc := range []struct {
+		name     string
+		session  string
+		cookie   string
+		accept   string
+		method   string
+		redirect bool
+	}{
+		{name: "active session", session: testSession, cookie: "ory_session_abc=session", accept: "text/html"},
+		{name: "no session", session: unauthorized, accept: "text/html"},
+		{name: "rejected session cookie", session: unauthorized, cookie: "ory_session_abc=session", accept: "text/html,application/xhtml+xml", redirect: true},
+		{name: "expired session", session: expired, accept: "text/html", redirect: true},
+		{name: "expired session on api request", session: expired, cookie: "ory_session_abc=session", accept: "application/json"},
+		{name: "expired session on form post", session: expired, cookie: "ory_session_abc=session", accept: "text/html", method: "POST"},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			ws := newWhoamiServer(t, tc.session)
+			conf := &config{pathPrefix: "/.ory", publicURL: publicURL, redirectExpired: true}
+
+			method := tc.method
+			if method == "" {
+				method = "GET"
+			}
+			r := httptest.NewRequest(method, "/dashboard?tab=1", nil)
+			r.Header.Set("Accept", tc.accept)
+			if tc.cookie != "" {
+				r.Header.Set("Cookie", tc.cookie)
+			}
+
+			rec, forwarded := serveCheckOry(t, conf, ws.url, r)
+			if !tc.redirect {
+				assert.NotNil(t, forwarded)
+				return
+			}
+
+			assert.Nil(t, forwarded)
+			assert.Equal(t, http.StatusSeeOther, rec.Code)
+			assert.Equal(t, "http://localhost:4000/.ory/self-service/login/browser?return_to="+url.QueryEscape("http://localhost:4000/dashboard?tab=1"), rec.Header().Get("Location"))
+		})
+	}
+
+	t.Run("case=disabled", func(t *testing.T) {
+		ws := newWhoamiServer(t, expired)
+		conf := &config{pathPrefix: "/.ory", publicURL: publicURL}
+
+		r := httptest.NewRequest("GET", "/dashboard", nil)
+		r.Header.Set("Accept", "text/html")
+		_, forwarded := serveCheckOry(t, conf, ws.url, r)
+		require.NotNil(t, forwarded)
+		assert.Empty(t, forwarded.Header.Get("Authorization"))
+	})
+}
+
+func TestWhoamiURL(t *testing.T) {
+	for base, expected := range map[string]string{
+		"https://my-project.projects.oryapis.com":      "https://my-project.projects.oryapis.com/api/kratos/public/sessions/whoami",
+		"https://my-project.projects.oryapis.com/":     "https://my-project.projects.oryapis.com/api/kratos/public/sessions/whoami",
+		"https://auth.example.com/ory/":                "https://auth.example.com/ory/api/kratos/public/sessions/whoami",
+		"https://auth.example.com/ory//nested?foo=bar": "https://auth.example.com/ory/nested/api/kratos/public/sessions/whoami?foo=bar",
+	} {
+		t.Run("base="+base, func(t *testing.T) {
+			u, err := url.Parse(base)
+			require.NoError(t, err)
+			actual := whoamiURL(u)
+			assert.Equal(t, expected, actual.String())
+			assert.NotContains(t, actual.Path, `\`)
+			assert.Equal(t, base, u.String(), "the target must not be modified")
+		})
+	}
+}
+
+func TestCheckOryLogsTokenSize(t *testing.T) {
+	ws := newWhoamiServer(t, testSession)
+	conf := &config{pathPrefix: "/.ory"}
+
+	hook := test.NewGlobal()
+	l := logrusx.New("test", "test", logrusx.ForceLevel(logrus.DebugLevel), logrusx.WithHook(hook))
+	signer, keys, err := newSigner(l, conf)
+	require.NoError(t, err)
+
+	var forwarded *http.Request
+	checkOry(conf, l, herodot.NewJSONWriter(l), keys, signer, ws.url)(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r
+	})
+	require.NotNil(t, forwarded)
+
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, logrus.DebugLevel, entry.Level)
+	assert.Equal(t, len(strings.TrimPrefix(forwarded.Header.Get("Authorization"), "Bearer ")), entry.Data["jwt_bytes"])
+	assert.Equal(t, len(testSession), entry.Data["session_bytes"])
+	assert.NotContains(t, entry.Message, "a2a9a3b5")
+}
+
+// newEchoServer returns a server which responds with the escaped path and query it received.
+func newEchoServer(t *testing.T) *url.URL {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.EscapedPath() + "?" + r.URL.RawQuery))
+	}))
+	t.Cleanup(ts.Close)
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	return u
+}
+
+// newHeaderEchoServer returns a server which responds with the values of the headers it received, one per line.
+func newHeaderEchoServer(t *testing.T, names ...string) *url.URL {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, name := range names {
+			_, _ = fmt.Fprintf(w, "%s=%s\n", name, r.Header.Get(name))
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	return u
+}
+
+// serveProxy sends the request through the proxy handler and returns the response body.
+func serveProxy(t *testing.T, conf *config, upstream *url.URL, target string) string {
+	return serveProxyWithHeader(t, conf, upstream, target, nil)
+}
+
+// serveProxyWithHeader behaves like serveProxy, but sends the request with the header. The Host header sets the host
+// of the request.
+func serveProxyWithHeader(t *testing.T, conf *config, upstream *url.URL, target string, header http.Header) string {
+	if conf.publicURL == nil {
+		conf.publicURL = urlx.ParseOrPanic("http://localhost:4000")
+	}
+
+	ts := httptest.NewServer(newProxyHandler(conf, logrusx.New("test", "test"), upstream, ""))
+	t.Cleanup(ts.Close)
+
+	req, err := http.NewRequest("GET", ts.URL+target, nil)
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if host := header.Get("Host"); len(host) > 0 {
+		req.Host = host
+	}
+
+	res, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestProxyQueryParameters(t *testing.T) {
+	ory, upstream := newEchoServer(t), newEchoServer(t)
+	conf := &config{pathPrefix: "/.ory", oryURL: ory}
+
+	assert.Equal(t, "/self-service/login/browser?foo=a&foo=b&return_to=%2Fdashboard%3Fx%3D1",
+		serveProxy(t, conf, upstream, "/.ory/self-service/login/browser?foo=a&foo=b&return_to=%2Fdashboard%3Fx%3D1"))
+	assert.Equal(t, "/dashboard?foo=a&foo=b&bar=",
+		serveProxy(t, conf, upstream, "/dashboard?foo=a&foo=b&bar="))
+}
+
+func TestMaxHeaderBytes(t *testing.T) {
+	serve := func(t *testing.T, maxHeaderBytes int) int {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		server := newServer(&config{maxHeaderBytes: maxHeaderBytes}, ln.Addr().String(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		go func() { _ = server.Serve(ln) }()
+		t.Cleanup(func() { _ = server.Close() })
+
+		req, err := http.NewRequest("GET", "http://"+ln.Addr().String()+"/", nil)
+		require.NoError(t, err)
+		req.Header.Set("Cookie", "ory_session_abc="+strings.Repeat("a", 2<<20))
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		return res.StatusCode
+	}
+
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, serve(t, http.DefaultMaxHeaderBytes))
+	assert.Equal(t, http.StatusNoContent, serve(t, 4<<20))
+}
+
+func TestProxyForwardsAcceptLanguage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	t.Cleanup(ts.Close)
+	ory := urlx.ParseOrPanic(ts.URL)
+
+	conf := &config{pathPrefix: "/.ory", oryURL: ory, publicURL: urlx.ParseOrPanic("http://localhost:4000")}
+	proxyServer := httptest.NewServer(newProxyHandler(conf, logrusx.New("test", "test"), newEchoServer(t), ""))
+	t.Cleanup(proxyServer.Close)
+
+	req, err := http.NewRequest("GET", proxyServer.URL+"/.ory/self-service/login/browser", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Language", "de-CH, de;q=0.9, en;q=0.8")
+
+	res, err := proxyServer.Client().Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "de-CH, de;q=0.9, en;q=0.8", string(body))
+}
+
+func TestProxyPreservesEncodedPath(t *testing.T) {
+	ory, upstream := newEchoServer(t), newEchoServer(t)
+	conf := &config{pathPrefix: "/.ory", oryURL: ory}
+
+	assert.Equal(t, "/api/kratos/public/schemas/preset%3A%2F%2Femail?",
+		serveProxy(t, conf, upstream, "/.ory/api/kratos/public/schemas/preset%3A%2F%2Femail"))
+	assert.Equal(t, "/self-service/login/browser?",
+		serveProxy(t, conf, upstream, "/.ory/self-service/login/browser"))
+	assert.Equal(t, "/files/a%2Fb?",
+		serveProxy(t, conf, upstream, "/files/a%2Fb"))
+}
+
+func TestNewUpstreamTransport(t *testing.T) {
+	assert.Equal(t, http.DefaultTransport, newUpstreamTransport(&config{}))
+
+	transport, ok := newUpstreamTransport(&config{upstreamMaxConns: 4}).(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 4, transport.MaxConnsPerHost)
+	assert.Equal(t, 4, transport.MaxIdleConnsPerHost)
+	assert.NotSame(t, http.DefaultTransport, transport)
+}
+
+func BenchmarkUpstreamMaxConns(b *testing.B) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	b.Cleanup(ts.Close)
+
+	for _, conns := range []int{0, 1, 4, 16} {
+		b.Run(fmt.Sprintf("conns=%d", conns), func(b *testing.B) {
+			c := &http.Client{Transport: newUpstreamTransport(&config{upstreamMaxConns: conns})}
+			b.SetParallelism(16)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					res, err := c.Get(ts.URL)
+					if err != nil {
+						b.Fatal(err)
+					}
+					_ = res.Body.Close()
+				}
+			})
+		})
+	}
+}
+
+func TestDefaultPublicURL(t *testing.T) {
+	for host, expected := range map[string]string{
+		"":          "http://localhost:4000",
+		"0.0.0.0":   "http://localhost:4000",
+		"::":        "http://localhost:4000",
+		"127.0.0.1": "http://127.0.0.1:4000",
+		"::1":       "http://[::1]:4000",
+		"dev.local": "http://dev.local:4000",
+	} {
+		assert.Equal(t, expected, defaultPublicURL("http", host, 4000), host)
+	}
+	assert.Equal(t, "https://localhost:4443", defaultPublicURL("https", "", 4443))
+}
+
+func TestMountPath(t *testing.T) {
+	for in, expected := range map[string]string{"/.ory": "/.ory", "/_auth": "/_auth", "/_auth/": "/_auth", "/a//b": "/a/b"} {
+		actual, err := parseMountPath(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, expected, actual)
+	}
+	for _, in := range []string{"", "_auth", "/", "//"} {
+		_, err := parseMountPath(in)
+		assert.Error(t, err, in)
+	}
+
+	ory, upstream := newNamedServer(t, "ory"), newNamedServer(t, "app")
+	conf := &config{pathPrefix: "/_auth", oryURL: ory}
+	assert.Equal(t, "ory /self-service/login/browser", serveProxy(t, conf, upstream, "/_auth/self-service/login/browser"))
+	assert.Equal(t, "app /.ory/self-service/login/browser", serveProxy(t, conf, upstream, "/.ory/self-service/login/browser"))
+
+	ws := newWhoamiServer(t, testSession)
+	rec, _ := serveCheckOry(t, conf, ws.url, httptest.NewRequest("GET", "/_auth/jwks.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	conf.publicURL = urlx.ParseOrPanic("http://localhost:4000")
+	assert.True(t, strings.HasPrefix(loginURL(conf, httptest.NewRequest("GET", "/", nil)), "http://localhost:4000/_auth/self-service/login/browser?"))
+}
+
+func TestDisableHSTS(t *testing.T) {
+	rec := httptest.NewRecorder()
+	called := false
+	disableHSTS(rec, httptest.NewRequest("GET", "/", nil), func(http.ResponseWriter, *http.Request) { called = true })
+	assert.True(t, called)
+	assert.Equal(t, "max-age=0;", rec.Header().Get("Strict-Transport-Security"))
+
+	cmd := NewProxyCommand("ory", "test")
+	assert.False(t, flagx.MustGetBool(cmd, KeepHSTSFlag), "HSTS is disabled by default")
+}
+
+func TestShutdownServer(t *testing.T) {
+	t.Run("case=waits for requests in flight", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		}))
+		t.Cleanup(server.Close)
+
+		go func() { _, _ = http.Get(server.URL) }()
+		<-started
+		time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+		var cleaned bool
+		require.NoError(t, shutdownServer(server.Config, time.Second, func(context.Context) error {
+			cleaned = true
+			return nil
+		}))
+		assert.True(t, cleaned)
+	})
+
+	t.Run("case=runs the cleanup after the timeout", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		}))
+		t.Cleanup(func() {
+			close(release)
+			server.Close()
+		})
+
+		go func() { _, _ = http.Get(server.URL) }()
+		<-started
+
+		var cleanupCtxErr error
+		err := shutdownServer(server.Config, 20*time.Millisecond, func(ctx context.Context) error {
+			cleanupCtxErr = ctx.Err()
+			return nil
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.NoError(t, cleanupCtxErr, "the cleanup must get a fresh deadline")
+	})
+}
+
+func TestListen(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = taken.Close() })
+	port := taken.Addr().(*net.TCPAddr).Port
+
+	t.Run("case=names the port in use", func(t *testing.T) {
+		_, err := listen(taken.Addr().String(), port, false, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), fmt.Sprintf("port %d is already in use", port))
+		assert.Contains(t, err.Error(), "--"+PortFlag)
+	})
+
+	t.Run("case=mentions the PORT environment variable", func(t *testing.T) {
+		_, err := listen(taken.Addr().String(), port, true, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "set by the PORT environment variable")
+	})
+
+	t.Run("case=picks a free port with auto", func(t *testing.T) {
+		ln, err := listen(taken.Addr().String(), port, false, true)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = ln.Close() })
+		assert.NotEqual(t, port, ln.Addr().(*net.TCPAddr).Port)
+	})
+
+	t.Run("case=detects the address in use", func(t *testing.T) {
+		_, err := net.Listen("tcp", taken.Addr().String())
+		assert.True(t, isAddrInUse(err))
+		assert.False(t, isAddrInUse(errors.New("some other error")))
+		assert.False(t, isAddrInUse(nil))
+	})
+
+	t.Run("case=binds to a free port", func(t *testing.T) {
+		ln, err := listen("127.0.0.1:0", 0, false, false)
+		require.NoError(t, err)
+		assert.NoError(t, ln.Close())
+	})
+}
+
+func TestUsePort(t *testing.T) {
+	t.Run("case=derived urls", func(t *testing.T) {
+		conf := &config{
+			port:              4000,
+			publicURL:         urlx.ParseOrPanic("http://localhost:4000"),
+			defaultRedirectTo: urlx.ParseOrPanic("http://localhost:4000"),
+		}
+		conf.portURLs = []*url.URL{conf.publicURL, conf.defaultRedirectTo}
+		conf.usePort(4001)
+
+		assert.Equal(t, 4001, conf.port)
+		assert.Equal(t, "http://localhost:4001", conf.publicURL.String())
+		assert.Equal(t, "http://localhost:4001", conf.defaultRedirectTo.String())
+	})
+
+	t.Run("case=urls passed by the user", func(t *testing.T) {
+		conf := &config{
+			port:              4000,
+			publicURL:         urlx.ParseOrPanic("http://localhost:4000"),
+			defaultRedirectTo: urlx.ParseOrPanic("http://localhost:4000/welcome"),
+		}
+		conf.usePort(4001)
+
+		assert.Equal(t, 4001, conf.port)
+		assert.Equal(t, "http://localhost:4000", conf.publicURL.String())
+		assert.Equal(t, "http://localhost:4000/welcome", conf.defaultRedirectTo.String())
+	})
+}
+
+func TestRewriteHost(t *testing.T) {
+	echoHost := func(t *testing.T) *url.URL {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, r.Host)
+		}))
+		t.Cleanup(ts.Close)
+		return urlx.ParseOrPanic(ts.URL)
+	}
+	ory, upstream := echoHost(t), echoHost(t)
+
+	for _, tc := range []struct {
+		name        string
+		rewriteHost bool
+		path        string
+		expected    func(proxyHost string) string
+	}{
+		{name: "keeps the host", path: "/dashboard", expected: func(proxyHost string) string { return proxyHost }},
+		{name: "rewrites the host", rewriteHost: true, path: "/dashboard", expected: func(string) string { return upstream.Host }},
+		{name: "rewrites the host of bypassed paths", rewriteHost: true, path: "/public/app.js", expected: func(string) string { return upstream.Host }},
+		{name: "always uses the host of Ory", path: "/.ory/sessions/whoami", expected: func(string) string { return ory.Host }},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			conf := &config{
+				pathPrefix:  "/.ory",
+				oryURL:      ory,
+				bypassPaths: []string{"/public"},
+				publicURL:   urlx.ParseOrPanic("http://localhost:4000"),
+				rewriteHost: tc.rewriteHost,
+			}
+
+			ts := httptest.NewServer(newProxyHandler(conf, logrusx.New("test", "test"), upstream, ""))
+			t.Cleanup(ts.Close)
+
+			res, err := ts.Client().Get(ts.URL + tc.path)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected(urlx.ParseOrPanic(ts.URL).Host), string(body))
+		})
+	}
+}
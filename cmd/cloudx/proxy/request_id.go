@@ -0,0 +1,31 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/gofrs/uuid/v3"
+)
+
+const defaultRequestIDHeader = "X-Request-Id"
+
+func (c *config) requestIDHeaderName() string {
+	if len(c.requestIDHeader) == 0 {
+		return defaultRequestIDHeader
+	}
+	return c.requestIDHeader
+}
+
+// withRequestID makes sure every request carries a request ID, so that the same ID shows up in the logs of the
+// proxy, Ory, and the application.
+func withRequestID(conf *config) func(http.ResponseWriter, *http.Request, http.HandlerFunc) {
+	header := conf.requestIDHeaderName()
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if len(r.Header.Get(header)) == 0 {
+			r.Header.Set(header, uuid.Must(uuid.NewV4()).String())
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/logrusx"
+)
+
+func TestRequestID(t *testing.T) {
+	var whoamiRequestID string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		whoamiRequestID = r.Header.Get("X-Request-Id")
+		_, _ = w.Write([]byte(testSession))
+	}))
+	t.Cleanup(ts.Close)
+	endpoint, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	serve := func(t *testing.T, conf *config, r *http.Request) *http.Request {
+		l := logrusx.New("test", "test")
+		signer, keys, err := newSigner(l, conf)
+		require.NoError(t, err)
+
+		var forwarded *http.Request
+		check := checkOry(conf, l, herodot.NewJSONWriter(l), keys, signer, endpoint)
+		withRequestID(conf)(httptest.NewRecorder(), r, func(w http.ResponseWriter, r *http.Request) {
+			check(w, r, func(w http.ResponseWriter, r *http.Request) {
+				forwarded = r
+			})
+		})
+		require.NotNil(t, forwarded)
+		return forwarded
+	}
+
+	t.Run("case=generates an id", func(t *testing.T) {
+		forwarded := serve(t, &config{pathPrefix: "/.ory"}, httptest.NewRequest("GET", "/", nil))
+		assert.NotEmpty(t, forwarded.Header.Get("X-Request-Id"))
+		assert.Equal(t, forwarded.Header.Get("X-Request-Id"), whoamiRequestID)
+	})
+
+	t.Run("case=propagates an existing id", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Request-Id", "browser-id")
+		forwarded := serve(t, &config{pathPrefix: "/.ory"}, r)
+		assert.Equal(t, "browser-id", forwarded.Header.Get("X-Request-Id"))
+		assert.Equal(t, "browser-id", whoamiRequestID)
+	})
+
+	t.Run("case=custom header", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Correlation-Id", "correlation-id")
+		forwarded := serve(t, &config{pathPrefix: "/.ory", requestIDHeader: "X-Correlation-Id"}, r)
+		assert.Equal(t, "correlation-id", forwarded.Header.Get("X-Correlation-Id"))
+		assert.Equal(t, "correlation-id", whoamiRequestID)
+	})
+}
@@ -0,0 +1,58 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/urfave/negroni"
+
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/reqlog"
+)
+
+// redactedValue replaces secrets in logs and printed configurations.
+const redactedValue = "REDACTED"
+
+// newRequestLogger logs every request and response. Credentials are always redacted, even if the logger is
+// configured to leak sensitive values, because they would allow anyone reading the logs to take over the session.
+func newRequestLogger(conf *config, l *logrusx.Logger) *reqlog.Middleware {
+	m := reqlog.NewMiddlewareFromLogger(l, "ory")
+	m.Before = func(entry *logrusx.Logger, r *http.Request, remoteAddr string) *logrusx.Logger {
+		return reqlog.DefaultBefore(entry, redactRequest(r, conf.sessionTokenHeader), remoteAddr)
+	}
+	m.After = func(entry *logrusx.Logger, r *http.Request, res negroni.ResponseWriter, latency time.Duration, name string) *logrusx.Logger {
+		return entry.WithRequest(redactRequest(r, conf.sessionTokenHeader)).WithField("http_response", map[string]interface{}{
+			"status":      res.Status(),
+			"size":        res.Size(),
+			"text_status": http.StatusText(res.Status()),
+			"took":        latency,
+			"headers":     entry.HTTPHeadersRedacted(redactHeaders(res.Header(), "Set-Cookie")),
+		})
+	}
+	return m
+}
+
+// redactRequest returns a shallow copy of the request for logging, with the credential headers redacted.
+func redactRequest(r *http.Request, sessionTokenHeader string) *http.Request {
+	names := []string{"Authorization", "Cookie", "X-Session-Token"}
+	if len(sessionTokenHeader) > 0 {
+		names = append(names, sessionTokenHeader)
+	}
+
+	redacted := *r
+	redacted.Header = redactHeaders(r.Header, names...)
+	return &redacted
+}
+
+func redactHeaders(h http.Header, names ...string) http.Header {
+	h = h.Clone()
+	for _, name := range names {
+		if len(h.Values(name)) > 0 {
+			h.Set(name, redactedValue)
+		}
+	}
+	return h
+}
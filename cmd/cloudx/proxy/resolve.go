@@ -0,0 +1,39 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// parseResolveRules parses `host:ip` pairs. The IP may be an IPv6 address.
+func parseResolveRules(values []string) (map[string]string, error) {
+	rules := make(map[string]string, len(values))
+	for _, value := range values {
+		host, ip, ok := strings.Cut(value, ":")
+		if !ok || len(host) == 0 || net.ParseIP(strings.Trim(ip, "[]")) == nil {
+			return nil, errors.Errorf("the value of --%s must be in the form host:ip but got: %s", ResolveFlag, value)
+		}
+		rules[strings.ToLower(host)] = strings.Trim(ip, "[]")
+	}
+	return rules, nil
+}
+
+// resolvingDialContext connects to the given IP instead of resolving the host.
+func resolvingDialContext(rules map[string]string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			if ip, ok := rules[strings.ToLower(host)]; ok {
+				addr = net.JoinHostPort(ip, port)
+			}
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
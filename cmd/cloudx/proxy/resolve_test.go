@@ -0,0 +1,44 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/urlx"
+)
+
+func TestParseResolveRules(t *testing.T) {
+	rules, err := parseResolveRules([]string{"host.docker.internal:192.168.65.2", "App.Local:[::1]", "v6.local:::1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"host.docker.internal": "192.168.65.2", "app.local": "::1", "v6.local": "::1"}, rules)
+
+	for _, value := range []string{"host.docker.internal", ":127.0.0.1", "app.local:not-an-ip"} {
+		_, err := parseResolveRules([]string{value})
+		assert.Error(t, err, value)
+	}
+}
+
+func TestResolvingDialContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	t.Cleanup(ts.Close)
+	port := urlx.ParseOrPanic(ts.URL).Port()
+
+	c := &http.Client{Transport: newUpstreamTransport(&config{resolve: map[string]string{"app.invalid": "127.0.0.1"}})}
+	res, err := c.Get("http://app.invalid:" + port + "/")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "app.invalid:"+port, string(body), "the Host header must not change")
+}
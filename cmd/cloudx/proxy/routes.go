@@ -0,0 +1,182 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// route sends requests whose path starts with prefix to a different upstream than the default one. With
+// stripPrefix, the prefix is removed from the path before forwarding.
+type route struct {
+	prefix      string
+	upstream    *url.URL
+	stripPrefix bool
+}
+
+type routesFile struct {
+	Routes []struct {
+		Prefix      string `yaml:"prefix"`
+		Upstream    string `yaml:"upstream"`
+		StripPrefix bool   `yaml:"strip_prefix"`
+	} `yaml:"routes"`
+}
+
+// parseRoutes builds the routing table from the routes file, if any, and the --route flags. Flags replace routes
+// from the file with the same prefix.
+func parseRoutes(file string, values []string) ([]route, error) {
+	var routes []route
+	if len(file) > 0 {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read --%s", RoutesFileFlag)
+		}
+
+		// JSON is valid YAML, so this reads both.
+		var f routesFile
+		if err := yaml.Unmarshal(raw, &f); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse --%s %s", RoutesFileFlag, file)
+		}
+
+		for k, r := range f.Routes {
+			rt, err := newRoute(r.Prefix, r.Upstream, r.StripPrefix)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "route %d in --%s %s", k, RoutesFileFlag, file)
+			}
+			routes = append(routes, rt)
+		}
+		if err := checkDuplicateRoutes(routes); err != nil {
+			return nil, errors.WithMessagef(err, "--%s %s", RoutesFileFlag, file)
+		}
+	}
+
+	flagRoutes := make([]route, 0, len(values))
+	for _, v := range values {
+		prefix, upstream, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, errors.Errorf("--%s must be in format of `/path/prefix=http://upstream` but got: %s", RouteFlag, v)
+		}
+
+		rt, err := newRoute(prefix, upstream, false)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "--%s %s", RouteFlag, v)
+		}
+		flagRoutes = append(flagRoutes, rt)
+	}
+	if err := checkDuplicateRoutes(flagRoutes); err != nil {
+		return nil, errors.WithMessagef(err, "--%s", RouteFlag)
+	}
+
+	for _, rt := range flagRoutes {
+		replaced := false
+		for k := range routes {
+			if routes[k].prefix == rt.prefix {
+				routes[k], replaced = rt, true
+			}
+		}
+		if !replaced {
+			routes = append(routes, rt)
+		}
+	}
+	return routes, nil
+}
+
+// newRoute validates a route. The upstream may carry a `strip` query parameter to strip the prefix, which is
+// removed from the upstream URL.
+func newRoute(prefix, upstream string, stripPrefix bool) (route, error) {
+	if !strings.HasPrefix(prefix, "/") {
+		return route{}, errors.Errorf("the path prefix must start with a slash but got: %q", prefix)
+	}
+
+	u, err := url.ParseRequestURI(upstream)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return route{}, errors.Errorf("the upstream must be an absolute http or https URL but got: %q", upstream)
+	}
+
+	// Only the scheme and host of the upstream are used, so a path would be silently ignored.
+	if len(strings.Trim(u.Path, "/")) > 0 {
+		return route{}, errors.Errorf("the upstream must not have a path, the request path is forwarded as is, but got: %q", upstream)
+	}
+
+	if query := u.Query(); query.Has("strip") {
+		strip, err := strconv.ParseBool(query.Get("strip"))
+		if err != nil {
+			return route{}, errors.Errorf("the strip parameter must be true or false but got: %q", query.Get("strip"))
+		}
+		stripPrefix = stripPrefix || strip
+
+		query.Del("strip")
+		u.RawQuery = query.Encode()
+	}
+
+	return route{prefix: prefix, upstream: u, stripPrefix: stripPrefix}, nil
+}
+
+// matches reports whether the path is the prefix or below it. The prefix only matches whole path segments, so that
+// /api matches /api/items but not /apiary.
+func (rt *route) matches(path string) bool {
+	if strings.HasSuffix(rt.prefix, "/") {
+		return strings.HasPrefix(path, rt.prefix)
+	}
+	return path == rt.prefix || strings.HasPrefix(path, rt.prefix+"/")
+}
+
+// strip removes the route's prefix from the request path, keeping the path absolute. Paths the prefix does not match
+// on a segment boundary are left alone.
+func (rt *route) strip(u *url.URL) {
+	if !rt.stripPrefix || !rt.matches(u.Path) {
+		return
+	}
+
+	u.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(u.Path, rt.prefix), "/")
+	if len(u.RawPath) > 0 {
+		u.RawPath = "/" + strings.TrimPrefix(strings.TrimPrefix(u.RawPath, rt.prefix), "/")
+	}
+}
+
+func checkDuplicateRoutes(routes []route) error {
+	seen := make(map[string]bool, len(routes))
+	for _, rt := range routes {
+		if seen[rt.prefix] {
+			return errors.Errorf("the path prefix %s is routed more than once", rt.prefix)
+		}
+		seen[rt.prefix] = true
+	}
+	return nil
+}
+
+// routeOverlaps describes every pair of routes where one prefix contains the other, so that users notice when a
+// route shadows parts of another.
+func routeOverlaps(routes []route) []string {
+	var overlaps []string
+	for _, a := range routes {
+		for _, b := range routes {
+			if a.prefix != b.prefix && b.matches(a.prefix) {
+				overlaps = append(overlaps, fmt.Sprintf("Requests matching %s are sent to %s instead of %s.", a.prefix, a.upstream, b.upstream))
+			}
+		}
+	}
+	sort.Strings(overlaps)
+	return overlaps
+}
+
+// routeFor returns the route with the longest prefix matching the path, or nil if the request goes to the default
+// upstream.
+func (c *config) routeFor(path string) *route {
+	var match *route
+	for k, rt := range c.routes {
+		if rt.matches(path) && (match == nil || len(rt.prefix) > len(match.prefix)) {
+			match = &c.routes[k]
+		}
+	}
+	return match
+}
@@ -0,0 +1,184 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newNamedServer responds with its name and the path it received.
+func newNamedServer(t *testing.T, name string) *url.URL {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(name + " " + r.URL.EscapedPath()))
+	}))
+	t.Cleanup(ts.Close)
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	return u
+}
+
+func writeRoutesFile(t *testing.T, name, content string) string {
+	file := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(file, []byte(content), 0600))
+	return file
+}
+
+func TestParseRoutes(t *testing.T) {
+	t.Run("case=flags", func(t *testing.T) {
+		routes, err := parseRoutes("", []string{"/api=http://localhost:8080", "/admin=https://admin.example.com"})
+		require.NoError(t, err)
+		require.Len(t, routes, 2)
+		assert.Equal(t, "/api", routes[0].prefix)
+		assert.Equal(t, "localhost:8080", routes[0].upstream.Host)
+		assert.Equal(t, "https", routes[1].upstream.Scheme)
+	})
+
+	for _, tc := range []struct{ name, content string }{
+		{name: "routes.yaml", content: "routes:\n  - prefix: /api\n    upstream: http://localhost:8080\n  - prefix: /admin\n    upstream: http://localhost:9000\n"},
+		{name: "routes.json", content: `{"routes":[{"prefix":"/api","upstream":"http://localhost:8080"},{"prefix":"/admin","upstream":"http://localhost:9000"}]}`},
+	} {
+		t.Run("file="+tc.name, func(t *testing.T) {
+			routes, err := parseRoutes(writeRoutesFile(t, tc.name, tc.content), nil)
+			require.NoError(t, err)
+			require.Len(t, routes, 2)
+			assert.Equal(t, "/api", routes[0].prefix)
+			assert.Equal(t, "localhost:9000", routes[1].upstream.Host)
+		})
+	}
+
+	t.Run("case=flags replace routes from the file", func(t *testing.T) {
+		file := writeRoutesFile(t, "routes.yaml", "routes:\n  - prefix: /api\n    upstream: http://localhost:8080\n")
+		routes, err := parseRoutes(file, []string{"/api=http://localhost:8081", "/admin=http://localhost:9000"})
+		require.NoError(t, err)
+		require.Len(t, routes, 2)
+		assert.Equal(t, "localhost:8081", routes[0].upstream.Host)
+		assert.Equal(t, "/admin", routes[1].prefix)
+	})
+
+	t.Run("case=invalid", func(t *testing.T) {
+		for _, v := range []string{"/api", "api=http://localhost:8080", "/api=localhost:8080", "/api=ftp://localhost", "/api="} {
+			_, err := parseRoutes("", []string{v})
+			assert.Error(t, err, v)
+		}
+
+		_, err := parseRoutes("", []string{"/api=http://localhost:8080", "/api=http://localhost:8081"})
+		assert.ErrorContains(t, err, "more than once")
+
+		_, err = parseRoutes(writeRoutesFile(t, "routes.yaml", "routes:\n  - prefix: /api\n    upstream: http://a\n  - prefix: /api\n    upstream: http://b\n"), nil)
+		assert.ErrorContains(t, err, "more than once")
+
+		_, err = parseRoutes(writeRoutesFile(t, "routes.yaml", "routes:\n  - prefix: /api\n    upstream: not a url\n"), nil)
+		assert.ErrorContains(t, err, "route 0")
+
+		_, err = parseRoutes("", []string{"/api=http://localhost:8080/v1"})
+		assert.ErrorContains(t, err, "must not have a path")
+
+		_, err = parseRoutes(filepath.Join(t.TempDir(), "missing.yaml"), nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestRouteOverlaps(t *testing.T) {
+	routes, err := parseRoutes("", []string{"/api=http://api", "/api/v2=http://api-v2", "/apiary=http://apiary", "/admin=http://admin"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Requests matching /api/v2 are sent to http://api-v2 instead of http://api."}, routeOverlaps(routes))
+}
+
+func TestProxyRoutes(t *testing.T) {
+	ory, upstream := newEchoServer(t), newNamedServer(t, "default")
+	routes, err := parseRoutes("", []string{
+		"/api=" + newNamedServer(t, "api").String(),
+		"/api/v2=" + newNamedServer(t, "api-v2").String(),
+		"/.ory/custom=" + newNamedServer(t, "custom").String(),
+	})
+	require.NoError(t, err)
+	conf := &config{pathPrefix: "/.ory", oryURL: ory, routes: routes}
+
+	assert.Equal(t, "default /", serveProxy(t, conf, upstream, "/"))
+	assert.Equal(t, "default /dashboard", serveProxy(t, conf, upstream, "/dashboard"))
+	assert.Equal(t, "api /api/items", serveProxy(t, conf, upstream, "/api/items"))
+	assert.Equal(t, "api-v2 /api/v2/items", serveProxy(t, conf, upstream, "/api/v2/items"))
+	assert.Equal(t, "/custom?", serveProxy(t, conf, upstream, "/.ory/custom"), "Ory paths can not be routed elsewhere")
+	assert.Equal(t, "api /api", serveProxy(t, conf, upstream, "/api"))
+	assert.Equal(t, "default /apiary", serveProxy(t, conf, upstream, "/apiary"))
+	assert.Equal(t, "default /api-docs", serveProxy(t, conf, upstream, "/api-docs"))
+	assert.Equal(t, "api /api/v2beta", serveProxy(t, conf, upstream, "/api/v2beta"))
+}
+
+func TestRouteFor(t *testing.T) {
+	routes, err := parseRoutes("", []string{"/api=http://api", "/static/=http://static", "/=http://root"})
+	require.NoError(t, err)
+	conf := &config{routes: routes}
+
+	for path, expected := range map[string]string{
+		"/api":           "/api",
+		"/api/":          "/api",
+		"/api/items":     "/api",
+		"/apiary":        "/",
+		"/api-docs":      "/",
+		"/static/":       "/static/",
+		"/static/app.js": "/static/",
+		"/static":        "/",
+		"/":              "/",
+	} {
+		rt := conf.routeFor(path)
+		if assert.NotNil(t, rt, path) {
+			assert.Equal(t, expected, rt.prefix, path)
+		}
+	}
+
+	assert.Nil(t, (&config{routes: routes[:1]}).routeFor("/apiary"))
+}
+
+func TestProxyRoutesStripPrefix(t *testing.T) {
+	ory, upstream := newEchoServer(t), newNamedServer(t, "default")
+	file := writeRoutesFile(t, "routes.yaml", "routes:\n  - prefix: /static/\n    upstream: "+newNamedServer(t, "static").String()+"\n    strip_prefix: true\n")
+	routes, err := parseRoutes(file, []string{
+		"/api=" + newNamedServer(t, "api").String() + "?strip=true",
+		"/keep=" + newNamedServer(t, "keep").String() + "?strip=false",
+	})
+	require.NoError(t, err)
+	for _, rt := range routes {
+		assert.Empty(t, rt.upstream.RawQuery, "the strip parameter must not be sent upstream")
+	}
+	conf := &config{pathPrefix: "/.ory", oryURL: ory, routes: routes}
+
+	for path, expected := range map[string]string{
+		"/api/items":     "api /items",
+		"/api/items/":    "api /items/",
+		"/api":           "api /",
+		"/api/":          "api /",
+		"/api/a%2Fb":     "api /a%2Fb",
+		"/apiary":        "default /apiary",
+		"/static/app.js": "static /app.js",
+		"/static/":       "static /",
+		"/keep/items":    "keep /keep/items",
+		"/dashboard":     "default /dashboard",
+		"/":              "default /",
+	} {
+		assert.Equal(t, expected, serveProxy(t, conf, upstream, path), path)
+	}
+
+	t.Run("case=strips on segment boundaries only", func(t *testing.T) {
+		rt, err := newRoute("/api", "http://localhost:8080", true)
+		require.NoError(t, err)
+		u := &url.URL{Path: "/apiary"}
+		rt.strip(u)
+		assert.Equal(t, "/apiary", u.Path)
+	})
+
+	t.Run("case=invalid strip parameter", func(t *testing.T) {
+		_, err := parseRoutes("", []string{"/api=http://localhost:8080?strip=maybe"})
+		assert.ErrorContains(t, err, "strip")
+	})
+}
@@ -0,0 +1,104 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// sessionCache remembers active sessions for a short time, so that not every request needs a call to whoami.
+type sessionCache struct {
+	ttl time.Duration
+
+	sync.Mutex
+	entries   map[string]sessionCacheEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type sessionCacheEntry struct {
+	session   json.RawMessage
+	expiresAt time.Time
+}
+
+func newSessionCache(ttl time.Duration) *sessionCache {
+	return &sessionCache{ttl: ttl, entries: map[string]sessionCacheEntry{}, now: time.Now}
+}
+
+// sessionCacheKey hashes all credentials sent to whoami, so that the cache never holds them in plain text. Requests
+// without credentials have no key.
+func sessionCacheKey(r *http.Request, sessionTokenHeader string) string {
+	h := sha256.New()
+	found := false
+	for _, name := range []string{"Cookie", "Authorization", "X-Session-Token", sessionTokenHeader} {
+		value := ""
+		if len(name) > 0 {
+			value = r.Header.Get(name)
+		}
+		found = found || len(value) > 0
+		_, _ = h.Write([]byte(value))
+		_, _ = h.Write([]byte{0})
+	}
+	if !found {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *sessionCache) get(key string) (json.RawMessage, bool) {
+	if len(key) == 0 {
+		return nil, false
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	} else if !c.now().Before(e.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.session, true
+}
+
+// set caches active sessions until the TTL passes or the session expires, whichever is first. Inactive sessions
+// remove the entry.
+func (c *sessionCache) set(key string, session json.RawMessage) {
+	if len(key) == 0 {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	now := c.now()
+	if now.Sub(c.lastSweep) > c.ttl {
+		for k, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	if !gjson.GetBytes(session, "active").Bool() {
+		delete(c.entries, key)
+		return
+	}
+
+	expiresAt := now.Add(c.ttl)
+	if sessionExpiresAt := gjson.GetBytes(session, "expires_at"); sessionExpiresAt.Exists() && sessionExpiresAt.Time().Before(expiresAt) {
+		expiresAt = sessionExpiresAt.Time()
+	}
+	c.entries[key] = sessionCacheEntry{session: session, expiresAt: expiresAt}
+}
@@ -0,0 +1,100 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/logrusx"
+)
+
+func TestSessionCacheKey(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	assert.Empty(t, sessionCacheKey(r, ""), "requests without credentials are not cached")
+
+	r.Header.Set("Cookie", "ory_session_abc=secret")
+	key := sessionCacheKey(r, "")
+	assert.NotEmpty(t, key)
+	assert.NotContains(t, key, "secret")
+
+	other := httptest.NewRequest("GET", "/", nil)
+	other.Header.Set("Authorization", "ory_session_abc=secret")
+	assert.NotEqual(t, key, sessionCacheKey(other, ""), "the same value in another header is another key")
+
+	custom := httptest.NewRequest("GET", "/", nil)
+	custom.Header.Set("X-Api-Session", "secret")
+	assert.Empty(t, sessionCacheKey(custom, ""))
+	assert.NotEmpty(t, sessionCacheKey(custom, "X-Api-Session"))
+}
+
+func TestSessionCache(t *testing.T) {
+	now := time.Now()
+	c := newSessionCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.set("active", json.RawMessage(testSession))
+	session, ok := c.get("active")
+	require.True(t, ok)
+	assert.JSONEq(t, testSession, string(session))
+
+	c.set("inactive", json.RawMessage(`{"active":false}`))
+	_, ok = c.get("inactive")
+	assert.False(t, ok, "inactive sessions are not cached")
+
+	c.set("active", json.RawMessage(`{"active":false}`))
+	_, ok = c.get("active")
+	assert.False(t, ok, "inactive sessions invalidate the entry")
+
+	c.set("expiring", json.RawMessage(`{"active":true,"expires_at":"`+now.Add(10*time.Second).Format(time.RFC3339Nano)+`"}`))
+	c.set("active", json.RawMessage(testSession))
+
+	now = now.Add(30 * time.Second)
+	_, ok = c.get("expiring")
+	assert.False(t, ok, "entries never outlive the session")
+	_, ok = c.get("active")
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = c.get("active")
+	assert.False(t, ok, "entries expire after the TTL")
+}
+
+func TestCheckOrySessionCache(t *testing.T) {
+	for _, tc := range []struct {
+		ttl           time.Duration
+		expectedCalls int
+	}{
+		{ttl: 0, expectedCalls: 3},
+		{ttl: time.Minute, expectedCalls: 1},
+	} {
+		t.Run("ttl="+tc.ttl.String(), func(t *testing.T) {
+			ws := newWhoamiServer(t, testSession)
+			conf := &config{pathPrefix: "/.ory", sessionCacheTTL: tc.ttl}
+
+			l := logrusx.New("test", "test")
+			signer, keys, err := newSigner(l, conf)
+			require.NoError(t, err)
+			handler := checkOry(conf, l, herodot.NewJSONWriter(l), keys, signer, ws.url)
+
+			for i := 0; i < 3; i++ {
+				r := httptest.NewRequest("GET", "/", nil)
+				r.Header.Set("Cookie", "ory_session_abc=secret")
+
+				var forwarded *http.Request
+				handler(httptest.NewRecorder(), r, func(_ http.ResponseWriter, r *http.Request) { forwarded = r })
+				require.NotNil(t, forwarded)
+				assert.NotEmpty(t, forwarded.Header.Get("Authorization"), "every request gets a token")
+			}
+			assert.Equal(t, tc.expectedCalls, ws.Calls())
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/urlx"
+)
+
+type sessionState int
+
+const (
+	sessionMissing sessionState = iota
+	sessionExpired
+	sessionActive
+)
+
+// classifySession tells a missing session apart from an expired one. Ory answers both with an inactive session, so
+// a session counts as expired if whoami returned one whose expiry lies in the past, or if the request carried
+// session credentials that were not accepted.
+func classifySession(r *http.Request, session []byte, sessionTokenHeader string) sessionState {
+	if gjson.GetBytes(session, "active").Bool() {
+		return sessionActive
+	}
+
+	if expiresAt := gjson.GetBytes(session, "expires_at"); expiresAt.Exists() && expiresAt.Time().Before(time.Now()) {
+		return sessionExpired
+	}
+
+	if hasSessionCredentials(r, sessionTokenHeader) {
+		return sessionExpired
+	}
+
+	return sessionMissing
+}
+
+// acceptsHTML reports whether the request was most likely made by a browser navigating to a page.
+func acceptsHTML(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil && mediaType == "text/html" {
+			return true
+		}
+	}
+	return false
+}
+
+// loginURL returns the URL initializing a browser login flow that returns to the requested page afterwards.
+func loginURL(conf *config, r *http.Request) string {
+	returnTo := urlx.Copy(conf.publicURL)
+	returnTo.Path = r.URL.Path
+	returnTo.RawPath = r.URL.RawPath
+	returnTo.RawQuery = r.URL.RawQuery
+
+	login := urlx.AppendPaths(conf.publicURL, conf.pathPrefix, "/self-service/login/browser")
+	login.RawQuery = url.Values{"return_to": {returnTo.String()}}.Encode()
+	return login.String()
+}
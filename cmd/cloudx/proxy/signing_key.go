@@ -0,0 +1,93 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/gofrs/uuid/v3"
+	"github.com/pkg/errors"
+	"github.com/square/go-jose/v3"
+
+	"github.com/ory/x/jwksx"
+	"github.com/ory/x/logrusx"
+)
+
+// loadOrGenerateSigningKey reads the private JSON Web Key Set from file. If the file does not exist, a new key is
+// generated and written to it, so that the next start signs with the same key. The key ID stored in the file is kept
+// unless kid is set.
+func loadOrGenerateSigningKey(l *logrusx.Logger, file, alg, kid string) (*jose.JSONWebKeySet, error) {
+	raw, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		keys, err := generateSigningKey(l, alg, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err := json.MarshalIndent(keys, "", "  ")
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err := os.WriteFile(file, raw, 0600); err != nil {
+			return nil, errors.Wrapf(err, "unable to write --%s", JWTKeyFileFlag)
+		}
+		l.WithField("path", file).Infof("Wrote the %s JSON Web Key to the key file.", alg)
+		return keys, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "unable to read --%s", JWTKeyFileFlag)
+	}
+
+	keys, err := parseSigningKey(raw)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "--%s %s", JWTKeyFileFlag, file)
+	}
+	if keys.Keys[0].Algorithm != alg {
+		return nil, errors.Errorf("the key in --%s %s is an %s key but --%s is %s", JWTKeyFileFlag, file, keys.Keys[0].Algorithm, JWTAlgorithmFlag, alg)
+	}
+
+	if len(kid) > 0 {
+		keys.Keys[0].KeyID = kid
+	}
+
+	l.WithField("path", file).Infof("Loaded the %s JSON Web Key from the key file.", alg)
+	return keys, nil
+}
+
+// parseSigningKey accepts a JSON Web Key Set with exactly one key, or a single JSON Web Key. The key must be private.
+func parseSigningKey(raw []byte) (*jose.JSONWebKeySet, error) {
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(raw, &keys); err != nil || len(keys.Keys) == 0 {
+		var key jose.JSONWebKey
+		if err := json.Unmarshal(raw, &key); err != nil {
+			return nil, errors.Wrap(err, "unable to decode the JSON Web Key")
+		}
+		keys.Keys = []jose.JSONWebKey{key}
+	}
+
+	if len(keys.Keys) != 1 {
+		return nil, errors.Errorf("the JSON Web Key Set must contain exactly one key but contains %d", len(keys.Keys))
+	} else if keys.Keys[0].IsPublic() {
+		return nil, errors.New("the JSON Web Key must be a private key")
+	} else if len(keys.Keys[0].Algorithm) == 0 {
+		return nil, errors.New("the JSON Web Key must set its algorithm")
+	}
+	return &keys, nil
+}
+
+// generateSigningKey generates a new key with the given key ID, or a random one if kid is empty.
+func generateSigningKey(l *logrusx.Logger, alg, kid string) (*jose.JSONWebKeySet, error) {
+	if len(kid) == 0 {
+		kid = uuid.Must(uuid.NewV4()).String()
+	}
+
+	l.WithField("started_at", time.Now()).Info("")
+	keys, err := jwksx.GenerateSigningKeys(kid, alg, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate JSON Web Key")
+	}
+	l.WithField("completed_at", time.Now()).Infof("%s JSON Web Key generation completed.", alg)
+	return keys, nil
+}
@@ -0,0 +1,128 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/square/go-jose/v3"
+	"github.com/square/go-jose/v3/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/urlx"
+)
+
+func TestSigningKeyFile(t *testing.T) {
+	l := logrusx.New("test", "test")
+
+	t.Run("case=generates the key once and reuses it", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "jwks.json")
+		conf := &config{pathPrefix: "/.ory", jwtKeyFile: file}
+
+		_, first, err := newSigner(l, conf)
+		require.NoError(t, err)
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		_, second, err := newSigner(l, conf)
+		require.NoError(t, err)
+		assert.Equal(t, first.Keys[0].KeyID, second.Keys[0].KeyID)
+
+		rec, _ := serveCheckOry(t, conf, newWhoamiServer(t, testSession).url, httptest.NewRequest("GET", "/.ory/jwks.json", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var served jose.JSONWebKeySet
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+		require.Len(t, served.Keys, 1)
+		assert.Equal(t, first.Keys[0].KeyID, served.Keys[0].KeyID)
+		assert.True(t, served.Keys[0].IsPublic())
+	})
+
+	t.Run("case=loads a single key", func(t *testing.T) {
+		keys, err := generateSigningKey(l, "ES256", "")
+		require.NoError(t, err)
+		raw, err := json.Marshal(keys.Keys[0])
+		require.NoError(t, err)
+		file := filepath.Join(t.TempDir(), "jwk.json")
+		require.NoError(t, os.WriteFile(file, raw, 0600))
+
+		loaded, err := loadOrGenerateSigningKey(l, file, "ES256", "")
+		require.NoError(t, err)
+		assert.Equal(t, keys.Keys[0].KeyID, loaded.Keys[0].KeyID)
+	})
+
+	keys, err := generateSigningKey(l, "ES256", "")
+	require.NoError(t, err)
+	encode := func(v interface{}) string {
+		raw, err := json.Marshal(v)
+		require.NoError(t, err)
+		return string(raw)
+	}
+
+	for _, tc := range []struct {
+		name, contents, alg, expected string
+	}{
+		{name: "not json", contents: "nope", alg: "ES256", expected: "unable to decode"},
+		{name: "public key", contents: encode(publicKeySet(keys)), alg: "ES256", expected: "must be a private key"},
+		{name: "two keys", contents: encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{keys.Keys[0], keys.Keys[0]}}), alg: "ES256", expected: "exactly one key"},
+		{name: "other algorithm", contents: encode(keys), alg: "RS256", expected: "is an ES256 key but --jwt-algorithm is RS256"},
+	} {
+		t.Run("case=rejects "+tc.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "jwks.json")
+			require.NoError(t, os.WriteFile(file, []byte(tc.contents), 0600))
+			_, err := loadOrGenerateSigningKey(l, file, tc.alg, "")
+			assert.ErrorContains(t, err, tc.expected)
+		})
+	}
+}
+
+func TestJWTKeyID(t *testing.T) {
+	l := logrusx.New("test", "test")
+	issuer := urlx.ParseOrPanic("https://example.com")
+
+	headerKeyID := func(t *testing.T, conf *config) (string, string) {
+		sig, keys, err := newSigner(l, conf)
+		require.NoError(t, err)
+		raw, err := mintToken(sig, issuer, json.RawMessage(testSession), conf)
+		require.NoError(t, err)
+		token, err := jwt.ParseSigned(raw)
+		require.NoError(t, err)
+		require.Len(t, token.Headers, 1)
+		return token.Headers[0].KeyID, publicKeySet(keys).Keys[0].KeyID
+	}
+
+	t.Run("case=token header and key set share the generated key id", func(t *testing.T) {
+		header, published := headerKeyID(t, &config{})
+		assert.NotEmpty(t, header)
+		assert.Equal(t, published, header)
+	})
+
+	t.Run("case=uses the configured key id", func(t *testing.T) {
+		header, published := headerKeyID(t, &config{jwtKeyID: "my-key"})
+		assert.Equal(t, "my-key", header)
+		assert.Equal(t, "my-key", published)
+	})
+
+	t.Run("case=reuses the key id stored in the key file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "jwks.json")
+		_, stored, err := newSigner(l, &config{jwtKeyFile: file, jwtKeyID: "stored"})
+		require.NoError(t, err)
+		assert.Equal(t, "stored", stored.Keys[0].KeyID)
+
+		header, published := headerKeyID(t, &config{jwtKeyFile: file})
+		assert.Equal(t, "stored", header)
+		assert.Equal(t, "stored", published)
+
+		header, published = headerKeyID(t, &config{jwtKeyFile: file, jwtKeyID: "override"})
+		assert.Equal(t, "override", header)
+		assert.Equal(t, "override", published)
+	})
+}
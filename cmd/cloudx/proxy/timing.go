@@ -0,0 +1,44 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ory/x/logrusx"
+)
+
+// timingTransport logs the method, path, status, and latency of every round trip to the application. Round trips to
+// Ory are only logged if includeOry is set.
+type timingTransport struct {
+	http.RoundTripper
+	l          *logrusx.Logger
+	oryHost    string
+	includeOry bool
+
+	requestIDHeader string
+}
+
+func (t *timingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == t.oryHost && !t.includeOry {
+		return t.RoundTripper.RoundTrip(req)
+	}
+
+	start := time.Now()
+	res, err := t.RoundTripper.RoundTrip(req)
+	l := t.l.
+		WithField("method", req.Method).
+		WithField("host", req.URL.Host).
+		WithField("path", req.URL.Path).
+		WithField("request_id", req.Header.Get(t.requestIDHeader)).
+		WithField("latency", time.Since(start).String())
+	if err != nil {
+		l.WithError(err).Debug("Upstream request failed.")
+		return nil, err
+	}
+
+	l.WithField("status", res.StatusCode).Debug("Upstream request completed.")
+	return res, nil
+}
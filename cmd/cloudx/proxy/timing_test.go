@@ -0,0 +1,52 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+)
+
+func TestTimingTransport(t *testing.T) {
+	upstream := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusTeapot, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+
+	for name, tc := range map[string]struct {
+		includeOry bool
+		expected   []string
+	}{
+		"without ory": {expected: []string{"/dashboard"}},
+		"with ory":    {includeOry: true, expected: []string{"/dashboard", "/self-service/login/browser"}},
+	} {
+		t.Run("case="+name, func(t *testing.T) {
+			hook := test.NewGlobal()
+			l := logrusx.New("test", "test", logrusx.ForceLevel(logrus.DebugLevel), logrusx.WithHook(hook))
+			transport := &timingTransport{RoundTripper: upstream, l: l, oryHost: "my-project.projects.oryapis.com", includeOry: tc.includeOry}
+
+			for _, target := range []string{"http://localhost:3000/dashboard", "https://my-project.projects.oryapis.com/self-service/login/browser"} {
+				res, err := transport.RoundTrip(httptest.NewRequest("GET", target, nil))
+				require.NoError(t, err)
+				_ = res.Body.Close()
+			}
+
+			var paths []string
+			for _, entry := range hook.AllEntries() {
+				assert.Equal(t, logrus.DebugLevel, entry.Level)
+				assert.Equal(t, http.StatusTeapot, entry.Data["status"])
+				assert.NotEmpty(t, entry.Data["latency"])
+				paths = append(paths, entry.Data["path"].(string))
+			}
+			assert.Equal(t, tc.expected, paths)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/flagx"
+)
+
+// validateTLSFlags makes sure the certificate and key are either both set or both unset.
+func validateTLSFlags(certFile, keyFile string) error {
+	if (len(certFile) == 0) != (len(keyFile) == 0) {
+		return errors.Errorf("--%s and --%s must be set together", TLSCertFlag, TLSKeyFlag)
+	}
+	return nil
+}
+
+// tlsFilesFromFlags returns the certificate and key to serve HTTPS with, which are empty when --no-tls is set.
+func tlsFilesFromFlags(cmd *cobra.Command) (certFile, keyFile string, err error) {
+	if flagx.MustGetBool(cmd, NoTLSFlag) {
+		return "", "", nil
+	}
+
+	certFile, keyFile = flagx.MustGetString(cmd, TLSCertFlag), flagx.MustGetString(cmd, TLSKeyFlag)
+	if err := validateTLSFlags(certFile, keyFile); err != nil {
+		return "", "", err
+	}
+	return certFile, keyFile, nil
+}
+
+// loadTLSConfig loads the certificate to serve HTTPS with. It returns nil if the proxy serves plain HTTP.
+func loadTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	if err := validateTLSFlags(certFile, keyFile); err != nil {
+		return nil, err
+	} else if len(certFile) == 0 {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load the TLS certificate")
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
+
+// certFingerprint returns the SHA-256 fingerprint of the leaf certificate in the format browsers show it in, for
+// example AB:12:....
+func certFingerprint(c *tls.Config) string {
+	if c == nil || len(c.Certificates) == 0 || len(c.Certificates[0].Certificate) == 0 {
+		return ""
+	}
+
+	sum := sha256.Sum256(c.Certificates[0].Certificate[0])
+	parts := make([]string, len(sum))
+	for k, b := range sum {
+		parts[k] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,147 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate writes a self-signed certificate for localhost and its key to temporary files.
+func writeTestCertificate(t *testing.T) (certFile, keyFile string, cert *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile, cert
+}
+
+func TestTLSFilesFromFlags(t *testing.T) {
+	for k, tc := range []struct {
+		args          []string
+		cert, key     string
+		expectedError string
+	}{
+		{},
+		{args: []string{"--tls-cert", "cert.pem", "--tls-key", "key.pem"}, cert: "cert.pem", key: "key.pem"},
+		{args: []string{"--tls-cert", "cert.pem", "--tls-key", "key.pem", "--no-tls"}},
+		{args: []string{"--tls-cert", "cert.pem", "--no-tls"}},
+		{args: []string{"--tls-cert", "cert.pem"}, expectedError: "must be set together"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			cmd := NewProxyCommand("ory", "test")
+			require.NoError(t, cmd.ParseFlags(tc.args))
+
+			cert, key, err := tlsFilesFromFlags(cmd)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.cert, cert)
+			assert.Equal(t, tc.key, key)
+		})
+	}
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	certFile, keyFile, cert := writeTestCertificate(t)
+
+	t.Run("case=plain http without flags", func(t *testing.T) {
+		c, err := loadTLSConfig("", "")
+		require.NoError(t, err)
+		assert.Nil(t, c)
+	})
+
+	t.Run("case=certificate and key must be set together", func(t *testing.T) {
+		_, err := loadTLSConfig(certFile, "")
+		assert.ErrorContains(t, err, "--tls-cert and --tls-key must be set together")
+		_, err = loadTLSConfig("", keyFile)
+		assert.ErrorContains(t, err, "--tls-cert and --tls-key must be set together")
+	})
+
+	t.Run("case=fails on unreadable files", func(t *testing.T) {
+		_, err := loadTLSConfig(filepath.Join(t.TempDir(), "missing.pem"), keyFile)
+		assert.ErrorContains(t, err, "unable to load the TLS certificate")
+	})
+
+	t.Run("case=serves https with the certificate", func(t *testing.T) {
+		c, err := loadTLSConfig(certFile, keyFile)
+		require.NoError(t, err)
+		require.NotNil(t, c)
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		server := &http.Server{
+			Handler:   http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("ok")) }),
+			TLSConfig: c,
+		}
+		go func() { _ = server.ServeTLS(ln, "", "") }()
+		t.Cleanup(func() { _ = server.Close() })
+
+		pool := x509.NewCertPool()
+		pool.AddCert(cert)
+		hc := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+		res, err := hc.Get("https://" + ln.Addr().String())
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", string(body))
+	})
+}
+
+func TestCertFingerprint(t *testing.T) {
+	certFile, keyFile, cert := writeTestCertificate(t)
+	c, err := loadTLSConfig(certFile, keyFile)
+	require.NoError(t, err)
+
+	fp := certFingerprint(c)
+	sum := sha256.Sum256(cert.Raw)
+	assert.Equal(t, fmt.Sprintf("%X", sum[:]), strings.ReplaceAll(fp, ":", ""))
+	assert.Len(t, strings.Split(fp, ":"), sha256.Size)
+
+	assert.Empty(t, certFingerprint(nil))
+}
@@ -0,0 +1,46 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/x/logrusx"
+)
+
+// whoamiTraceTransport logs every session check with a summary of the returned session. Credentials, traits, and
+// the rest of the session are never logged.
+type whoamiTraceTransport struct {
+	http.RoundTripper
+	l *logrusx.Logger
+}
+
+func (t *whoamiTraceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := t.l.WithField("url", req.URL.String())
+
+	res, err := t.RoundTripper.RoundTrip(req)
+	if err != nil {
+		l.WithError(err).Debug("Session check failed.")
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	l.WithField("status", res.StatusCode).
+		WithField("active", gjson.GetBytes(body, "active").Bool()).
+		WithField("subject", gjson.GetBytes(body, "identity.id").String()).
+		WithField("schema_id", gjson.GetBytes(body, "identity.schema_id").String()).
+		WithField("error", gjson.GetBytes(body, "error.reason").String()).
+		Debug("Session check completed.")
+	return res, nil
+}
@@ -0,0 +1,51 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+)
+
+func TestWhoamiTraceTransport(t *testing.T) {
+	const session = `{"active":true,"identity":{"id":"a2a9a3b5","schema_id":"default","traits":{"email":"foo@example.com"}}}`
+	upstream := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(session))}, nil
+	})
+
+	hook := test.NewGlobal()
+	l := logrusx.New("test", "test", logrusx.ForceLevel(logrus.DebugLevel), logrusx.WithHook(hook))
+
+	req := httptest.NewRequest("GET", "https://my-project.projects.oryapis.com/api/kratos/public/sessions/whoami", nil)
+	req.Header.Set("Cookie", "ory_session_abc=secret")
+	res, err := (&whoamiTraceTransport{RoundTripper: upstream, l: l}).RoundTrip(req)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, session, string(body), "the body must still be readable")
+
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, logrus.DebugLevel, entry.Level)
+	assert.Equal(t, http.StatusOK, entry.Data["status"])
+	assert.Equal(t, true, entry.Data["active"])
+	assert.Equal(t, "a2a9a3b5", entry.Data["subject"])
+	assert.Equal(t, "default", entry.Data["schema_id"])
+
+	logged, err := entry.String()
+	require.NoError(t, err)
+	assert.NotContains(t, logged, "foo@example.com")
+	assert.NotContains(t, logged, "secret")
+}
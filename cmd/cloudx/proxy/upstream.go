@@ -0,0 +1,56 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parseUpstream parses the URL of the application. Users often leave out the scheme, for example localhost:3000,
+// which either fails to parse or parses as a URL with the scheme localhost.
+func parseUpstream(raw string) (*url.URL, error) {
+	u, err := url.ParseRequestURI(raw)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0 {
+		return u, nil
+	}
+
+	if !strings.Contains(raw, "://") {
+		return nil, errors.Errorf("the upstream %q has no scheme, did you mean http://%s?", raw, raw)
+	}
+	return nil, errors.Errorf("the upstream must be an absolute http or https URL such as http://localhost:3000 but got: %q", raw)
+}
+
+// checkUpstreamLoop rejects upstreams pointing at the proxy itself, which would forward every request back to the
+// proxy until it runs out of connections.
+func (c *config) checkUpstreamLoop(upstream *url.URL) error {
+	port := upstream.Port()
+	if len(port) == 0 {
+		port = "80"
+		if upstream.Scheme == "https" {
+			port = "443"
+		}
+	}
+	if port != strconv.Itoa(c.port) {
+		return nil
+	}
+
+	if isLocalHost(upstream.Hostname()) || strings.EqualFold(upstream.Hostname(), c.host) ||
+		(c.publicURL != nil && strings.EqualFold(upstream.Hostname(), c.publicURL.Hostname())) {
+		return errors.Errorf("the upstream %s points to the proxy itself on port %d, pass the URL of your application instead or change the port with --%s", upstream, c.port, PortFlag)
+	}
+	return nil
+}
+
+func isLocalHost(host string) bool {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
@@ -0,0 +1,42 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/http/httpguts"
+)
+
+// parseUpstreamHeaders parses `key=value` pairs. The Authorization header carries the JSON Web Token and can not
+// be set, neither can Host, which is controlled with --rewrite-host.
+func parseUpstreamHeaders(values []string) (http.Header, error) {
+	headers := make(http.Header, len(values))
+	for _, value := range values {
+		key, v, ok := strings.Cut(value, "=")
+		if !ok || !httpguts.ValidHeaderFieldName(key) || !httpguts.ValidHeaderFieldValue(v) {
+			return nil, errors.Errorf("the value of --%s must be in the form key=value but got: %s", UpstreamHeaderFlag, value)
+		}
+
+		switch key = http.CanonicalHeaderKey(key); key {
+		case "Authorization", "Host":
+			return nil, errors.Errorf("the header %s is set by the proxy and can not be set with --%s", key, UpstreamHeaderFlag)
+		}
+		headers.Add(key, v)
+	}
+	return headers, nil
+}
+
+// setUpstreamHeaders adds the headers of --upstream-header to requests to the application. They replace headers of
+// the same name sent by the client.
+func (c *config) setUpstreamHeaders(r *http.Request) {
+	if r.URL.Host == c.oryURL.Host {
+		return
+	}
+	for key, values := range c.upstreamHeaders {
+		r.Header[key] = values
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/urlx"
+)
+
+func TestParseUpstreamHeaders(t *testing.T) {
+	headers, err := parseUpstreamHeaders([]string{"x-shared-secret=s3cr=t", "X-Forwarded-Proto=https", "X-Multi=a", "X-Multi=b"})
+	require.NoError(t, err)
+	assert.Equal(t, http.Header{
+		"X-Shared-Secret":   {"s3cr=t"},
+		"X-Forwarded-Proto": {"https"},
+		"X-Multi":           {"a", "b"},
+	}, headers)
+
+	for _, value := range []string{"X-Missing-Value", "=value", "Bad Name=value", "authorization=Bearer token", "Host=example.org"} {
+		t.Run("case=rejects "+value, func(t *testing.T) {
+			_, err := parseUpstreamHeaders([]string{value})
+			assert.ErrorContains(t, err, "--upstream-header")
+		})
+	}
+}
+
+func TestProxyUpstreamHeaders(t *testing.T) {
+	echoHeaders := func(t *testing.T) *httptest.Server {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, "%s %s", r.Header.Get("X-Shared-Secret"), r.Header.Get("X-Forwarded-Proto"))
+		}))
+		t.Cleanup(ts.Close)
+		return ts
+	}
+	ory, upstream := echoHeaders(t), echoHeaders(t)
+
+	conf := &config{
+		pathPrefix:      "/.ory",
+		oryURL:          urlx.ParseOrPanic(ory.URL),
+		bypassPaths:     []string{"/public"},
+		upstreamHeaders: http.Header{"X-Shared-Secret": {"s3cret"}, "X-Forwarded-Proto": {"https"}},
+	}
+
+	assert.Equal(t, "s3cret https", serveProxy(t, conf, urlx.ParseOrPanic(upstream.URL), "/dashboard"))
+	assert.Equal(t, "s3cret https", serveProxy(t, conf, urlx.ParseOrPanic(upstream.URL), "/public/index.html"))
+	assert.Equal(t, " ", serveProxy(t, conf, urlx.ParseOrPanic(upstream.URL), "/.ory/sessions/whoami"), "requests to Ory do not get the headers")
+}
@@ -0,0 +1,62 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/urlx"
+)
+
+func TestParseUpstream(t *testing.T) {
+	for _, raw := range []string{"http://localhost:3000", "https://app.example.org/base"} {
+		u, err := parseUpstream(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, raw, u.String())
+	}
+
+	for _, tc := range []struct {
+		raw, expected string
+	}{
+		{raw: "localhost:3000", expected: "has no scheme, did you mean http://localhost:3000?"},
+		{raw: "127.0.0.1:3000", expected: "has no scheme, did you mean http://127.0.0.1:3000?"},
+		{raw: "my-app", expected: "has no scheme, did you mean http://my-app?"},
+		{raw: "ftp://localhost:3000", expected: "must be an absolute http or https URL"},
+		{raw: "http://", expected: "must be an absolute http or https URL"},
+	} {
+		t.Run("case="+tc.raw, func(t *testing.T) {
+			_, err := parseUpstream(tc.raw)
+			assert.ErrorContains(t, err, tc.expected)
+		})
+	}
+}
+
+func TestCheckUpstreamLoop(t *testing.T) {
+	conf := &config{port: 4000, host: "192.168.1.10", publicURL: urlx.ParseOrPanic("http://dev.example.org:4000")}
+
+	for _, upstream := range []string{
+		"http://localhost:4000",
+		"http://127.0.0.1:4000/app",
+		"http://[::1]:4000",
+		"http://0.0.0.0:4000",
+		"http://app.localhost:4000",
+		"http://192.168.1.10:4000",
+		"http://dev.example.org:4000",
+	} {
+		assert.ErrorContains(t, conf.checkUpstreamLoop(urlx.ParseOrPanic(upstream)), "points to the proxy itself", upstream)
+	}
+
+	for _, upstream := range []string{
+		"http://localhost:3000",
+		"http://localhost",
+		"https://example.org:4000",
+	} {
+		assert.NoError(t, conf.checkUpstreamLoop(urlx.ParseOrPanic(upstream)), upstream)
+	}
+
+	assert.Error(t, (&config{port: 80}).checkUpstreamLoop(urlx.ParseOrPanic("http://localhost")), "the default port of the scheme counts")
+}
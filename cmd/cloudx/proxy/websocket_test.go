@@ -0,0 +1,88 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/urlx"
+)
+
+func TestWebSocketPassthrough(t *testing.T) {
+	var authorization string
+	upgrader := websocket.Upgrader{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			kind, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(kind, message); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	ws := newWhoamiServer(t, testSession)
+	conf := &config{pathPrefix: "/.ory", oryURL: ws.url, publicURL: urlx.ParseOrPanic("http://localhost:4000")}
+	l := logrusx.New("test", "test")
+	signer, keys, err := newSigner(l, conf)
+	require.NoError(t, err)
+
+	check := checkOry(conf, l, herodot.NewJSONWriter(l), keys, signer, ws.url)
+	handler := newProxyHandler(conf, l, urlx.ParseOrPanic(upstream.URL), "")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		check(w, r, handler.ServeHTTP)
+	}))
+	t.Cleanup(ts.Close)
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	u.Scheme = "ws"
+	u.Path = "/socket"
+
+	conn, res, err := websocket.DefaultDialer.Dial(u.String(), http.Header{"Cookie": {"ory_session_abc=session"}})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+
+	for _, message := range []string{"hello", strings.Repeat("a", 1<<16)} {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(message)))
+		_, echoed, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, message, string(echoed))
+	}
+
+	assert.Empty(t, authorization, "no JSON Web Token is minted for upgrades")
+	assert.Zero(t, ws.Calls(), "upgrades do not check the session")
+}
+
+func TestIsUpgrade(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	assert.False(t, isUpgrade(r))
+
+	r.Header.Set("Connection", "keep-alive, Upgrade")
+	assert.False(t, isUpgrade(r))
+
+	r.Header.Set("Upgrade", "websocket")
+	assert.True(t, isUpgrade(r))
+}
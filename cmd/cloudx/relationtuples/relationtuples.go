@@ -127,4 +127,5 @@ func wrapForOryCLI(cmd *cobra.Command) {
 	client.RegisterProjectFlag(cmd.Flags())
 	forwardConnectionInfo(cmd)
 	hideKetoFlags(cmd)
+	client.WrapJSONErrors(cmd)
 }
@@ -0,0 +1,26 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cloudx
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ory/cli/cmd/cloudx/project"
+	"github.com/ory/x/cmdx"
+)
+
+func NewResolveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resolve",
+		Short: "Resolve Ory Network resources",
+	}
+
+	cmd.AddCommand(
+		project.NewResolveSlugCmd(),
+	)
+
+	cmdx.RegisterNoiseFlags(cmd.PersistentFlags())
+
+	return cmd
+}
@@ -6,6 +6,8 @@ package cloudx
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/ory/cli/cmd/cloudx/client"
+	"github.com/ory/cli/cmd/cloudx/identity"
 	"github.com/ory/cli/cmd/cloudx/oauth2"
 	"github.com/ory/x/cmdx"
 )
@@ -15,8 +17,14 @@ func NewRevokeCmd() *cobra.Command {
 		Use:   "revoke",
 		Short: "Revoke resources",
 	}
-	cmd.AddCommand(oauth2.NewRevokeToken())
+	cmd.AddCommand(
+		oauth2.NewRevokeToken(),
+		identity.NewRevokeSessionCmd(),
+	)
 
+	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
+	client.RegisterYesFlag(cmd.PersistentFlags())
 	cmdx.RegisterHTTPClientFlags(cmd.PersistentFlags())
 	cmdx.RegisterFormatFlags(cmd.PersistentFlags())
 	return cmd
@@ -19,9 +19,12 @@ func NewUseCmd() *cobra.Command {
 
 	cmd.AddCommand(
 		project.NewUseProjectCmd(),
+		NewUseConsoleURLCmd(),
+		NewUseFormatCmd(),
 	)
 
 	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
 	client.RegisterYesFlag(cmd.PersistentFlags())
 	cmdx.RegisterNoiseFlags(cmd.PersistentFlags())
 	cmdx.RegisterJSONFormatFlags(cmd.PersistentFlags())
@@ -0,0 +1,43 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cloudx
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+)
+
+func NewUseConsoleURLCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "console-url [url]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Set the Ory Network console URL in the configuration file. When no URL is provided, prints the URL in use.",
+		Long: `Set the Ory Network console URL in the configuration file. The --console-url flag and the
+ORY_CONSOLE_URL environment variable take precedence over it. Pass an empty string to remove it.
+The URL is kept when signing in or out.`,
+		Example: `$ ory use console-url https://console.staging.ory.dev
+
+https://console.staging.ory.dev
+
+$ ory use console-url ""
+
+https://console.ory.sh`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				if err := client.SetConsoleURL(cmd, args[0]); err != nil {
+					return err
+				}
+			}
+			if err := client.ValidateConsoleURL(cmd); err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), client.ConsoleURL(cmd).String())
+			return nil
+		},
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright © 2023 Ory Corp
+// SPDX-License-Identifier: Apache-2.0
+
+package cloudx
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ory/cli/cmd/cloudx/client"
+	"github.com/ory/x/cmdx"
+)
+
+func NewUseFormatCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "format [format]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Set the default output format in the configuration file. When no format is provided, prints the default in use.",
+		Long: `Set the default of the --format flag of the Ory Network commands in the configuration file.
+An explicit --format on the command line still wins. Pass an empty string to remove it. The
+format is kept when signing in or out.`,
+		Example: `$ ory use format json
+
+json
+
+$ ory list projects
+
+[{"id":"ecaaa3cb-0730-4ee8-a6df-9553cdfeef89", ...}]`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h, err := client.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 1 {
+				if err := h.SetDefaultFormat(args[0]); err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), args[0])
+				return nil
+			}
+
+			// The helper already applied the stored format to the flag, unless it was passed explicitly.
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), cmd.Flags().Lookup(cmdx.FlagFormat).Value.String())
+			return nil
+		},
+	}
+}
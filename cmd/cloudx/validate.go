@@ -7,7 +7,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/ory/cli/cmd/cloudx/client"
-	"github.com/ory/kratos/cmd/identities"
+	"github.com/ory/cli/cmd/cloudx/identity"
 	"github.com/ory/x/cmdx"
 )
 
@@ -17,9 +17,10 @@ func NewValidateCmd() *cobra.Command {
 		Short: "Validate resources",
 	}
 
-	cmd.AddCommand(identities.NewValidateIdentityCmd())
+	cmd.AddCommand(identity.NewValidateIdentityCmd())
 
 	client.RegisterConfigFlag(cmd.PersistentFlags())
+	client.RegisterConsoleURLFlag(cmd.PersistentFlags())
 	client.RegisterYesFlag(cmd.PersistentFlags())
 	cmdx.RegisterNoiseFlags(cmd.PersistentFlags())
 	cmdx.RegisterJSONFormatFlags(cmd.PersistentFlags())
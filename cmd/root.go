@@ -32,6 +32,9 @@ func NewRootCmd() *cobra.Command {
 		jsonnet.NewFormatCmd(),
 		jsonnet.NewLintCmd(),
 		cloudx.NewDeleteCmd(),
+		cloudx.NewDiffCmd(),
+		cloudx.NewDoctorCmd(),
+		cloudx.NewExportCmd(),
 		cloudx.NewGetCmd(),
 		cloudx.NewUseCmd(),
 		cloudx.NewListCmd(),
@@ -40,8 +43,11 @@ func NewRootCmd() *cobra.Command {
 		cloudx.NewPatchCmd(),
 		cloudx.NewParseCmd(),
 		cloudx.NewPerformCmd(),
+		cloudx.NewResolveCmd(),
+		cloudx.NewSetCmd(),
 		proxy.NewProxyCommand("ory", buildinfo.Version),
 		proxy.NewTunnelCommand("ory", buildinfo.Version),
+		proxy.NewWhoamiCommand("ory"),
 		cloudx.NewUpdateCmd(),
 		cloudx.NewValidateCmd(),
 		cloudx.NewRevokeCmd(),
@@ -49,6 +55,8 @@ func NewRootCmd() *cobra.Command {
 		cloudx.NewIsCmd(),
 		versionCmd,
 	)
+	client.RegisterJSONErrorsFlag(c)
+	client.RegisterNoColorFlag(c)
 	cmdx.EnableUsageTemplating(c)
 
 	return c
@@ -58,7 +66,11 @@ func Execute() {
 	ctx := client.ContextWithClient(context.Background())
 	rootCmd := NewRootCmd()
 	if err := rootCmd.ExecuteContext(ctx); err != nil {
-		if !errors.Is(err, cmdx.ErrNoPrintButFail) {
+		if errors.Is(err, cmdx.ErrNoPrintButFail) {
+			// The error was already printed.
+		} else if client.JSONErrors(rootCmd) {
+			client.PrintJSONError(rootCmd.ErrOrStderr(), err)
+		} else {
 			_, _ = fmt.Fprintln(rootCmd.ErrOrStderr(), err)
 		}
 		os.Exit(1)